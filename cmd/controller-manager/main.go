@@ -39,6 +39,8 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller/tidbmonitor"
 	"github.com/pingcap/tidb-operator/pkg/controller/tidbngmonitoring"
 	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/startscript"
+	startscriptv2 "github.com/pingcap/tidb-operator/pkg/manager/member/startscript/v2"
 	"github.com/pingcap/tidb-operator/pkg/metrics"
 	"github.com/pingcap/tidb-operator/pkg/scheme"
 	"github.com/pingcap/tidb-operator/pkg/upgrader"
@@ -149,6 +151,7 @@ func main() {
 	if err != nil {
 		klog.Fatalf("failed to create Dependencies: %s", err)
 	}
+	startscriptv2.SetTemplateSource(startscript.NewConfigMapTemplateSource(deps.ConfigMapLister))
 
 	onStarted := func(ctx context.Context) {
 		// Upgrade before running any controller logic. If it fails, we wait
@@ -98,6 +98,41 @@ const (
 	AnnPDDeferDeleting = "tidb.pingcap.com/pd-defer-deleting"
 	// AnnSysctlInit is pod annotation key to indicate whether configuring sysctls with init container
 	AnnSysctlInit = "tidb.pingcap.com/sysctl-init"
+	// AnnNumaNode is pod annotation key to indicate the NUMA node which the TiKV server is bound to by numactl
+	AnnNumaNode = "tidb.pingcap.com/numa-node"
+	// AnnTiKVRecoverMode is pod annotation key to indicate that TiKV is started in recover mode,
+	// it is set during the recovery of a store whose disk is replaced, the start script prints a banner in this mode
+	AnnTiKVRecoverMode = "tidb.pingcap.com/tikv-recover-mode"
+	// AnnTiKVBinaryPath is pod annotation key to indicate the path of tikv-server binary in the image,
+	// it is used by custom images which install the binary elsewhere
+	AnnTiKVBinaryPath = "tidb.pingcap.com/tikv-binary-path"
+	// AnnTiKVArchBinaryPaths is pod annotation key to indicate the paths of tikv-server binary per arch in multi-arch
+	// images, e.g. "amd64=/amd64/tikv-server,arm64=/arm64/tikv-server". The start script selects the path by the
+	// arch reported by `uname -m` at runtime, the binary path is used for the other arches
+	AnnTiKVArchBinaryPaths = "tidb.pingcap.com/tikv-arch-binary-paths"
+	// AnnTiKVExecAttempts is pod annotation key to indicate the number of attempts to exec tikv-server,
+	// the start script retries on transient failures of exec if it is greater than 1
+	AnnTiKVExecAttempts = "tidb.pingcap.com/tikv-exec-attempts"
+	// AnnTiKVTextfileDir is pod annotation key to indicate the textfile dir of node-exporter mounted to TiKV,
+	// the start script writes the checksum of its model to the dir for the detection of config drift
+	AnnTiKVTextfileDir = "tidb.pingcap.com/tikv-textfile-dir"
+	// AnnTiKVReadinessFile is pod annotation key to indicate the file which the start script touches once the
+	// status server of TiKV is up, which means the store is registered to PD. It defaults to /tmp/tikv-ready if empty.
+	AnnTiKVReadinessFile = "tidb.pingcap.com/tikv-readiness-file"
+	// AnnTiKVInMemoryEngine is pod annotation key to indicate the mount path of a memory-backed volume, e.g. an
+	// emptyDir with medium Memory in additionalVolumes, which is used as the data dir of TiKV to enable the
+	// in-memory engine. It is experimental and the data is lost once the pod is restarted.
+	AnnTiKVInMemoryEngine = "tidb.pingcap.com/tikv-in-memory-engine"
+	// AnnTiKVPDSRVDomain is pod annotation key to indicate the domain whose SRV records `_pd._tcp.<domain>` publish
+	// the PD endpoints, the start script of TiKV resolves them by dig and falls back to the PD service if none is resolved.
+	AnnTiKVPDSRVDomain = "tidb.pingcap.com/tikv-pd-srv-domain"
+	// AnnTiKVMetricsPushGateway is pod annotation key to indicate the URL of a Prometheus pushgateway, e.g.
+	// http://pushgateway:9091, the start script of TiKV starts a loop in background which scrapes the metrics
+	// from the status server and pushes them to the gateway, for the networks where TiKV can not be scraped.
+	AnnTiKVMetricsPushGateway = "tidb.pingcap.com/tikv-metrics-push-gateway"
+	// AnnTiKVMetricsPushInterval is pod annotation key to indicate the seconds between two pushes of the metrics
+	// of TiKV to the gateway of AnnTiKVMetricsPushGateway, it defaults to 15.
+	AnnTiKVMetricsPushInterval = "tidb.pingcap.com/tikv-metrics-push-interval"
 	// AnnEvictLeaderBeginTime is pod annotation key to indicate the begin time for evicting region leader
 	AnnEvictLeaderBeginTime = "tidb.pingcap.com/evictLeaderBeginTime"
 	// AnnTiCDCGracefulShutdownBeginTime is pod annotation key to indicate the begin time for graceful shutdown TiCDC
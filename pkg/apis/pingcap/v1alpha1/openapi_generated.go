@@ -28,6 +28,7 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AcrossK8sVerificationSpec":     schema_pkg_apis_pingcap_v1alpha1_AcrossK8sVerificationSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AutoResource":                  schema_pkg_apis_pingcap_v1alpha1_AutoResource(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AutoRule":                      schema_pkg_apis_pingcap_v1alpha1_AutoRule(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AzblobStorageProvider":         schema_pkg_apis_pingcap_v1alpha1_AzblobStorageProvider(ref),
@@ -116,6 +117,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SecretRef":                     schema_pkg_apis_pingcap_v1alpha1_SecretRef(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Security":                      schema_pkg_apis_pingcap_v1alpha1_Security(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ServiceSpec":                   schema_pkg_apis_pingcap_v1alpha1_ServiceSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SidecarReadinessSpec":          schema_pkg_apis_pingcap_v1alpha1_SidecarReadinessSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Status":                        schema_pkg_apis_pingcap_v1alpha1_Status(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StmtSummary":                   schema_pkg_apis_pingcap_v1alpha1_StmtSummary(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageClaim":                  schema_pkg_apis_pingcap_v1alpha1_StorageClaim(ref),
@@ -126,6 +128,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec":                     schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBAccessConfig":              schema_pkg_apis_pingcap_v1alpha1_TiDBAccessConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfig":                    schema_pkg_apis_pingcap_v1alpha1_TiDBConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBPreStopSpec":               schema_pkg_apis_pingcap_v1alpha1_TiDBPreStopSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec":               schema_pkg_apis_pingcap_v1alpha1_TiDBServiceSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec":         schema_pkg_apis_pingcap_v1alpha1_TiDBSlowLogTailerSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec":                      schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref),
@@ -141,11 +144,14 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVCoprocessorReadPoolConfig": schema_pkg_apis_pingcap_v1alpha1_TiKVCoprocessorReadPoolConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVDbConfig":                  schema_pkg_apis_pingcap_v1alpha1_TiKVDbConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVEncryptionConfig":          schema_pkg_apis_pingcap_v1alpha1_TiKVEncryptionConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVEncryptionSecretConfig":    schema_pkg_apis_pingcap_v1alpha1_TiKVEncryptionSecretConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVGCConfig":                  schema_pkg_apis_pingcap_v1alpha1_TiKVGCConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVImportConfig":              schema_pkg_apis_pingcap_v1alpha1_TiKVImportConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVLogFileSpec":               schema_pkg_apis_pingcap_v1alpha1_TiKVLogFileSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVMasterKeyConfig":           schema_pkg_apis_pingcap_v1alpha1_TiKVMasterKeyConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPDConfig":                  schema_pkg_apis_pingcap_v1alpha1_TiKVPDConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPessimisticTxn":            schema_pkg_apis_pingcap_v1alpha1_TiKVPessimisticTxn(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPreStopSpec":               schema_pkg_apis_pingcap_v1alpha1_TiKVPreStopSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVRaftDBConfig":              schema_pkg_apis_pingcap_v1alpha1_TiKVRaftDBConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVRaftstoreConfig":           schema_pkg_apis_pingcap_v1alpha1_TiKVRaftstoreConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVReadPoolConfig":            schema_pkg_apis_pingcap_v1alpha1_TiKVReadPoolConfig(ref),
@@ -448,6 +454,54 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_AcrossK8sVerificationSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AcrossK8sVerificationSpec contains the retry bounds of verifying the PD endpoints through the discovery service",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout (in seconds) of each verification request Defaults to 3",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxRetries": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxRetries is the max retries of the verification, the start script exits with a nonzero code after it so that the pod is restarted and the failure is surfaced. Defaults to 0 (retry until the verification succeeds)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxBackoff": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxBackoff (in seconds) is the ceiling of the random backoff between two retries Defaults to 5",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"caBundlePath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CABundlePath is the path of the CA bundle in the containers to verify the certificate of discovery, the start scripts access discovery by https if it is set, e.g. when discovery is behind a TLS proxy with a private CA. The CA bundle is not mounted by the operator, mount it to the components by additionalVolumes and additionalVolumeMounts. Defaults to \"\" (access discovery by http)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"verbose": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Verbose makes the start scripts echo the PD endpoints finally used by the components to stderr with the \"[start-script] effective PD endpoints:\" prefix, e.g. to diagnose the bring-up across k8s. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_AutoResource(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2952,6 +3006,13 @@ func schema_pkg_apis_pingcap_v1alpha1_DiscoverySpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"port": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Port used by start scripts to access the discovery service, it can be different from the port of discovery service if discovery is accessed through a sidecar or service mesh. Only works with start script v2. Defaults to 10261",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 			},
 		},
@@ -7938,6 +7999,35 @@ func schema_pkg_apis_pingcap_v1alpha1_ServiceSpec(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_SidecarReadinessSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SidecarReadinessSpec contains the health endpoint of a sidecar which start scripts v2 wait for",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the health endpoint of the sidecar which returns 2xx once the sidecar is ready, e.g. http://localhost:15021/healthz/ready for Istio",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout (in seconds) of waiting for the sidecar, the start script exits with a nonzero code after it so that the container is restarted. Defaults to 0 (wait until the sidecar is ready)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_Status(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -8617,6 +8707,13 @@ func schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref common.ReferenceCallback) co
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
 						},
 					},
+					"dataVolumeName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DataVolumeName is the name of the volume used as the data dir of TiCDC, it should be one of storageVolumes or additionalVolumes which is mounted to the TiCDC container. The sort dir of TiCDC is also placed in the data dir. Only works with start script v2. Defaults to \"\" (use the default data dir of TiCDC)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
@@ -8990,6 +9087,26 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBConfig(ref common.ReferenceCallback) c
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiDBPreStopSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiDBPreStopSpec contains the parameters of the TiDB preStop hook",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout (in seconds) of waiting for the client connections to be closed, terminationGracePeriodSeconds of TiDB pods should be longer than it. Defaults to 60",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiDBServiceSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -9548,12 +9665,18 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
+					"preStop": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreStop configures the preStop hook of TiDB, which labels the server as draining and waits for the client connections to be closed before the TiDB container is stopped. It is ignored if the preStop handler is set in Lifecycle. Defaults to nil (no preStop hook)",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBPreStopSpec"),
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Probe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBInitializer", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBTLSClient", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Probe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBInitializer", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBPreStopSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBTLSClient", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
@@ -10026,6 +10149,13 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy"),
 						},
 					},
+					"basePortOffset": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BasePortOffset is added to all the ports of TiFlash (tcp, http, flash, proxy, metrics, proxy status and internal), e.g. to run TiFlash beside another one in host network. Defaults to 0 (use the default ports)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 				Required: []string{"replicas", "storageClaims"},
 			},
@@ -10926,6 +11056,35 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVEncryptionConfig(ref common.ReferenceC
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVEncryptionSecretConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVEncryptionSecretConfig references the secrets which store the master keys of TiKV encryption at rest. The master key is read from the `master-key` key of the secret.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"masterKeySecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MasterKeySecretName is the name of the secret which stores the current master key",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"previousMasterKeySecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreviousMasterKeySecretName is the name of the secret which stores the previous master key, it is only needed when rotating the master key",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"masterKeySecretName"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiKVGCConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -11029,6 +11188,42 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVImportConfig(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVLogFileSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVLogFileSpec contains the log file and its rotation settings of TiKV. The rotation settings are only applied if `log.file` is not set in the config file of TiKV.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path is the absolute path of the log file, it should be in a persistent volume, e.g. /var/lib/tikv/log/tikv.log",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"maxSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxSize is the max size in MB of the log file before it is rotated Defaults to 0 (use the default of TiKV)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxBackups": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxBackups is the max number of the rotated log files to keep Defaults to 0 (use the default of TiKV)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiKVMasterKeyConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -11184,6 +11379,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVPessimisticTxn(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVPreStopSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVPreStopSpec contains the parameters of the TiKV preStop hook",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"leaderCountThreshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LeaderCountThreshold is the leader count of the store, the preStop hook stops waiting once the leader count drops to or below it. Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout (in seconds) of the preStop hook, including looking up PD and waiting for the leader eviction, terminationGracePeriodSeconds of TiKV pods should be longer than it. Defaults to 300",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiKVRaftDBConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -12453,7 +12675,22 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 					},
 					"storeLabels": {
 						SchemaProps: spec.SchemaProps{
-							Description: "StoreLabels configures additional labels for TiKV stores.",
+							Description: "StoreLabels configures additional labels for TiKV stores. A plain key is looked up in the labels of the node where the TiKV Pod is scheduled, while an entry in the form of `key=value` is passed to tikv-server as a static label by the v2 start script.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"podLabelsAsStoreLabels": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodLabelsAsStoreLabels are the keys of Pod labels passed to tikv-server as store labels, the labels are read from the file mounted by the downward API when TiKV starts. The labels configured by storeLabels or the STORE_LABELS env take precedence over them. Only works with start script v2.",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -12480,12 +12717,203 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy"),
 						},
 					},
+					"statusListenHost": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StatusListenHost is the host that the status server of TiKV listens on, e.g. the Pod IP in network-policy-restricted environments. A link-local IPv6 address can carry a zone index, e.g. fe80::1%eth0. Only works with start script v2. Defaults to the host that the TiKV server listens on",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"disableStatusServer": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DisableStatusServer disables the status server of TiKV in security-hardened deployments, it can not be used together with the dynamic configuration which advertises the status address. Only works with start script v2. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"startTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout threshold when tikv get started Defaults to the start timeout of pd",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"walVolumeName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional volume name configuration for RocksDB WAL, it should be one of storageVolumes or additionalVolumes which is mounted to the TiKV container. The mount path of the volume is used as `rocksdb.wal-dir` unless it is set in the config. Defaults to \"\" (WAL is stored in the data dir)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"titanVolumeName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional volume name configuration for the blob files of Titan, it should be one of storageVolumes or additionalVolumes which is mounted to the TiKV container. If Titan is enabled by `rocksdb.titan.enabled` in the config, the mount path of the volume is used as `rocksdb.titan.dirname` unless it is set in the config. Defaults to \"\" (the blob files are stored in the data dir)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"raftEngineSubDir": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional sub dir for the logs of Raft Engine in the volume named \"raft\", which should be one of storageVolumes or additionalVolumes mounted to the TiKV container. If the volume is mounted, the dir is used as `raft-engine.dir` unless it is set in the config. Only works with start script v2. Defaults to \"\" (the mount path of the raft volume)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"encryptionConfig": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EncryptionConfig references the secrets which store the master keys of encryption at rest, the secrets are mounted to TiKV and used as the file master keys. The master keys can not be set in the config at the same time.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVEncryptionSecretConfig"),
+						},
+					},
+					"preStop": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreStop configures the preStop hook of TiKV, which evicts the leaders of the store through PD before the TiKV container is stopped. The evict leader scheduler created by the hook is kept while TiKV is stopped, and it is removed by the start script after TiKV restarts. Defaults to nil (no preStop hook)",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPreStopSpec"),
+						},
+					},
+					"advertiseHostSuffix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AdvertiseHostSuffix overrides the DNS suffix of the host advertised by TiKV, the advertised host is ${POD_NAME}.{advertiseHostSuffix} if it is set. It is useful if TiKV is resolved by an external DNS zone instead of the peer service. Only works with start script v2. Defaults to \"\" (use the peer service of TiKV, e.g. ${POD_NAME}.{cluster}-tikv-peer.{namespace}.svc)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"peerServiceName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PeerServiceName overrides the name of the headless service in the host advertised by TiKV, it is used by clusters imported or migrated with a legacy peer service name. The service must be the one that the Pods are resolved by, it is not created by the operator. It is ignored if AdvertiseHostSuffix is set. Only works with start script v2. Defaults to \"\" (use {cluster}-tikv-peer)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"advertisePort": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AdvertisePort is the port advertised by TiKV for the deployments behind NAT or port mapping, TiKV still listens on the default port 20160. Only works with start script v2. Defaults to 0 (advertise the listening port)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"preComputeCapacity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreComputeCapacity indicates whether to compute the capacity of TiKV from the storage request when rendering the start script, instead of reading it from the CAPACITY env of the container. The capacity is rendered in bytes, and \"0\" (unlimited) is rendered if the storage request is not set. Only works with start script v2. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"logLevel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LogLevel is the log level passed to TiKV by the start script, it overrides the log level in the config file if it is set. Only works with start script v2. Defaults to \"\" (use the log level in the config file)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"logFile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LogFile makes TiKV log to a file with rotation instead of stdout, e.g. for a log shipper reading the files from the data volume. Only works with start script v2. Defaults to nil (log to stdout)",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVLogFileSpec"),
+						},
+					},
+					"additionalStartupFlags": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AdditionalStartupFlags are the flags appended to the command line of TiKV by the start script, e.g. \"--security-redact-info-log\". They are appended in order after the flags managed by the operator, and the command line flags take precedence over the settings in the config file. Each flag is quoted and passed to TiKV as one argument, shell variables and commands in it are not expanded. Only works with start script v2.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"fixDataDirPermissions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FixDataDirPermissions indicates whether the start script sets the owner of the data dir to the user running TiKV and removes the group and other write permissions from it before starting TiKV, the files created by TiKV are also not group or other writable. Only the data dir is changed, the other directories of the data volume are not touched. Only works with start script v2. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"checkDataDirFsync": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CheckDataDirFsync indicates whether the start script writes and fsyncs a small file in the data dir before starting TiKV, TiKV is not started if it fails, e.g. when the volume is broken or read-only. Note that it can not detect the storage which acknowledges fsync without persisting the data. Only works with start script v2. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"minFreeSpace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinFreeSpace is the minimum free space of the data volume required to start TiKV, the start script checks the free space of the data dir by df and exits before starting TiKV if it is less than this value. Only works with start script v2. Defaults to nil (no check)",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+					"startScriptPrologue": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartScriptPrologue is the shell script inserted verbatim into the start script of TiKV before composing the arguments of TiKV, e.g. to do the vendor-specific setup of the node. It must not only contain whitespace if it is set. Only works with start script v2.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"sizeThreadPoolsByCPULimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SizeThreadPoolsByCPULimit indicates whether the start script sizes the unified read pool, which runs the coprocessor requests, and the scheduler worker pool of TiKV by the CPU limit of the container. It has no effect if the TiKVCPUQuota feature flag or the CPU limit is not set, or the pools are already configured in the config file, TiKV sizes the pools by itself in these cases. Only works with start script v2. Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"storageAPIVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StorageAPIVersion is the storage.api-version of TiKV, API V2 is required by some features of RawKV, e.g. TTL. It can only be set when TiKV is bootstrapped, the start script refuses to start TiKV if the api version of the existing data is different. Only works with start script v2. Defaults to the api version in the config file, which is 1 if not set",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"reserveSpacePercent": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReserveSpacePercent is the percentage of the capacity of TiKV reserved as storage.reserve-space, so that the reserved space is scaled with the volume instead of being a fixed size in the config file. The capacity is the --capacity of TiKV, or the size of the data volume if the capacity is not set. It has no effect if storage.reserve-space is already set in the config file. Only works with start script v2. Defaults to 0 (the storage.reserve-space in the config file, which defaults to 5GB in TiKV)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"gcNumThreads": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GCNumThreads is the gc.num-threads of TiKV, which is the number of the background GC workers, large clusters need more of them to keep up with the GC of the MVCC versions. It has no effect if gc.num-threads is already set in the config file. Only works with start script v2. Defaults to 0 (the gc.num-threads in the config file, which defaults to 1 in TiKV)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"regionSplitSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionSplitSize is the coprocessor.region-split-size of TiKV, which is the size of the regions split from a large region, the workloads with huge values may use larger regions to reduce the number of regions. It must be a multiple of 1Mi. The regions smaller than the schedule.max-merge-region-size of PD are merged, which should be tuned in the config of PD together with it. It has no effect if region-split-size or region-max-size is already set in the config file. Only works with start script v2. Defaults to nil (the coprocessor.region-split-size in the config file, which defaults to 96MiB in TiKV)",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+					"regionMaxSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionMaxSize is the coprocessor.region-max-size of TiKV, a region is split if it is larger than it. It must be a multiple of 1Mi and not be less than RegionSplitSize. It has no effect if region-split-size or region-max-size is already set in the config file. Only works with start script v2. Defaults to nil (the coprocessor.region-max-size in the config file, which defaults to 3/2 of the region-split-size in TiKV)",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+					"blockCacheMemoryPercent": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BlockCacheMemoryPercent is the percentage of the memory limit of the TiKV container set as the storage.block-cache.capacity of TiKV, so that the block cache is resized with the Pod instead of being a fixed size in the config file. It has no effect if the memory limit is not set or storage.block-cache.capacity is already set in the config file. Only works with start script v2. Defaults to 0 (the storage.block-cache.capacity in the config file, which is sized by TiKV from the memory of the container if not set)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"startupDelaySeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartupDelaySeconds is the number of seconds the start script sleeps before any network operation, e.g. when the Pod network is not routable right after the Pod is started with some CNI plugins. Only works with start script v2. Defaults to 0 (no delay)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Failover", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Probe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Failover", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Probe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVEncryptionSecretConfig", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVLogFileSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPreStopSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -13838,6 +14266,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 							Format:      "",
 						},
 					},
+					"acrossK8sVerification": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AcrossK8sVerification configures how the start scripts retry verifying the PD endpoints through the discovery service when the cluster is deployed across multiple Kubernetes clusters. Only works with start script v2.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AcrossK8sVerificationSpec"),
+						},
+					},
 					"cluster": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Cluster is the external cluster, if configured, the components in this TidbCluster will join to this configured cluster.",
@@ -13923,7 +14357,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 					},
 					"startScriptV2FeatureFlags": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Feature flags used by v2 startup script to enable various features. Examples of supported feature flags: - WaitForDnsNameIpMatch indicates whether PD and TiKV has to wait until local IP address matches the one published to external DNS - PreferPDAddressesOverDiscovery advises start script to use TidbClusterSpec.PDAddresses (if supplied) as argument for pd-server, tikv-server and tidb-server commands",
+							Description: "Feature flags used by v2 startup script to enable various features. Examples of supported feature flags: - WaitForDnsNameIpMatch indicates whether PD and TiKV has to wait until local IP address matches the one published to external DNS - TopologyStoreLabels indicates whether TiKV passes the well-known topology labels of its Pod, i.e. topology.kubernetes.io/region and topology.kubernetes.io/zone, as the store labels region and zone if they are set on the Pod, the store labels which are already set take precedence over them. The labels are set on the Nodes by k8s, they must be copied to the Pods, e.g. by the PodTopologyLabelsAdmission feature gate of k8s, and TiKV fails to start if none of them is set on its Pod - MultiplePDAddresses indicates whether TiKV uses the addresses of all PD members instead of the PD service, note that TiKV will be rolling updated when PD is scaled - SkipDnsWait indicates whether PD and TiKV skip waiting for their DNS names on startup, it can not be used together with WaitForDnsNameIpMatch - DualStack indicates whether PD, TiKV, TiFlash and TiCDC listen on the IPv6 wildcard address to accept both IPv4 and IPv6 connections - DnsLookupWithDig indicates whether TiKV, TiFlash and TiDB use dig instead of getent to resolve their DNS names when waiting for DNS name IP match - DnsLookupWithNslookup indicates whether TiKV, TiFlash and TiDB use nslookup instead of getent to resolve their DNS names when waiting for DNS name IP match, it can not be used together with DnsLookupWithDig - PodNameFallback indicates whether start scripts fall back to HOSTNAME and /etc/hostname if POD_NAME is not set, and exit with an error if the pod name still can not be determined - SourceExtraEnvFile indicates whether start scripts source the env file extra.env in the config dir of the component if it exists, e.g. /etc/tikv/extra.env, the variables in it are exported to the component - ModelChecksum indicates whether the TiKV start script has a header comment with the checksum of its model, which only changes if the rendered settings change - GoMaxProcs indicates whether start scripts of PD, TiDB and TiCDC export GOMAXPROCS as the CPU limit of the container rounded up to cores, unless GOMAXPROCS is set in env - WaitForPDLeader indicates whether TiKV waits until the PD cluster has elected a leader before starting, the wait is bounded by the start timeout of TiKV - CachePDAddr indicates whether TiKV caches the PD addr verified by discovery in its data dir when the cluster is deployed across k8s, the cached addr is reused on the next start if PD is still reachable by it - ArgsPerLine indicates whether the start script of TiKV prints the arguments of tikv-server one per line before starting it, the arguments passed to tikv-server are not changed - StrictMode indicates whether start scripts exit on the first failed command by `set -e`, the failures of the commands expected to fail, e.g. the DNS lookups in the waiting loops, are checked explicitly - Localhost indicates whether PD, TiKV and TiDB advertise and reach each other by 127.0.0.1 instead of the DNS names of services, and PD bootstraps without discovery, it is only for the single-node test clusters running all the components in one network namespace - ResolvedSummary indicates whether the start scripts of PD, TiKV and TiDB write a JSON summary of the resolved addresses, e.g. the PD addr, the advertise addr, the data dir and the ports, to /tmp/start-script-summary.json before starting the component, for the tooling inspecting the Pods - ExitCodes indicates whether start scripts print the exit code to stderr on exit, and exit with the code 10 instead of 1 if waiting for DNS times out, the exit codes of the failure stages are listed in the start script v2 package - DnsWaitJitter indicates whether PD, TiKV, TiFlash and TiDB sleep a random jitter of up to 2 seconds in addition to the interval between the attempts of resolving their DNS names, so that the Pods restarted at the same time do not resolve their domains at the same time - PreferIPv6ListenHost indicates whether TiDB, TiCDC and Pump listen on the IPv6 wildcard address instead of the IPv4 one if PreferIPv6 is set - TiProxyAdvertiseAddr indicates whether TiProxy is started with the advertise address of its pod, the TiProxy version must support the --advertise-addr flag - TiDBTiFlashDnsNameIpMatch indicates whether TiDB and TiFlash also wait until local IP address matches the one published to external DNS if WaitForDnsNameIpMatch is set - TiKVCPUQuota indicates whether TiKV limits the CPU time of foreground requests by the CPU limit of its container, which is passed to the start script by the TIKV_CPU_LIMIT env - Base64Fallback indicates whether the URLs of discovery are encoded by openssl if there is no base64 in the image, and all the line breaks of the encoded URLs are removed, including the CR of CRLF",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -13936,11 +14370,52 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 							},
 						},
 					},
+					"dnsWaitThresholdUnit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DnsWaitThresholdUnit is the unit of the start timeouts of components, e.g. `spec.tikv.startTimeout`, when they are used as the threshold of waiting for DNS by start script v2. - attempts: the threshold is the number of attempts to resolve the domain, which are made once per second - seconds: the threshold is the seconds elapsed since the wait begins, including the time of resolving the domain Defaults to \"attempts\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dnsWaitIntervalSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DnsWaitIntervalSeconds is the seconds start script v2 sleeps between the attempts of resolving the domain when waiting for the DNS name of a component to match the Pod IP, e.g. a larger one for slow DNS servers. The elapsed time is increased by the interval per attempt, so an attempt counts as the interval in the threshold of the attempts unit. Defaults to 0 (1 second)",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"dnsWaitResolver": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DnsWaitResolver is the IP address of the DNS server queried by start script v2 when waiting for the DNS name of a component, so that a caching layer of the host, e.g. nscd honored by getent, which may return stale IPs is bypassed. As getent always follows the resolver configured by nsswitch, dig is used instead of getent if it is set, unless the DnsLookupWithNslookup feature flag is set. Defaults to \"\" (use the resolver of the host)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startScriptV2Shell": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartScriptV2Shell is the shell which runs start scripts v2, e.g. /bin/bash. It is used as the interpreter in the shebang of the scripts and the command of the containers running them, so it must exist in the images. Defaults to /bin/sh",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startScriptV2TemplateConfigMap": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartScriptV2TemplateConfigMap is the name of a ConfigMap in the namespace of the cluster which contains the user templates of start scripts v2, the keys are the names of the scripts, e.g. tikv-start-script. The user template of a script takes precedence over the built-in one, and the built-in one is used if the ConfigMap has no key for the script. It is an advanced option, the user templates must be kept compatible with the fields of the script models across the upgrades of the operator. The ConfigMap must have the label app.kubernetes.io/managed-by=tidb-operator to be watched by the operator. Defaults to \"\" (use the built-in templates)",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"sidecarReadiness": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SidecarReadiness makes start scripts v2 wait for a sidecar, e.g. the proxy of a service mesh, to be ready before any network operation, as the connections fail before the sidecar is ready. Defaults to nil (no wait)",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SidecarReadinessSpec"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HelperSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PumpSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiProxySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.AcrossK8sVerificationSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HelperSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PumpSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SidecarReadinessSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SuspendAction", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiProxySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodDNSConfig", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
 	}
 }
 
@@ -16,6 +16,8 @@ package v1alpha1
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -44,9 +46,15 @@ const (
 	// shutdown a TiCDC pod.
 	defaultTiCDCGracefulShutdownTimeout = 10 * time.Minute
 	defaultPDStartTimeout               = 30
+	defaultDiscoveryPort                = int32(10261)
+	// defaultStartScriptShell is the shell which runs the start scripts by default
+	defaultStartScriptShell = "/bin/sh"
 
 	// the latest version
 	versionLatest = "latest"
+
+	// TiKVRaftVolumeName is the name of the volume which stores the logs of Raft Engine if it is mounted to TiKV
+	TiKVRaftVolumeName = "raft"
 )
 
 var (
@@ -200,6 +208,39 @@ func (tc *TidbCluster) TiFlashContainerPrivilege() *bool {
 	return tc.Spec.TiFlash.Privileged
 }
 
+// TiFlashPorts contains the ports of TiFlash, they are the default ports shifted by the base port offset.
+// The config, the start script and the container ports of TiFlash all use it to stay consistent.
+// +k8s:deepcopy-gen=false
+type TiFlashPorts struct {
+	Offset int32
+
+	Tcp         int32
+	Http        int32
+	Flash       int32
+	Proxy       int32
+	Metrics     int32
+	ProxyStatus int32
+	Internal    int32
+}
+
+// TiFlashPorts returns the ports of TiFlash shifted by its BasePortOffset.
+func (tc *TidbCluster) TiFlashPorts() *TiFlashPorts {
+	var offset int32
+	if tc.Spec.TiFlash != nil {
+		offset = tc.Spec.TiFlash.BasePortOffset
+	}
+	return &TiFlashPorts{
+		Offset:      offset,
+		Tcp:         DefaultTiFlashTcpPort + offset,
+		Http:        DefaultTiFlashHttpPort + offset,
+		Flash:       DefaultTiFlashFlashPort + offset,
+		Proxy:       DefaultTiFlashProxyPort + offset,
+		Metrics:     DefaultTiFlashMetricsPort + offset,
+		ProxyStatus: DefaultTiFlashProxyStatusPort + offset,
+		Internal:    DefaultTiFlashInternalPort + offset,
+	}
+}
+
 // TiCDCImage return the image used by TiCDC.
 //
 // If TiCDC isn't specified, return empty string.
@@ -1040,6 +1081,60 @@ func (tikv *TiKVSpec) ShouldSeparateRaftLog() bool {
 	return *separateRaftLog
 }
 
+// TitanDir returns the mount path of TitanVolumeName, which is used as the dirname of Titan.
+// It returns "" if TitanVolumeName is not set or Titan is not enabled in the config.
+func (tikv *TiKVSpec) TitanDir() string {
+	if tikv.TitanVolumeName == "" || tikv.Config == nil {
+		return ""
+	}
+	if enabled := tikv.Config.Get("rocksdb.titan.enabled"); enabled == nil || enabled.Interface() != true {
+		return ""
+	}
+	return tikv.VolumeMountPath(tikv.TitanVolumeName)
+}
+
+// WalDir returns the dir of RocksDB WAL in the WAL volume, it returns "" if WALVolumeName is not set
+// or the volume is not mounted to the TiKV container.
+func (tikv *TiKVSpec) WalDir() string {
+	if tikv.WALVolumeName == "" {
+		return ""
+	}
+	return tikv.VolumeMountPath(tikv.WALVolumeName)
+}
+
+// RaftEngineDir returns the dir of Raft Engine in the raft volume, it returns "" if the volume
+// is not mounted to the TiKV container.
+func (tikv *TiKVSpec) RaftEngineDir() string {
+	mountPath := tikv.VolumeMountPath(TiKVRaftVolumeName)
+	if mountPath == "" {
+		return ""
+	}
+	return path.Join(mountPath, tikv.RaftEngineSubDir)
+}
+
+// BlockCacheSizedByMemoryLimit returns whether the block cache of TiKV is sized by the memory limit of
+// the container in the start script, it requires both BlockCacheMemoryPercent and the memory limit.
+func (tikv *TiKVSpec) BlockCacheSizedByMemoryLimit() bool {
+	_, ok := tikv.Limits[corev1.ResourceMemory]
+	return ok && tikv.BlockCacheMemoryPercent != 0
+}
+
+// TiKVCPULimitUsed returns whether the CPU limit of the TiKV container is applied by the start script, it requires
+// both the TiKVCPUQuota start script feature flag and the CPU limit.
+func (tc *TidbCluster) TiKVCPULimitUsed() bool {
+	if !slices.Contains(tc.Spec.StartScriptV2FeatureFlags, StartScriptV2FeatureFlagTiKVCPUQuota) {
+		return false
+	}
+	_, ok := tc.Spec.TiKV.Limits[corev1.ResourceCPU]
+	return ok
+}
+
+// VolumeMountPath returns the mount path of the storage volume or additional volume with the name,
+// it returns "" if the volume is not mounted to the TiKV container.
+func (tikv *TiKVSpec) VolumeMountPath(name string) string {
+	return volumeMountPath(tikv.StorageVolumes, tikv.AdditionalVolumeMounts, name)
+}
+
 func (tikv *TiKVSpec) GetLogTailerSpec() LogTailerSpec {
 	if tikv.LogTailer == nil {
 		return defaultLogTailerSpec
@@ -1068,6 +1163,26 @@ func (tikv *TiKVSpec) GetScaleOutParallelism() int {
 	return int(*(tikv.ScalePolicy.ScaleOutParallelism))
 }
 
+// VolumeMountPath returns the mount path of the storage volume or additional volume with the name,
+// it returns "" if the volume is not mounted to the TiCDC container.
+func (ticdc *TiCDCSpec) VolumeMountPath(name string) string {
+	return volumeMountPath(ticdc.StorageVolumes, ticdc.AdditionalVolumeMounts, name)
+}
+
+func volumeMountPath(storageVolumes []StorageVolume, additionalVolumeMounts []corev1.VolumeMount, name string) string {
+	for _, sv := range storageVolumes {
+		if sv.Name == name {
+			return sv.MountPath
+		}
+	}
+	for _, vm := range additionalVolumeMounts {
+		if vm.Name == name {
+			return vm.MountPath
+		}
+	}
+	return ""
+}
+
 func (tiflash *TiFlashSpec) GetRecoverByUID() types.UID {
 	if tiflash.Failover == nil {
 		return ""
@@ -1265,9 +1380,33 @@ func (tc *TidbCluster) StartScriptVersion() StartScriptVersion {
 	}
 }
 
+// StartScriptShell returns the shell which runs the start scripts of components,
+// the shell of start scripts v2 can be configured while the one of v1 is always /bin/sh.
+func (tc *TidbCluster) StartScriptShell() string {
+	if tc.StartScriptVersion() == StartScriptV2 && tc.Spec.StartScriptV2Shell != "" {
+		return tc.Spec.StartScriptV2Shell
+	}
+	return defaultStartScriptShell
+}
+
 func (tc *TidbCluster) PDStartTimeout() int {
 	if tc.Spec.PD != nil && tc.Spec.PD.StartTimeout != 0 {
 		return tc.Spec.PD.StartTimeout
 	}
 	return defaultPDStartTimeout
 }
+
+func (tc *TidbCluster) TiKVStartTimeout() int {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.StartTimeout != 0 {
+		return tc.Spec.TiKV.StartTimeout
+	}
+	return tc.PDStartTimeout()
+}
+
+// DiscoveryPort returns the port used by start scripts to access the discovery service
+func (tc *TidbCluster) DiscoveryPort() int32 {
+	if tc.Spec.Discovery.Port != nil {
+		return *tc.Spec.Discovery.Port
+	}
+	return defaultDiscoveryPort
+}
@@ -813,6 +813,65 @@ func TestTiCDCGracefulShutdownTimeout(t *testing.T) {
 	g.Expect(tc.TiCDCGracefulShutdownTimeout()).To(Equal(time.Minute))
 }
 
+func TestTiKVStartTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Spec.TiKV = nil
+	g.Expect(tc.TiKVStartTimeout()).To(Equal(defaultPDStartTimeout))
+
+	tc.Spec.TiKV = &TiKVSpec{}
+	g.Expect(tc.TiKVStartTimeout()).To(Equal(defaultPDStartTimeout))
+
+	tc.Spec.PD.StartTimeout = 60
+	g.Expect(tc.TiKVStartTimeout()).To(Equal(60))
+
+	tc.Spec.TiKV.StartTimeout = 120
+	g.Expect(tc.TiKVStartTimeout()).To(Equal(120))
+}
+
+func TestStartScriptShell(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.StartScriptShell()).To(Equal("/bin/sh"))
+
+	tc.Spec.StartScriptV2Shell = "/bin/bash"
+	g.Expect(tc.StartScriptShell()).To(Equal("/bin/sh"))
+
+	tc.Spec.StartScriptVersion = StartScriptV2
+	g.Expect(tc.StartScriptShell()).To(Equal("/bin/bash"))
+
+	tc.Spec.StartScriptV2Shell = ""
+	g.Expect(tc.StartScriptShell()).To(Equal("/bin/sh"))
+}
+
+func TestDiscoveryPort(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.DiscoveryPort()).To(Equal(defaultDiscoveryPort))
+
+	port := int32(10262)
+	tc.Spec.Discovery.Port = &port
+	g.Expect(tc.DiscoveryPort()).To(Equal(int32(10262)))
+}
+
+func TestTiFlashPorts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(*tc.TiFlashPorts()).To(Equal(TiFlashPorts{
+		Tcp: 9000, Http: 8123, Flash: 3930, Proxy: 20170, Metrics: 8234, ProxyStatus: 20292, Internal: 9009,
+	}))
+
+	tc.Spec.TiFlash = &TiFlashSpec{BasePortOffset: 100}
+	g.Expect(*tc.TiFlashPorts()).To(Equal(TiFlashPorts{
+		Offset: 100,
+		Tcp:    9100, Http: 8223, Flash: 4030, Proxy: 20270, Metrics: 8334, ProxyStatus: 20392, Internal: 9109,
+	}))
+}
+
 func TestComponentFunc(t *testing.T) {
 	t.Run("ComponentIsNormal", func(t *testing.T) {
 		g := NewGomegaWithT(t)
@@ -133,13 +133,75 @@ const (
 	StartScriptV2 StartScriptVersion = "v2"
 )
 
+// DnsWaitThresholdUnit is the unit of the threshold of waiting for DNS in start script v2
+type DnsWaitThresholdUnit string
+
+const (
+	// DnsWaitThresholdUnitAttempts counts the threshold by the attempts of resolving the domain, one attempt per second
+	DnsWaitThresholdUnitAttempts DnsWaitThresholdUnit = "attempts"
+	// DnsWaitThresholdUnitSeconds counts the threshold by the seconds elapsed, including the time of resolving the domain
+	DnsWaitThresholdUnitSeconds DnsWaitThresholdUnit = "seconds"
+)
+
 type StartScriptV2FeatureFlag string
 
 const (
 	StartScriptV2FeatureFlagWaitForDnsNameIpMatch          = "WaitForDnsNameIpMatch"
 	StartScriptV2FeatureFlagPreferPDAddressesOverDiscovery = "PreferPDAddressesOverDiscovery"
+	StartScriptV2FeatureFlagTopologyStoreLabels            = "TopologyStoreLabels"
+	StartScriptV2FeatureFlagMultiplePDAddresses            = "MultiplePDAddresses"
+	StartScriptV2FeatureFlagSkipDnsWait                    = "SkipDnsWait"
+	StartScriptV2FeatureFlagDualStack                      = "DualStack"
+	StartScriptV2FeatureFlagDnsLookupWithDig               = "DnsLookupWithDig"
+	StartScriptV2FeatureFlagDnsLookupWithNslookup          = "DnsLookupWithNslookup"
+	StartScriptV2FeatureFlagPodNameFallback                = "PodNameFallback"
+	StartScriptV2FeatureFlagSourceExtraEnvFile             = "SourceExtraEnvFile"
+	StartScriptV2FeatureFlagModelChecksum                  = "ModelChecksum"
+	StartScriptV2FeatureFlagGoMaxProcs                     = "GoMaxProcs"
+	StartScriptV2FeatureFlagWaitForPDLeader                = "WaitForPDLeader"
+	StartScriptV2FeatureFlagCachePDAddr                    = "CachePDAddr"
+	StartScriptV2FeatureFlagArgsPerLine                    = "ArgsPerLine"
+	StartScriptV2FeatureFlagStrictMode                     = "StrictMode"
+	StartScriptV2FeatureFlagLocalhost                      = "Localhost"
+	StartScriptV2FeatureFlagResolvedSummary                = "ResolvedSummary"
+	StartScriptV2FeatureFlagExitCodes                      = "ExitCodes"
+	StartScriptV2FeatureFlagDnsWaitJitter                  = "DnsWaitJitter"
+	StartScriptV2FeatureFlagPreferIPv6ListenHost           = "PreferIPv6ListenHost"
+	StartScriptV2FeatureFlagTiProxyAdvertiseAddr           = "TiProxyAdvertiseAddr"
+	StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch      = "TiDBTiFlashDnsNameIpMatch"
+	StartScriptV2FeatureFlagTiKVCPUQuota                   = "TiKVCPUQuota"
+	StartScriptV2FeatureFlagBase64Fallback                 = "Base64Fallback"
 )
 
+// SupportedStartScriptV2FeatureFlags is the list of feature flags supported by v2 start script
+var SupportedStartScriptV2FeatureFlags = []StartScriptV2FeatureFlag{
+	StartScriptV2FeatureFlagWaitForDnsNameIpMatch,
+	StartScriptV2FeatureFlagPreferPDAddressesOverDiscovery,
+	StartScriptV2FeatureFlagTopologyStoreLabels,
+	StartScriptV2FeatureFlagMultiplePDAddresses,
+	StartScriptV2FeatureFlagSkipDnsWait,
+	StartScriptV2FeatureFlagDualStack,
+	StartScriptV2FeatureFlagDnsLookupWithDig,
+	StartScriptV2FeatureFlagDnsLookupWithNslookup,
+	StartScriptV2FeatureFlagPodNameFallback,
+	StartScriptV2FeatureFlagSourceExtraEnvFile,
+	StartScriptV2FeatureFlagModelChecksum,
+	StartScriptV2FeatureFlagGoMaxProcs,
+	StartScriptV2FeatureFlagWaitForPDLeader,
+	StartScriptV2FeatureFlagCachePDAddr,
+	StartScriptV2FeatureFlagArgsPerLine,
+	StartScriptV2FeatureFlagStrictMode,
+	StartScriptV2FeatureFlagLocalhost,
+	StartScriptV2FeatureFlagResolvedSummary,
+	StartScriptV2FeatureFlagExitCodes,
+	StartScriptV2FeatureFlagDnsWaitJitter,
+	StartScriptV2FeatureFlagPreferIPv6ListenHost,
+	StartScriptV2FeatureFlagTiProxyAdvertiseAddr,
+	StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch,
+	StartScriptV2FeatureFlagTiKVCPUQuota,
+	StartScriptV2FeatureFlagBase64Fallback,
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -340,6 +402,12 @@ type TidbClusterSpec struct {
 	// +optional
 	AcrossK8s bool `json:"acrossK8s,omitempty"`
 
+	// AcrossK8sVerification configures how the start scripts retry verifying the PD endpoints
+	// through the discovery service when the cluster is deployed across multiple Kubernetes clusters.
+	// Only works with start script v2.
+	// +optional
+	AcrossK8sVerification *AcrossK8sVerificationSpec `json:"acrossK8sVerification,omitempty"`
+
 	// Cluster is the external cluster, if configured, the components in this TidbCluster will join to this configured cluster.
 	// +optional
 	Cluster *TidbClusterRef `json:"cluster,omitempty"`
@@ -387,7 +455,132 @@ type TidbClusterSpec struct {
 	// Examples of supported feature flags:
 	// - WaitForDnsNameIpMatch indicates whether PD and TiKV has to wait until local IP address matches the one published to external DNS
 	// - PreferPDAddressesOverDiscovery advises start script to use TidbClusterSpec.PDAddresses (if supplied) as argument for pd-server, tikv-server and tidb-server commands
+	// - TopologyStoreLabels indicates whether TiKV passes the well-known topology labels of its Pod, i.e. topology.kubernetes.io/region and topology.kubernetes.io/zone, as the store labels region and zone if they are set on the Pod, the store labels which are already set take precedence over them. The labels are set on the Nodes by k8s, they must be copied to the Pods, e.g. by the PodTopologyLabelsAdmission feature gate of k8s, and TiKV fails to start if none of them is set on its Pod
+	// - MultiplePDAddresses indicates whether TiKV uses the addresses of all PD members instead of the PD service, note that TiKV will be rolling updated when PD is scaled
+	// - SkipDnsWait indicates whether PD and TiKV skip waiting for their DNS names on startup, it can not be used together with WaitForDnsNameIpMatch
+	// - DualStack indicates whether PD, TiKV, TiFlash and TiCDC listen on the IPv6 wildcard address to accept both IPv4 and IPv6 connections
+	// - DnsLookupWithDig indicates whether TiKV, TiFlash and TiDB use dig instead of getent to resolve their DNS names when waiting for DNS name IP match
+	// - DnsLookupWithNslookup indicates whether TiKV, TiFlash and TiDB use nslookup instead of getent to resolve their DNS names when waiting for DNS name IP match, it can not be used together with DnsLookupWithDig
+	// - PodNameFallback indicates whether start scripts fall back to HOSTNAME and /etc/hostname if POD_NAME is not set, and exit with an error if the pod name still can not be determined
+	// - SourceExtraEnvFile indicates whether start scripts source the env file extra.env in the config dir of the component if it exists, e.g. /etc/tikv/extra.env, the variables in it are exported to the component
+	// - ModelChecksum indicates whether the TiKV start script has a header comment with the checksum of its model, which only changes if the rendered settings change
+	// - GoMaxProcs indicates whether start scripts of PD, TiDB and TiCDC export GOMAXPROCS as the CPU limit of the container rounded up to cores, unless GOMAXPROCS is set in env
+	// - WaitForPDLeader indicates whether TiKV waits until the PD cluster has elected a leader before starting, the wait is bounded by the start timeout of TiKV
+	// - CachePDAddr indicates whether TiKV caches the PD addr verified by discovery in its data dir when the cluster is deployed across k8s, the cached addr is reused on the next start if PD is still reachable by it
+	// - ArgsPerLine indicates whether the start script of TiKV prints the arguments of tikv-server one per line before starting it, the arguments passed to tikv-server are not changed
+	// - StrictMode indicates whether start scripts exit on the first failed command by `set -e`, the failures of the commands expected to fail, e.g. the DNS lookups in the waiting loops, are checked explicitly
+	// - Localhost indicates whether PD, TiKV and TiDB advertise and reach each other by 127.0.0.1 instead of the DNS names of services, and PD bootstraps without discovery, it is only for the single-node test clusters running all the components in one network namespace
+	// - ResolvedSummary indicates whether the start scripts of PD, TiKV and TiDB write a JSON summary of the resolved addresses, e.g. the PD addr, the advertise addr, the data dir and the ports, to /tmp/start-script-summary.json before starting the component, for the tooling inspecting the Pods
+	// - ExitCodes indicates whether start scripts print the exit code to stderr on exit, and exit with the code 10 instead of 1 if waiting for DNS times out, the exit codes of the failure stages are listed in the start script v2 package
+	// - DnsWaitJitter indicates whether PD, TiKV, TiFlash and TiDB sleep a random jitter of up to 2 seconds in addition to the interval between the attempts of resolving their DNS names, so that the Pods restarted at the same time do not resolve their domains at the same time
+	// - PreferIPv6ListenHost indicates whether TiDB, TiCDC and Pump listen on the IPv6 wildcard address instead of the IPv4 one if PreferIPv6 is set
+	// - TiProxyAdvertiseAddr indicates whether TiProxy is started with the advertise address of its pod, the TiProxy version must support the --advertise-addr flag
+	// - TiDBTiFlashDnsNameIpMatch indicates whether TiDB and TiFlash also wait until local IP address matches the one published to external DNS if WaitForDnsNameIpMatch is set
+	// - TiKVCPUQuota indicates whether TiKV limits the CPU time of foreground requests by the CPU limit of its container, which is passed to the start script by the TIKV_CPU_LIMIT env
+	// - Base64Fallback indicates whether the URLs of discovery are encoded by openssl if there is no base64 in the image, and all the line breaks of the encoded URLs are removed, including the CR of CRLF
 	StartScriptV2FeatureFlags []StartScriptV2FeatureFlag `json:"startScriptV2FeatureFlags,omitempty"`
+
+	// DnsWaitThresholdUnit is the unit of the start timeouts of components, e.g. `spec.tikv.startTimeout`,
+	// when they are used as the threshold of waiting for DNS by start script v2.
+	// - attempts: the threshold is the number of attempts to resolve the domain, which are made once per second
+	// - seconds: the threshold is the seconds elapsed since the wait begins, including the time of resolving the domain
+	// Defaults to "attempts"
+	// +optional
+	// +kubebuilder:validation:Enum:="";"attempts";"seconds"
+	DnsWaitThresholdUnit DnsWaitThresholdUnit `json:"dnsWaitThresholdUnit,omitempty"`
+
+	// DnsWaitIntervalSeconds is the seconds start script v2 sleeps between the attempts of resolving the domain
+	// when waiting for the DNS name of a component to match the Pod IP, e.g. a larger one for slow DNS servers.
+	// The elapsed time is increased by the interval per attempt, so an attempt counts as the interval
+	// in the threshold of the attempts unit.
+	// Defaults to 0 (1 second)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DnsWaitIntervalSeconds int32 `json:"dnsWaitIntervalSeconds,omitempty"`
+
+	// DnsWaitResolver is the IP address of the DNS server queried by start script v2 when waiting for the DNS name
+	// of a component, so that a caching layer of the host, e.g. nscd honored by getent, which may return stale IPs
+	// is bypassed. As getent always follows the resolver configured by nsswitch, dig is used instead of getent
+	// if it is set, unless the DnsLookupWithNslookup feature flag is set.
+	// Defaults to "" (use the resolver of the host)
+	// +optional
+	DnsWaitResolver string `json:"dnsWaitResolver,omitempty"`
+
+	// StartScriptV2Shell is the shell which runs start scripts v2, e.g. /bin/bash. It is used as the interpreter
+	// in the shebang of the scripts and the command of the containers running them, so it must exist in the images.
+	// Defaults to /bin/sh
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/[A-Za-z0-9._/-]+$`
+	StartScriptV2Shell string `json:"startScriptV2Shell,omitempty"`
+
+	// StartScriptV2TemplateConfigMap is the name of a ConfigMap in the namespace of the cluster which contains
+	// the user templates of start scripts v2, the keys are the names of the scripts, e.g. tikv-start-script.
+	// The user template of a script takes precedence over the built-in one, and the built-in one is used
+	// if the ConfigMap has no key for the script. It is an advanced option, the user templates must be
+	// kept compatible with the fields of the script models across the upgrades of the operator. The ConfigMap must
+	// have the label app.kubernetes.io/managed-by=tidb-operator to be watched by the operator.
+	// Defaults to "" (use the built-in templates)
+	// +optional
+	StartScriptV2TemplateConfigMap string `json:"startScriptV2TemplateConfigMap,omitempty"`
+
+	// SidecarReadiness makes start scripts v2 wait for a sidecar, e.g. the proxy of a service mesh, to be ready
+	// before any network operation, as the connections fail before the sidecar is ready.
+	// Defaults to nil (no wait)
+	// +optional
+	SidecarReadiness *SidecarReadinessSpec `json:"sidecarReadiness,omitempty"`
+}
+
+// SidecarReadinessSpec contains the health endpoint of a sidecar which start scripts v2 wait for
+// +k8s:openapi-gen=true
+type SidecarReadinessSpec struct {
+	// URL is the health endpoint of the sidecar which returns 2xx once the sidecar is ready,
+	// e.g. http://localhost:15021/healthz/ready for Istio
+	// +kubebuilder:validation:Pattern=`^https?://[A-Za-z0-9._~:/?=&%\[\]-]+$`
+	URL string `json:"url"`
+
+	// Timeout (in seconds) of waiting for the sidecar, the start script exits with a nonzero code after it
+	// so that the container is restarted.
+	// Defaults to 0 (wait until the sidecar is ready)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+}
+
+// AcrossK8sVerificationSpec contains the retry bounds of verifying the PD endpoints through the discovery service
+// +k8s:openapi-gen=true
+type AcrossK8sVerificationSpec struct {
+	// Timeout (in seconds) of each verification request
+	// Defaults to 3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// MaxRetries is the max retries of the verification, the start script exits with
+	// a nonzero code after it so that the pod is restarted and the failure is surfaced.
+	// Defaults to 0 (retry until the verification succeeds)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+
+	// MaxBackoff (in seconds) is the ceiling of the random backoff between two retries
+	// Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxBackoff int32 `json:"maxBackoff,omitempty"`
+
+	// CABundlePath is the path of the CA bundle in the containers to verify the certificate of discovery,
+	// the start scripts access discovery by https if it is set, e.g. when discovery is behind a TLS proxy
+	// with a private CA. The CA bundle is not mounted by the operator, mount it to the components
+	// by additionalVolumes and additionalVolumeMounts.
+	// Defaults to "" (access discovery by http)
+	// +optional
+	CABundlePath string `json:"caBundlePath,omitempty"`
+
+	// Verbose makes the start scripts echo the PD endpoints finally used by the components to stderr
+	// with the "[start-script] effective PD endpoints:" prefix, e.g. to diagnose the bring-up across k8s.
+	// Defaults to false
+	// +optional
+	Verbose bool `json:"verbose,omitempty"`
 }
 
 // TidbClusterStatus represents the current status of a tidb cluster.
@@ -453,6 +646,15 @@ const (
 type DiscoverySpec struct {
 	*ComponentSpec              `json:",inline"`
 	corev1.ResourceRequirements `json:",inline"`
+
+	// Port used by start scripts to access the discovery service, it can be different from
+	// the port of discovery service if discovery is accessed through a sidecar or service mesh.
+	// Only works with start script v2.
+	// Defaults to 10261
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
 }
 
 // +k8s:openapi-gen=true
@@ -637,9 +839,19 @@ type TiKVSpec struct {
 	StorageVolumes []StorageVolume `json:"storageVolumes,omitempty"`
 
 	// StoreLabels configures additional labels for TiKV stores.
+	// A plain key is looked up in the labels of the node where the TiKV Pod is scheduled,
+	// while an entry in the form of `key=value` is passed to tikv-server as a static label
+	// by the v2 start script.
 	// +optional
 	StoreLabels []string `json:"storeLabels,omitempty"`
 
+	// PodLabelsAsStoreLabels are the keys of Pod labels passed to tikv-server as store labels,
+	// the labels are read from the file mounted by the downward API when TiKV starts.
+	// The labels configured by storeLabels or the STORE_LABELS env take precedence over them.
+	// Only works with start script v2.
+	// +optional
+	PodLabelsAsStoreLabels []string `json:"podLabelsAsStoreLabels,omitempty"`
+
 	// EnableNamedStatusPort enables status port(20180) in the Pod spec.
 	// If you set it to `true` for an existing cluster, the TiKV cluster will be rolling updated.
 	EnableNamedStatusPort bool `json:"enableNamedStatusPort,omitempty"`
@@ -647,6 +859,276 @@ type TiKVSpec struct {
 	// ScalePolicy is the scale configuration for TiKV
 	// +optional
 	ScalePolicy ScalePolicy `json:"scalePolicy,omitempty"`
+
+	// StatusListenHost is the host that the status server of TiKV listens on,
+	// e.g. the Pod IP in network-policy-restricted environments.
+	// A link-local IPv6 address can carry a zone index, e.g. fe80::1%eth0.
+	// Only works with start script v2.
+	// Defaults to the host that the TiKV server listens on
+	// +optional
+	StatusListenHost string `json:"statusListenHost,omitempty"`
+
+	// DisableStatusServer disables the status server of TiKV in security-hardened deployments,
+	// it can not be used together with the dynamic configuration which advertises the status address.
+	// Only works with start script v2.
+	// Defaults to false
+	// +optional
+	DisableStatusServer bool `json:"disableStatusServer,omitempty"`
+
+	// Timeout threshold when tikv get started
+	// Defaults to the start timeout of pd
+	// +optional
+	StartTimeout int `json:"startTimeout,omitempty"`
+
+	// Optional volume name configuration for RocksDB WAL, it should be one of
+	// storageVolumes or additionalVolumes which is mounted to the TiKV container.
+	// The mount path of the volume is used as `rocksdb.wal-dir` unless it is set in the config.
+	// Defaults to "" (WAL is stored in the data dir)
+	// +optional
+	WALVolumeName string `json:"walVolumeName,omitempty"`
+
+	// Optional volume name configuration for the blob files of Titan, it should be one of
+	// storageVolumes or additionalVolumes which is mounted to the TiKV container.
+	// If Titan is enabled by `rocksdb.titan.enabled` in the config, the mount path of the volume
+	// is used as `rocksdb.titan.dirname` unless it is set in the config.
+	// Defaults to "" (the blob files are stored in the data dir)
+	// +optional
+	TitanVolumeName string `json:"titanVolumeName,omitempty"`
+
+	// Optional sub dir for the logs of Raft Engine in the volume named "raft", which should be one of
+	// storageVolumes or additionalVolumes mounted to the TiKV container. If the volume is mounted,
+	// the dir is used as `raft-engine.dir` unless it is set in the config.
+	// Only works with start script v2.
+	// Defaults to "" (the mount path of the raft volume)
+	// +optional
+	RaftEngineSubDir string `json:"raftEngineSubDir,omitempty"`
+
+	// EncryptionConfig references the secrets which store the master keys of encryption at rest,
+	// the secrets are mounted to TiKV and used as the file master keys.
+	// The master keys can not be set in the config at the same time.
+	// +optional
+	EncryptionConfig *TiKVEncryptionSecretConfig `json:"encryptionConfig,omitempty"`
+
+	// PreStop configures the preStop hook of TiKV, which evicts the leaders of the store
+	// through PD before the TiKV container is stopped. The evict leader scheduler created by the hook
+	// is kept while TiKV is stopped, and it is removed by the start script after TiKV restarts.
+	// Defaults to nil (no preStop hook)
+	// +optional
+	PreStop *TiKVPreStopSpec `json:"preStop,omitempty"`
+
+	// AdvertiseHostSuffix overrides the DNS suffix of the host advertised by TiKV,
+	// the advertised host is ${POD_NAME}.{advertiseHostSuffix} if it is set.
+	// It is useful if TiKV is resolved by an external DNS zone instead of the peer service.
+	// Only works with start script v2.
+	// Defaults to "" (use the peer service of TiKV, e.g. ${POD_NAME}.{cluster}-tikv-peer.{namespace}.svc)
+	// +optional
+	AdvertiseHostSuffix string `json:"advertiseHostSuffix,omitempty"`
+
+	// PeerServiceName overrides the name of the headless service in the host advertised by TiKV,
+	// it is used by clusters imported or migrated with a legacy peer service name.
+	// The service must be the one that the Pods are resolved by, it is not created by the operator.
+	// It is ignored if AdvertiseHostSuffix is set. Only works with start script v2.
+	// Defaults to "" (use {cluster}-tikv-peer)
+	// +optional
+	PeerServiceName string `json:"peerServiceName,omitempty"`
+
+	// AdvertisePort is the port advertised by TiKV for the deployments behind NAT or port mapping,
+	// TiKV still listens on the default port 20160.
+	// Only works with start script v2.
+	// Defaults to 0 (advertise the listening port)
+	// +optional
+	AdvertisePort int32 `json:"advertisePort,omitempty"`
+
+	// PreComputeCapacity indicates whether to compute the capacity of TiKV from the storage request
+	// when rendering the start script, instead of reading it from the CAPACITY env of the container.
+	// The capacity is rendered in bytes, and "0" (unlimited) is rendered if the storage request is not set.
+	// Only works with start script v2.
+	// Defaults to false
+	// +optional
+	PreComputeCapacity bool `json:"preComputeCapacity,omitempty"`
+
+	// LogLevel is the log level passed to TiKV by the start script, it overrides
+	// the log level in the config file if it is set.
+	// Only works with start script v2.
+	// Defaults to "" (use the log level in the config file)
+	// +optional
+	// +kubebuilder:validation:Enum:="";"trace";"debug";"info";"warn";"error";"off"
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// LogFile makes TiKV log to a file with rotation instead of stdout, e.g. for a log shipper
+	// reading the files from the data volume.
+	// Only works with start script v2.
+	// Defaults to nil (log to stdout)
+	// +optional
+	LogFile *TiKVLogFileSpec `json:"logFile,omitempty"`
+
+	// AdditionalStartupFlags are the flags appended to the command line of TiKV by the start script,
+	// e.g. "--security-redact-info-log". They are appended in order after the flags managed by the operator,
+	// and the command line flags take precedence over the settings in the config file.
+	// Each flag is quoted and passed to TiKV as one argument, shell variables and commands in it are not expanded.
+	// Only works with start script v2.
+	// +optional
+	AdditionalStartupFlags []string `json:"additionalStartupFlags,omitempty"`
+
+	// FixDataDirPermissions indicates whether the start script sets the owner of the data dir
+	// to the user running TiKV and removes the group and other write permissions from it
+	// before starting TiKV, the files created by TiKV are also not group or other writable.
+	// Only the data dir is changed, the other directories of the data volume are not touched.
+	// Only works with start script v2.
+	// Defaults to false
+	// +optional
+	FixDataDirPermissions bool `json:"fixDataDirPermissions,omitempty"`
+
+	// CheckDataDirFsync indicates whether the start script writes and fsyncs a small file in the data dir
+	// before starting TiKV, TiKV is not started if it fails, e.g. when the volume is broken or read-only.
+	// Note that it can not detect the storage which acknowledges fsync without persisting the data.
+	// Only works with start script v2.
+	// Defaults to false
+	// +optional
+	CheckDataDirFsync bool `json:"checkDataDirFsync,omitempty"`
+
+	// MinFreeSpace is the minimum free space of the data volume required to start TiKV, the start script
+	// checks the free space of the data dir by df and exits before starting TiKV if it is less than this value.
+	// Only works with start script v2.
+	// Defaults to nil (no check)
+	// +optional
+	MinFreeSpace *resource.Quantity `json:"minFreeSpace,omitempty"`
+
+	// StartScriptPrologue is the shell script inserted verbatim into the start script of TiKV
+	// before composing the arguments of TiKV, e.g. to do the vendor-specific setup of the node.
+	// It must not only contain whitespace if it is set.
+	// Only works with start script v2.
+	// +optional
+	StartScriptPrologue string `json:"startScriptPrologue,omitempty"`
+
+	// SizeThreadPoolsByCPULimit indicates whether the start script sizes the unified read pool, which
+	// runs the coprocessor requests, and the scheduler worker pool of TiKV by the CPU limit of the container.
+	// It has no effect if the TiKVCPUQuota feature flag or the CPU limit is not set, or the pools are already configured in the config file,
+	// TiKV sizes the pools by itself in these cases.
+	// Only works with start script v2.
+	// Defaults to false
+	// +optional
+	SizeThreadPoolsByCPULimit bool `json:"sizeThreadPoolsByCPULimit,omitempty"`
+
+	// StorageAPIVersion is the storage.api-version of TiKV, API V2 is required by some features of RawKV, e.g. TTL.
+	// It can only be set when TiKV is bootstrapped, the start script refuses to start TiKV if the api version
+	// of the existing data is different.
+	// Only works with start script v2.
+	// Defaults to the api version in the config file, which is 1 if not set
+	// +kubebuilder:validation:Enum=1;2
+	// +optional
+	StorageAPIVersion int32 `json:"storageAPIVersion,omitempty"`
+
+	// ReserveSpacePercent is the percentage of the capacity of TiKV reserved as storage.reserve-space, so that
+	// the reserved space is scaled with the volume instead of being a fixed size in the config file.
+	// The capacity is the --capacity of TiKV, or the size of the data volume if the capacity is not set.
+	// It has no effect if storage.reserve-space is already set in the config file.
+	// Only works with start script v2.
+	// Defaults to 0 (the storage.reserve-space in the config file, which defaults to 5GB in TiKV)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=50
+	// +optional
+	ReserveSpacePercent int32 `json:"reserveSpacePercent,omitempty"`
+
+	// GCNumThreads is the gc.num-threads of TiKV, which is the number of the background GC workers, large clusters
+	// need more of them to keep up with the GC of the MVCC versions.
+	// It has no effect if gc.num-threads is already set in the config file.
+	// Only works with start script v2.
+	// Defaults to 0 (the gc.num-threads in the config file, which defaults to 1 in TiKV)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=32
+	// +optional
+	GCNumThreads int32 `json:"gcNumThreads,omitempty"`
+
+	// RegionSplitSize is the coprocessor.region-split-size of TiKV, which is the size of the regions split from
+	// a large region, the workloads with huge values may use larger regions to reduce the number of regions.
+	// It must be a multiple of 1Mi. The regions smaller than the schedule.max-merge-region-size of PD are merged,
+	// which should be tuned in the config of PD together with it.
+	// It has no effect if region-split-size or region-max-size is already set in the config file.
+	// Only works with start script v2.
+	// Defaults to nil (the coprocessor.region-split-size in the config file, which defaults to 96MiB in TiKV)
+	// +optional
+	RegionSplitSize *resource.Quantity `json:"regionSplitSize,omitempty"`
+
+	// RegionMaxSize is the coprocessor.region-max-size of TiKV, a region is split if it is larger than it.
+	// It must be a multiple of 1Mi and not be less than RegionSplitSize.
+	// It has no effect if region-split-size or region-max-size is already set in the config file.
+	// Only works with start script v2.
+	// Defaults to nil (the coprocessor.region-max-size in the config file, which defaults to 3/2 of
+	// the region-split-size in TiKV)
+	// +optional
+	RegionMaxSize *resource.Quantity `json:"regionMaxSize,omitempty"`
+
+	// BlockCacheMemoryPercent is the percentage of the memory limit of the TiKV container set as the
+	// storage.block-cache.capacity of TiKV, so that the block cache is resized with the Pod instead of being
+	// a fixed size in the config file.
+	// It has no effect if the memory limit is not set or storage.block-cache.capacity is already set in the config file.
+	// Only works with start script v2.
+	// Defaults to 0 (the storage.block-cache.capacity in the config file, which is sized by TiKV from the memory
+	// of the container if not set)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=80
+	// +optional
+	BlockCacheMemoryPercent int32 `json:"blockCacheMemoryPercent,omitempty"`
+
+	// StartupDelaySeconds is the number of seconds the start script sleeps before any network operation,
+	// e.g. when the Pod network is not routable right after the Pod is started with some CNI plugins.
+	// Only works with start script v2.
+	// Defaults to 0 (no delay)
+	// +optional
+	StartupDelaySeconds int `json:"startupDelaySeconds,omitempty"`
+}
+
+// TiKVPreStopSpec contains the parameters of the TiKV preStop hook
+// +k8s:openapi-gen=true
+type TiKVPreStopSpec struct {
+	// LeaderCountThreshold is the leader count of the store, the preStop hook stops waiting
+	// once the leader count drops to or below it.
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LeaderCountThreshold int32 `json:"leaderCountThreshold,omitempty"`
+
+	// Timeout (in seconds) of the preStop hook, including looking up PD and waiting for the leader eviction,
+	// terminationGracePeriodSeconds of TiKV pods should be longer than it.
+	// Defaults to 300
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// TiKVEncryptionSecretConfig references the secrets which store the master keys of TiKV encryption at rest.
+// The master key is read from the `master-key` key of the secret.
+// +k8s:openapi-gen=true
+type TiKVEncryptionSecretConfig struct {
+	// MasterKeySecretName is the name of the secret which stores the current master key
+	MasterKeySecretName string `json:"masterKeySecretName"`
+
+	// PreviousMasterKeySecretName is the name of the secret which stores the previous master key,
+	// it is only needed when rotating the master key
+	// +optional
+	PreviousMasterKeySecretName string `json:"previousMasterKeySecretName,omitempty"`
+}
+
+// TiKVLogFileSpec contains the log file and its rotation settings of TiKV.
+// The rotation settings are only applied if `log.file` is not set in the config file of TiKV.
+// +k8s:openapi-gen=true
+type TiKVLogFileSpec struct {
+	// Path is the absolute path of the log file, it should be in a persistent volume,
+	// e.g. /var/lib/tikv/log/tikv.log
+	Path string `json:"path"`
+
+	// MaxSize is the max size in MB of the log file before it is rotated
+	// Defaults to 0 (use the default of TiKV)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSize int32 `json:"maxSize,omitempty"`
+
+	// MaxBackups is the max number of the rotated log files to keep
+	// Defaults to 0 (use the default of TiKV)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBackups int32 `json:"maxBackups,omitempty"`
 }
 
 // TiFlashSpec contains details of TiFlash members
@@ -707,6 +1189,13 @@ type TiFlashSpec struct {
 	// ScalePolicy is the scale configuration for TiFlash
 	// +optional
 	ScalePolicy ScalePolicy `json:"scalePolicy,omitempty"`
+
+	// BasePortOffset is added to all the ports of TiFlash (tcp, http, flash, proxy, metrics,
+	// proxy status and internal), e.g. to run TiFlash beside another one in host network.
+	// Defaults to 0 (use the default ports)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BasePortOffset int32 `json:"basePortOffset,omitempty"`
 }
 
 // TiCDCSpec contains details of TiCDC members
@@ -752,6 +1241,14 @@ type TiCDCSpec struct {
 	// Defaults to 10m
 	// +optional
 	GracefulShutdownTimeout *metav1.Duration `json:"gracefulShutdownTimeout,omitempty"`
+
+	// DataVolumeName is the name of the volume used as the data dir of TiCDC, it should be one of
+	// storageVolumes or additionalVolumes which is mounted to the TiCDC container.
+	// The sort dir of TiCDC is also placed in the data dir.
+	// Only works with start script v2.
+	// Defaults to "" (use the default data dir of TiCDC)
+	// +optional
+	DataVolumeName string `json:"dataVolumeName,omitempty"`
 }
 
 // TiCDCConfig is the configuration of tidbcdc
@@ -942,6 +1439,24 @@ type TiDBSpec struct {
 	// Only v6.5.1+ supports this feature.
 	// +optional
 	BootstrapSQLConfigMapName *string `json:"bootstrapSQLConfigMapName,omitempty"`
+
+	// PreStop configures the preStop hook of TiDB, which labels the server as draining
+	// and waits for the client connections to be closed before the TiDB container is stopped.
+	// It is ignored if the preStop handler is set in Lifecycle.
+	// Defaults to nil (no preStop hook)
+	// +optional
+	PreStop *TiDBPreStopSpec `json:"preStop,omitempty"`
+}
+
+// TiDBPreStopSpec contains the parameters of the TiDB preStop hook
+// +k8s:openapi-gen=true
+type TiDBPreStopSpec struct {
+	// Timeout (in seconds) of waiting for the client connections to be closed,
+	// terminationGracePeriodSeconds of TiDB pods should be longer than it.
+	// Defaults to 60
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int `json:"timeout,omitempty"`
 }
 
 type TiDBInitializer struct {
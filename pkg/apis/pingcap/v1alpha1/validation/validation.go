@@ -21,6 +21,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -36,6 +37,9 @@ import (
 	utilnet "k8s.io/utils/net"
 )
 
+// tikvLogLevels are the log levels accepted by TiKV
+var tikvLogLevels = []string{"trace", "debug", "info", "warn", "error", "off"}
+
 // ValidateTidbCluster validates a TidbCluster, it performs basic validation for all TidbClusters despite it is legacy
 // or not
 func ValidateTidbCluster(tc *v1alpha1.TidbCluster) field.ErrorList {
@@ -132,6 +136,10 @@ func validateTiDBClusterSpec(spec *v1alpha1.TidbClusterSpec, fldPath *field.Path
 	}
 	if spec.TiKV != nil {
 		allErrs = append(allErrs, validateTiKVSpec(spec.TiKV, fldPath.Child("tikv"))...)
+		if spec.TiKV.DisableStatusServer && spec.EnableDynamicConfiguration != nil && *spec.EnableDynamicConfiguration {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("tikv", "disableStatusServer"), spec.TiKV.DisableStatusServer,
+				"status server can not be disabled if enableDynamicConfiguration is true"))
+		}
 	}
 	if spec.TiDB != nil {
 		allErrs = append(allErrs, validateTiDBSpec(spec.TiDB, fldPath.Child("tidb"))...)
@@ -148,6 +156,116 @@ func validateTiDBClusterSpec(spec *v1alpha1.TidbClusterSpec, fldPath *field.Path
 	if spec.PDAddresses != nil {
 		allErrs = append(allErrs, validatePDAddresses(spec.PDAddresses, fldPath.Child("pdAddresses"))...)
 	}
+	if spec.AcrossK8sVerification != nil {
+		allErrs = append(allErrs, validateAcrossK8sVerification(spec.AcrossK8sVerification, fldPath.Child("acrossK8sVerification"))...)
+	}
+	allErrs = append(allErrs, validateStartScriptV2FeatureFlags(spec.StartScriptV2FeatureFlags, fldPath.Child("startScriptV2FeatureFlags"))...)
+	if spec.StartScriptV2Shell != "" {
+		allErrs = append(allErrs, validateStartScriptV2Shell(spec.StartScriptV2Shell, fldPath.Child("startScriptV2Shell"))...)
+	}
+	if spec.DnsWaitResolver != "" {
+		allErrs = append(allErrs, validateDnsWaitResolver(spec.DnsWaitResolver, fldPath.Child("dnsWaitResolver"))...)
+	}
+	if spec.SidecarReadiness != nil {
+		allErrs = append(allErrs, validateSidecarReadiness(spec.SidecarReadiness, fldPath.Child("sidecarReadiness"))...)
+	}
+	return allErrs
+}
+
+// validateStartScriptV2Shell checks the shell is a clean absolute path,
+// which is put after "#!" of the scripts and can not contain spaces or args.
+func validateStartScriptV2Shell(shell string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !path.IsAbs(shell) || path.Clean(shell) != shell || strings.ContainsAny(shell, " \t\n") {
+		allErrs = append(allErrs, field.Invalid(fldPath, shell, "must be a clean absolute path without spaces"))
+	}
+	return allErrs
+}
+
+// validateDnsWaitResolver checks the resolver is an IP address, which is rendered into the DNS lookup commands
+// and can not be resolved by the DNS it bypasses.
+func validateDnsWaitResolver(resolver string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsValidIP(resolver) {
+		allErrs = append(allErrs, field.Invalid(fldPath, resolver, msg))
+	}
+	return allErrs
+}
+
+func validateAcrossK8sVerification(spec *v1alpha1.AcrossK8sVerificationSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.CABundlePath != "" && !path.IsAbs(spec.CABundlePath) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("caBundlePath"), spec.CABundlePath, "must be an absolute path"))
+	}
+	return allErrs
+}
+
+// validateSidecarReadiness checks the health endpoint is an http(s) URL with a host,
+// which is polled by the start scripts before any network operation.
+func validateSidecarReadiness(spec *v1alpha1.SidecarReadinessSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	u, err := url.Parse(spec.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(spec.URL, " \t\n'\"`$\\;|<>(){}") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), spec.URL, "must be an http or https URL with a host"))
+	}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.Timeout), fldPath.Child("timeout"))...)
+	return allErrs
+}
+
+func validateTiKVLogFile(spec *v1alpha1.TiKVLogFileSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if !path.IsAbs(spec.Path) || path.Clean(spec.Path) != spec.Path {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), spec.Path, "must be a clean absolute path"))
+	}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.MaxSize), fldPath.Child("maxSize"))...)
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.MaxBackups), fldPath.Child("maxBackups"))...)
+	return allErrs
+}
+
+// validateTiKVRegionSize validates the region sizes of TiKV, they are rendered in MiB, which is the unit
+// TiKV uses for the region sizes.
+func validateTiKVRegionSize(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, size := range []struct {
+		name string
+		q    *resource.Quantity
+	}{
+		{name: "regionSplitSize", q: spec.RegionSplitSize},
+		{name: "regionMaxSize", q: spec.RegionMaxSize},
+	} {
+		if size.q != nil && (size.q.Sign() <= 0 || size.q.Value()%(1<<20) != 0) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(size.name), size.q.String(), "must be a positive multiple of 1Mi"))
+		}
+	}
+	if spec.RegionSplitSize != nil && spec.RegionMaxSize != nil && spec.RegionMaxSize.Cmp(*spec.RegionSplitSize) < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("regionMaxSize"), spec.RegionMaxSize.String(), "must not be less than regionSplitSize"))
+	}
+	return allErrs
+}
+
+func validateStartScriptV2FeatureFlags(flags []v1alpha1.StartScriptV2FeatureFlag, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	supported := make([]string, 0, len(v1alpha1.SupportedStartScriptV2FeatureFlags))
+	for _, flag := range v1alpha1.SupportedStartScriptV2FeatureFlags {
+		supported = append(supported, string(flag))
+	}
+	for i, flag := range flags {
+		if !slices.Contains(v1alpha1.SupportedStartScriptV2FeatureFlags, flag) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Index(i), flag, supported))
+		}
+	}
+	if slices.Contains(flags, v1alpha1.StartScriptV2FeatureFlagSkipDnsWait) &&
+		slices.Contains(flags, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch) {
+		allErrs = append(allErrs, field.Invalid(fldPath, flags,
+			fmt.Sprintf("feature flag %s can not be used together with %s",
+				v1alpha1.StartScriptV2FeatureFlagSkipDnsWait, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch)))
+	}
+	if slices.Contains(flags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig) &&
+		slices.Contains(flags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup) {
+		allErrs = append(allErrs, field.Invalid(fldPath, flags,
+			fmt.Sprintf("feature flag %s can not be used together with %s",
+				v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup)))
+	}
 	return allErrs
 }
 
@@ -204,7 +322,79 @@ func validateTiKVSpec(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorL
 	if spec.ShouldSeparateRocksDBLog() && spec.RocksDBLogVolumeName != "" {
 		allErrs = append(allErrs, validateVolumeName(spec.RocksDBLogVolumeName, spec.StorageVolumes, spec.AdditionalVolumes, spec.AdditionalVolumeMounts, fldPath)...)
 	}
+	if spec.WALVolumeName != "" {
+		allErrs = append(allErrs, validateMountedVolumeName(spec.WALVolumeName, spec.StorageVolumes, spec.AdditionalVolumes, spec.AdditionalVolumeMounts, fldPath)...)
+	}
+	if spec.TitanVolumeName != "" {
+		allErrs = append(allErrs, validateMountedVolumeName(spec.TitanVolumeName, spec.StorageVolumes, spec.AdditionalVolumes, spec.AdditionalVolumeMounts, fldPath)...)
+	}
+	if spec.EncryptionConfig != nil && spec.EncryptionConfig.MasterKeySecretName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("encryptionConfig", "masterKeySecretName"), "master key secret name must be set"))
+	}
+	if ec := spec.EncryptionConfig; ec != nil && spec.Config != nil {
+		// the master keys from secrets are set in the config, they can not be configured in both places
+		keys := []string{"security.encryption.master-key"}
+		if ec.PreviousMasterKeySecretName != "" {
+			keys = append(keys, "security.encryption.previous-master-key")
+		}
+		for _, key := range keys {
+			if spec.Config.Get(key) != nil {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("config"), fmt.Sprintf("%s can not be set with encryptionConfig", key)))
+			}
+		}
+	}
+	if spec.AdvertiseHostSuffix != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.AdvertiseHostSuffix) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("advertiseHostSuffix"), spec.AdvertiseHostSuffix, msg))
+		}
+	}
+	if spec.PeerServiceName != "" {
+		for _, msg := range validation.IsDNS1035Label(spec.PeerServiceName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("peerServiceName"), spec.PeerServiceName, msg))
+		}
+	}
+	if spec.AdvertisePort != 0 {
+		for _, msg := range validation.IsValidPortNum(int(spec.AdvertisePort)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("advertisePort"), spec.AdvertisePort, msg))
+		}
+	}
+	for i, key := range spec.PodLabelsAsStoreLabels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("podLabelsAsStoreLabels").Index(i), key, msg))
+		}
+	}
+	if spec.LogLevel != "" && !slices.Contains(tikvLogLevels, spec.LogLevel) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("logLevel"), spec.LogLevel, tikvLogLevels))
+	}
+	if spec.LogFile != nil {
+		allErrs = append(allErrs, validateTiKVLogFile(spec.LogFile, fldPath.Child("logFile"))...)
+	}
+	if v := spec.StorageAPIVersion; v != 0 && v != 1 && v != 2 {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("storageAPIVersion"), v, []string{"1", "2"}))
+	}
+	if spec.MinFreeSpace != nil && spec.MinFreeSpace.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minFreeSpace"), spec.MinFreeSpace.String(), "must be greater than or equal to 0"))
+	}
+	allErrs = append(allErrs, validateTiKVRegionSize(spec, fldPath)...)
+	if spec.StartScriptPrologue != "" && strings.TrimSpace(spec.StartScriptPrologue) == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("startScriptPrologue"), spec.StartScriptPrologue, "must not only contain whitespace"))
+	}
 	allErrs = append(allErrs, validateTimeDurationStr(spec.EvictLeaderTimeout, fldPath.Child("evictLeaderTimeout"))...)
+	for i, l := range spec.StoreLabels {
+		// the static labels are rendered into the start script, so their keys and values are restricted
+		// to the charset of k8s labels
+		key, value, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("storeLabels").Index(i), l, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("storeLabels").Index(i), l, msg))
+		}
+	}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.StartupDelaySeconds), fldPath.Child("startupDelaySeconds"))...)
 	return allErrs
 }
 
@@ -217,6 +407,15 @@ func validateTiFlashSpec(spec *v1alpha1.TiFlashSpec, fldPath *field.Path) field.
 			spec.StorageClaims, "storageClaims should be configured at least one item."))
 	}
 	allErrs = append(allErrs, validateScalePolicy(&spec.ScalePolicy, fldPath.Child("scalePolicy"))...)
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.BasePortOffset), fldPath.Child("basePortOffset"))...)
+	if spec.BasePortOffset > 0 {
+		// the default ports may be changed at build time, so check all of them
+		maxPort := slices.Max([]int32{v1alpha1.DefaultTiFlashTcpPort, v1alpha1.DefaultTiFlashHttpPort, v1alpha1.DefaultTiFlashFlashPort,
+			v1alpha1.DefaultTiFlashProxyPort, v1alpha1.DefaultTiFlashMetricsPort, v1alpha1.DefaultTiFlashProxyStatusPort, v1alpha1.DefaultTiFlashInternalPort})
+		for _, msg := range validation.IsValidPortNum(int(maxPort + spec.BasePortOffset)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("basePortOffset"), spec.BasePortOffset, msg))
+		}
+	}
 	return allErrs
 }
 
@@ -226,6 +425,9 @@ func validateTiCDCSpec(spec *v1alpha1.TiCDCSpec, fldPath *field.Path) field.Erro
 	if len(spec.StorageVolumes) > 0 {
 		allErrs = append(allErrs, validateStorageVolumes(spec.StorageVolumes, fldPath.Child("storageVolumes"))...)
 	}
+	if spec.DataVolumeName != "" {
+		allErrs = append(allErrs, validateMountedVolumeName(spec.DataVolumeName, spec.StorageVolumes, spec.AdditionalVolumes, spec.AdditionalVolumeMounts, fldPath)...)
+	}
 	return allErrs
 }
 
@@ -426,6 +628,21 @@ func validateVolumeName(volumeName string, storageVolumes []v1alpha1.StorageVolu
 	return allErrs
 }
 
+// validateMountedVolumeName makes sure the volume is known and mounted to the container,
+// so that the mount path of it could be referenced by the start script.
+func validateMountedVolumeName(volumeName string, storageVolumes []v1alpha1.StorageVolume, additionalVolumes []corev1.Volume, additionalVolumeMounts []corev1.VolumeMount, fldPath *field.Path) field.ErrorList {
+	allErrs := validateVolumeName(volumeName, storageVolumes, additionalVolumes, additionalVolumeMounts, fldPath)
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+	for _, volume := range storageVolumes {
+		if volume.Name == volumeName && volume.MountPath == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("volumeName"), volumeName, "mountPath of the storage volume must not be empty"))
+		}
+	}
+	return allErrs
+}
+
 // validateEnv validates env vars
 func validateEnv(vars []corev1.EnvVar, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -539,6 +756,8 @@ func ValidateUpdateTidbCluster(old, tc *v1alpha1.TidbCluster) field.ErrorList {
 	allErrs = append(allErrs, validateUpdatePDConfig(old.Spec.PD, tc.Spec.PD, field.NewPath("spec.pd.config"))...)
 	allErrs = append(allErrs, disallowMutateBootstrapSQLConfigMapName(old.Spec.TiDB, tc.Spec.TiDB, field.NewPath("spec.tidb.bootstrapSQLConfigMapName"))...)
 	allErrs = append(allErrs, disallowUsingLegacyAPIInNewCluster(old, tc)...)
+	allErrs = append(allErrs, disallowMutateTiKVStorageAPIVersion(old, tc, field.NewPath("spec.tikv.storageAPIVersion"))...)
+	allErrs = append(allErrs, disallowMutateTiKVDataPaths(old.Spec.TiKV, tc.Spec.TiKV, field.NewPath("spec.tikv"))...)
 
 	return allErrs
 }
@@ -662,6 +881,44 @@ func disallowMutateBootstrapSQLConfigMapName(old, new *v1alpha1.TiDBSpec, p *fie
 	return allErrs
 }
 
+// disallowMutateTiKVStorageAPIVersion checks if user mutate the storageAPIVersion of TiKV after TiKV is bootstrapped,
+// as the api version of the existing data can not be changed.
+func disallowMutateTiKVStorageAPIVersion(old, tc *v1alpha1.TidbCluster, p *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old.Spec.TiKV == nil || tc.Spec.TiKV == nil || len(old.Status.TiKV.Stores) == 0 {
+		return allErrs
+	}
+	if old.Spec.TiKV.StorageAPIVersion != tc.Spec.TiKV.StorageAPIVersion {
+		return append(allErrs, field.Invalid(p, tc.Spec.TiKV.StorageAPIVersion, "storageAPIVersion is immutable after TiKV is bootstrapped"))
+	}
+	return allErrs
+}
+
+// disallowMutateTiKVDataPaths checks if user mutate the fields which decide where TiKV stores its data,
+// TiKV would start with empty dirs and the existing data would be orphaned if they are changed.
+func disallowMutateTiKVDataPaths(old, new *v1alpha1.TiKVSpec, p *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old == nil || new == nil {
+		return allErrs
+	}
+	paths := []struct {
+		name     string
+		old, new string
+	}{
+		{"dataSubDir", old.DataSubDir, new.DataSubDir},
+		{"walVolumeName", old.WALVolumeName, new.WALVolumeName},
+		{"titanVolumeName", old.TitanVolumeName, new.TitanVolumeName},
+		{"raftEngineSubDir", old.RaftEngineSubDir, new.RaftEngineSubDir},
+	}
+	for _, path := range paths {
+		if path.old != path.new {
+			allErrs = append(allErrs, field.Invalid(p.Child(path.name), path.new,
+				fmt.Sprintf("%s is immutable after the cluster is created, changing it orphans the existing data", path.name)))
+		}
+	}
+	return allErrs
+}
+
 func validateDeleteSlots(annotations map[string]string, key string, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	if annotations != nil {
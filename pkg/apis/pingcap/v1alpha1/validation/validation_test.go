@@ -422,6 +422,53 @@ func TestValidateRequestsStorage(t *testing.T) {
 	}
 }
 
+func TestValidateTiKVDisableStatusServer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tests := []struct {
+		name                       string
+		disableStatusServer        bool
+		enableDynamicConfiguration *bool
+		expectedErrors             int
+	}{
+		{
+			name:                "status server disabled",
+			disableStatusServer: true,
+			expectedErrors:      0,
+		},
+		{
+			name:                       "status server disabled with dynamic configuration",
+			disableStatusServer:        true,
+			enableDynamicConfiguration: pointer.BoolPtr(true),
+			expectedErrors:             1,
+		},
+		{
+			name:                       "status server disabled without dynamic configuration",
+			disableStatusServer:        true,
+			enableDynamicConfiguration: pointer.BoolPtr(false),
+			expectedErrors:             0,
+		},
+		{
+			name:                       "status server enabled with dynamic configuration",
+			enableDynamicConfiguration: pointer.BoolPtr(true),
+			expectedErrors:             0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTidbCluster()
+			requests := corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("10G"),
+			}
+			tc.Spec.PD.ResourceRequirements.Requests = requests
+			tc.Spec.TiKV.ResourceRequirements.Requests = requests
+			tc.Spec.TiKV.DisableStatusServer = tt.disableStatusServer
+			tc.Spec.EnableDynamicConfiguration = tt.enableDynamicConfiguration
+			err := ValidateTidbCluster(tc)
+			g.Expect(len(err)).Should(Equal(tt.expectedErrors), "%v", err)
+		})
+	}
+}
+
 func TestValidateService(t *testing.T) {
 	g := NewGomegaWithT(t)
 	tests := []struct {
@@ -709,6 +756,115 @@ func TestValidatePDAddresses(t *testing.T) {
 	}
 }
 
+func TestValidateStartScriptV2FeatureFlags(t *testing.T) {
+	successCases := [][]v1alpha1.StartScriptV2FeatureFlag{
+		nil,
+		{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch},
+		{v1alpha1.StartScriptV2FeatureFlagSkipDnsWait},
+		{},
+		{v1alpha1.StartScriptV2FeatureFlagSkipDnsWait, v1alpha1.StartScriptV2FeatureFlagPreferPDAddressesOverDiscovery},
+		{v1alpha1.StartScriptV2FeatureFlagDualStack, v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses},
+		{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup},
+	}
+
+	for _, c := range successCases {
+		errs := validateStartScriptV2FeatureFlags(c, field.NewPath("startScriptV2FeatureFlags"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := [][]v1alpha1.StartScriptV2FeatureFlag{
+		{v1alpha1.StartScriptV2FeatureFlagSkipDnsWait, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch},
+		{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses, v1alpha1.StartScriptV2FeatureFlagSkipDnsWait},
+		{"WaitForDnsNameIPMatch"},
+		{v1alpha1.StartScriptV2FeatureFlagDualStack, ""},
+		{v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup},
+	}
+
+	for _, c := range errorCases {
+		errs := validateStartScriptV2FeatureFlags(c, field.NewPath("startScriptV2FeatureFlags"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %s", c)
+		}
+	}
+
+	errs := validateStartScriptV2FeatureFlags([]v1alpha1.StartScriptV2FeatureFlag{"SkipDNSWait"}, field.NewPath("startScriptV2FeatureFlags"))
+	if len(errs) != 1 || errs[0].Type != field.ErrorTypeNotSupported || errs[0].Field != "startScriptV2FeatureFlags[0]" {
+		t.Errorf("expected not supported error for the unknown flag: %v", errs)
+	}
+	if msg := errs.ToAggregate().Error(); !strings.Contains(msg, `"SkipDNSWait"`) || !strings.Contains(msg, `"SkipDnsWait"`) {
+		t.Errorf("expected the unknown flag and the supported flags in the error: %s", msg)
+	}
+}
+
+func TestValidateStartScriptV2Shell(t *testing.T) {
+	successCases := []string{"/bin/sh", "/bin/bash", "/usr/local/bin/bash5"}
+	for _, c := range successCases {
+		errs := validateStartScriptV2Shell(c, field.NewPath("startScriptV2Shell"))
+		if len(errs) > 0 {
+			t.Errorf("expected success for %q: %v", c, errs)
+		}
+	}
+
+	errorCases := []string{"bash", "/bin/", "/bin/../bin/bash", "/usr/bin/env bash", "/bin/sh\nrm -rf /"}
+	for _, c := range errorCases {
+		errs := validateStartScriptV2Shell(c, field.NewPath("startScriptV2Shell"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %q", c)
+		}
+	}
+}
+
+func TestValidateDnsWaitResolver(t *testing.T) {
+	successCases := []string{"10.0.0.10", "169.254.20.10", "fd00::10"}
+	for _, c := range successCases {
+		errs := validateDnsWaitResolver(c, field.NewPath("dnsWaitResolver"))
+		if len(errs) > 0 {
+			t.Errorf("expected success for %q: %v", c, errs)
+		}
+	}
+
+	errorCases := []string{"kube-dns.kube-system", "10.0.0.10:53", "[fd00::10]", "10.0.0.10; rm -rf /"}
+	for _, c := range errorCases {
+		errs := validateDnsWaitResolver(c, field.NewPath("dnsWaitResolver"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %q", c)
+		}
+	}
+}
+
+func TestValidateSidecarReadiness(t *testing.T) {
+	successCases := []v1alpha1.SidecarReadinessSpec{
+		{URL: "http://localhost:15021/healthz/ready"},
+		{URL: "https://127.0.0.1:8443/ready?probe=1", Timeout: 60},
+		{URL: "http://[::1]:15021/healthz/ready"},
+	}
+	for _, c := range successCases {
+		errs := validateSidecarReadiness(&c, field.NewPath("sidecarReadiness"))
+		if len(errs) > 0 {
+			t.Errorf("expected success for %+v: %v", c, errs)
+		}
+	}
+
+	errorCases := []struct {
+		spec  v1alpha1.SidecarReadinessSpec
+		field string
+	}{
+		{v1alpha1.SidecarReadinessSpec{URL: "localhost:15021/healthz/ready"}, "sidecarReadiness.url"},
+		{v1alpha1.SidecarReadinessSpec{URL: "tcp://localhost:15021"}, "sidecarReadiness.url"},
+		{v1alpha1.SidecarReadinessSpec{URL: "http:///healthz/ready"}, "sidecarReadiness.url"},
+		{v1alpha1.SidecarReadinessSpec{URL: "http://localhost:15021/$(reboot)"}, "sidecarReadiness.url"},
+		{v1alpha1.SidecarReadinessSpec{URL: "http://localhost:15021/healthz/ready", Timeout: -1}, "sidecarReadiness.timeout"},
+	}
+	for _, c := range errorCases {
+		errs := validateSidecarReadiness(&c.spec, field.NewPath("sidecarReadiness"))
+		if len(errs) != 1 || errs[0].Field != c.field {
+			t.Errorf("expected failure of %s for %+v: %v", c.field, c.spec, errs)
+		}
+	}
+}
+
 func TestValidatePDSpec(t *testing.T) {
 	g := NewGomegaWithT(t)
 	tests := []struct {
@@ -756,6 +912,364 @@ func TestValidatePDSpec(t *testing.T) {
 	}
 }
 
+func TestValidateTiKVStoreLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tests := []struct {
+		name           string
+		storeLabels    []string
+		expectedErrors int
+	}{
+		{
+			name:           "static store labels",
+			storeLabels:    []string{"host", "rack=r1", "example.com/dc=dc-1"},
+			expectedErrors: 0,
+		},
+		{
+			name:           "static store labels are invalid",
+			storeLabels:    []string{"rack=r1", "rack=$(id)", "a b=c"},
+			expectedErrors: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTidbCluster()
+			tc.Spec.TiKV.ResourceRequirements = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("10G"),
+				},
+			}
+			tc.Spec.TiKV.StoreLabels = tt.storeLabels
+			err := validateTiKVSpec(tc.Spec.TiKV, field.NewPath("tikv"))
+			g.Expect(len(err)).Should(Equal(tt.expectedErrors))
+		})
+	}
+}
+
+func TestValidateTiKVSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tests := []struct {
+		name           string
+		modify         func(spec *v1alpha1.TiKVSpec)
+		expectedErrors int
+	}{
+		{
+			name:           "basic",
+			modify:         func(spec *v1alpha1.TiKVSpec) {},
+			expectedErrors: 0,
+		},
+		{
+			name: "wal volume in storage volumes",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"}}
+				spec.WALVolumeName = "wal"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "wal volume in additional volumes",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.AdditionalVolumes = []corev1.Volume{{Name: "wal"}}
+				spec.AdditionalVolumeMounts = []corev1.VolumeMount{{Name: "wal", MountPath: "/mnt/wal"}}
+				spec.WALVolumeName = "wal"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "wal volume is unknown",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.WALVolumeName = "wal"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "wal volume is not mounted",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "1Gi"}}
+				spec.WALVolumeName = "wal"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "titan volume in storage volumes",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StorageVolumes = []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}}
+				spec.TitanVolumeName = "titan"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "titan volume is unknown",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.TitanVolumeName = "titan"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "negative startup delay",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StartupDelaySeconds = -1
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "min free space",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				q := resource.MustParse("10Gi")
+				spec.MinFreeSpace = &q
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "negative min free space",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				q := resource.MustParse("-1")
+				spec.MinFreeSpace = &q
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "region sizes",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				split, max := resource.MustParse("256Mi"), resource.MustParse("384Mi")
+				spec.RegionSplitSize, spec.RegionMaxSize = &split, &max
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "region split size is not a multiple of 1Mi",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				q := resource.MustParse("256M")
+				spec.RegionSplitSize = &q
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "region max size is not positive",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				q := resource.MustParse("0")
+				spec.RegionMaxSize = &q
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "region max size is less than region split size",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				split, max := resource.MustParse("1Gi"), resource.MustParse("512Mi")
+				spec.RegionSplitSize, spec.RegionMaxSize = &split, &max
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "encryption master key secret",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.EncryptionConfig = &v1alpha1.TiKVEncryptionSecretConfig{MasterKeySecretName: "master-key"}
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "encryption master key secret is empty",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.EncryptionConfig = &v1alpha1.TiKVEncryptionSecretConfig{PreviousMasterKeySecretName: "previous-master-key"}
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "encryption master key secret with other config",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.EncryptionConfig = &v1alpha1.TiKVEncryptionSecretConfig{MasterKeySecretName: "master-key"}
+				spec.Config = v1alpha1.NewTiKVConfig()
+				spec.Config.Set("security.encryption.data-encryption-method", "aes128-ctr")
+				spec.Config.Set("security.encryption.previous-master-key.type", "plaintext")
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "encryption master key secret conflicts with config",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.EncryptionConfig = &v1alpha1.TiKVEncryptionSecretConfig{
+					MasterKeySecretName:         "master-key",
+					PreviousMasterKeySecretName: "previous-master-key",
+				}
+				spec.Config = v1alpha1.NewTiKVConfig()
+				spec.Config.Set("security.encryption.master-key.type", "kms")
+				spec.Config.Set("security.encryption.previous-master-key.path", "/tmp/key")
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "log level",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.LogLevel = "debug"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "log level is unknown",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.LogLevel = "warning"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "advertise host suffix",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.AdvertiseHostSuffix = "tikv.example.com"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "advertise host suffix is invalid",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.AdvertiseHostSuffix = ".tikv.example.com"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "peer service name",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.PeerServiceName = "legacy-tikv-peer"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "peer service name is invalid",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.PeerServiceName = "legacy.tikv.peer"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "advertise port",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.AdvertisePort = 30160
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "advertise port is out of range",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.AdvertisePort = 65536
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "log file",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log", MaxSize: 300, MaxBackups: 10}
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "log file is invalid",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "log/tikv.log", MaxSize: -1, MaxBackups: -1}
+			},
+			expectedErrors: 3,
+		},
+		{
+			name: "storage api version",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StorageAPIVersion = 2
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "storage api version is invalid",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StorageAPIVersion = 3
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "start script prologue",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StartScriptPrologue = "ulimit -n 1000000"
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "start script prologue only contains whitespace",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.StartScriptPrologue = " \n\t"
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "pod labels as store labels",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.PodLabelsAsStoreLabels = []string{"rack", "example.com/host-group"}
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "pod labels as store labels are invalid",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.PodLabelsAsStoreLabels = []string{"rack", "rack=r1", "$(id)"}
+			},
+			expectedErrors: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTidbCluster()
+			tc.Spec.TiKV.ResourceRequirements = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("10G"),
+				},
+			}
+			tt.modify(tc.Spec.TiKV)
+			err := validateTiKVSpec(tc.Spec.TiKV, field.NewPath("tikv"))
+			g.Expect(len(err)).Should(Equal(tt.expectedErrors), "%v", err)
+		})
+	}
+}
+
+func TestValidateTiFlashSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tests := []struct {
+		name           string
+		modify         func(spec *v1alpha1.TiFlashSpec)
+		expectedErrors int
+	}{
+		{
+			name:           "basic",
+			modify:         func(spec *v1alpha1.TiFlashSpec) {},
+			expectedErrors: 0,
+		},
+		{
+			name: "base port offset",
+			modify: func(spec *v1alpha1.TiFlashSpec) {
+				spec.BasePortOffset = 10000
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "base port offset is negative",
+			modify: func(spec *v1alpha1.TiFlashSpec) {
+				spec.BasePortOffset = -1
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "base port offset makes ports out of range",
+			modify: func(spec *v1alpha1.TiFlashSpec) {
+				spec.BasePortOffset = 50000
+			},
+			expectedErrors: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &v1alpha1.TiFlashSpec{
+				StorageClaims: []v1alpha1.StorageClaim{{}},
+			}
+			tt.modify(spec)
+			err := validateTiFlashSpec(spec, field.NewPath("tiflash"))
+			g.Expect(len(err)).Should(Equal(tt.expectedErrors), "%v", err)
+		})
+	}
+}
+
 func Test_disallowMutateBootstrapSQLConfigMapName(t *testing.T) {
 	g := NewGomegaWithT(t)
 	tests := []struct {
@@ -826,3 +1340,151 @@ func Test_disallowMutateBootstrapSQLConfigMapName(t *testing.T) {
 		})
 	}
 }
+
+func Test_disallowMutateTiKVStorageAPIVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	newTC := func(apiVersion int32, bootstrapped bool) *v1alpha1.TidbCluster {
+		tc := newTidbCluster()
+		tc.Spec.TiKV.StorageAPIVersion = apiVersion
+		if bootstrapped {
+			tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{"1": {ID: "1"}}
+		}
+		return tc
+	}
+	tests := []struct {
+		name      string
+		old       *v1alpha1.TidbCluster
+		new       *v1alpha1.TidbCluster
+		wantError bool
+	}{
+		{
+			name:      "set before bootstrap",
+			old:       newTC(0, false),
+			new:       newTC(2, false),
+			wantError: false,
+		},
+		{
+			name:      "no change after bootstrap",
+			old:       newTC(2, true),
+			new:       newTC(2, true),
+			wantError: false,
+		},
+		{
+			name:      "set after bootstrap",
+			old:       newTC(0, true),
+			new:       newTC(2, true),
+			wantError: true,
+		},
+		{
+			name:      "flip after bootstrap",
+			old:       newTC(2, true),
+			new:       newTC(1, true),
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := disallowMutateTiKVStorageAPIVersion(tt.old, tt.new, field.NewPath("spec.tikv.storageAPIVersion"))
+			if tt.wantError {
+				g.Expect(len(errs)).NotTo(Equal(0))
+			} else {
+				g.Expect(len(errs)).To(Equal(0))
+			}
+		})
+	}
+}
+
+func Test_disallowMutateTiKVDataPaths(t *testing.T) {
+	g := NewGomegaWithT(t)
+	newTC := func(modify func(spec *v1alpha1.TiKVSpec)) *v1alpha1.TidbCluster {
+		tc := newTidbCluster()
+		tc.Spec.TiKV.DataSubDir = "data"
+		tc.Spec.TiKV.WALVolumeName = "wal"
+		if modify != nil {
+			modify(tc.Spec.TiKV)
+		}
+		return tc
+	}
+	// tikvPathErrs returns the errors of the data paths of TiKV, other errors of the fixture are ignored
+	tikvPathErrs := func(errs field.ErrorList) field.ErrorList {
+		var pathErrs field.ErrorList
+		for _, err := range errs {
+			if strings.Contains(err.Detail, "is immutable after the cluster is created") {
+				pathErrs = append(pathErrs, err)
+			}
+		}
+		return pathErrs
+	}
+
+	g.Expect(tikvPathErrs(ValidateCreateTidbCluster(newTC(nil)))).To(BeEmpty())
+
+	tests := []struct {
+		name   string
+		modify func(spec *v1alpha1.TiKVSpec)
+		fields []string
+	}{
+		{
+			name: "no-op update",
+		},
+		{
+			name:   "change dataSubDir",
+			modify: func(spec *v1alpha1.TiKVSpec) { spec.DataSubDir = "data2" },
+			fields: []string{"spec.tikv.dataSubDir"},
+		},
+		{
+			name:   "unset dataSubDir",
+			modify: func(spec *v1alpha1.TiKVSpec) { spec.DataSubDir = "" },
+			fields: []string{"spec.tikv.dataSubDir"},
+		},
+		{
+			name: "change the other data paths",
+			modify: func(spec *v1alpha1.TiKVSpec) {
+				spec.WALVolumeName = ""
+				spec.TitanVolumeName = "titan"
+				spec.RaftEngineSubDir = "raft-engine"
+			},
+			fields: []string{"spec.tikv.walVolumeName", "spec.tikv.titanVolumeName", "spec.tikv.raftEngineSubDir"},
+		},
+		{
+			name:   "change the other fields",
+			modify: func(spec *v1alpha1.TiKVSpec) { spec.Replicas = 3 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tikvPathErrs(ValidateUpdateTidbCluster(newTC(nil), newTC(tt.modify)))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tt.fields))
+		})
+	}
+}
+
+func TestValidateAcrossK8sVerification(t *testing.T) {
+	successCases := []v1alpha1.AcrossK8sVerificationSpec{
+		{},
+		{MaxRetries: 3},
+		{CABundlePath: "/var/lib/discovery-ca/ca.crt"},
+	}
+
+	for _, c := range successCases {
+		errs := validateAcrossK8sVerification(&c, field.NewPath("acrossK8sVerification"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.AcrossK8sVerificationSpec{
+		{CABundlePath: "ca.crt"},
+		{CABundlePath: "./discovery-ca/ca.crt"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateAcrossK8sVerification(&c, field.NewPath("acrossK8sVerification"))
+		if len(errs) != 1 || errs[0].Field != "acrossK8sVerification.caBundlePath" {
+			t.Errorf("expected failure for %s: %v", c.CABundlePath, errs)
+		}
+	}
+}
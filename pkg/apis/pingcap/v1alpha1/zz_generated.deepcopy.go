@@ -32,6 +32,22 @@ import (
 	types "k8s.io/apimachinery/pkg/types"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcrossK8sVerificationSpec) DeepCopyInto(out *AcrossK8sVerificationSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcrossK8sVerificationSpec.
+func (in *AcrossK8sVerificationSpec) DeepCopy() *AcrossK8sVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AcrossK8sVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoResource) DeepCopyInto(out *AutoResource) {
 	*out = *in
@@ -1575,6 +1591,11 @@ func (in *DiscoverySpec) DeepCopyInto(out *DiscoverySpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.ResourceRequirements.DeepCopyInto(&out.ResourceRequirements)
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -5121,6 +5142,22 @@ func (in *ServiceSpec) DeepCopy() *ServiceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarReadinessSpec) DeepCopyInto(out *SidecarReadinessSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarReadinessSpec.
+func (in *SidecarReadinessSpec) DeepCopy() *SidecarReadinessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarReadinessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
@@ -5878,6 +5915,22 @@ func (in *TiDBMember) DeepCopy() *TiDBMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBPreStopSpec) DeepCopyInto(out *TiDBPreStopSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBPreStopSpec.
+func (in *TiDBPreStopSpec) DeepCopy() *TiDBPreStopSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBPreStopSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiDBServiceSpec) DeepCopyInto(out *TiDBServiceSpec) {
 	*out = *in
@@ -6026,6 +6079,11 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PreStop != nil {
+		in, out := &in.PreStop, &out.PreStop
+		*out = new(TiDBPreStopSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -7115,6 +7173,22 @@ func (in *TiKVEncryptionConfig) DeepCopy() *TiKVEncryptionConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVEncryptionSecretConfig) DeepCopyInto(out *TiKVEncryptionSecretConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVEncryptionSecretConfig.
+func (in *TiKVEncryptionSecretConfig) DeepCopy() *TiKVEncryptionSecretConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVEncryptionSecretConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVFailureStore) DeepCopyInto(out *TiKVFailureStore) {
 	*out = *in
@@ -7236,6 +7310,22 @@ func (in *TiKVImportConfig) DeepCopy() *TiKVImportConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVLogFileSpec) DeepCopyInto(out *TiKVLogFileSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVLogFileSpec.
+func (in *TiKVLogFileSpec) DeepCopy() *TiKVLogFileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVLogFileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVMasterKeyConfig) DeepCopyInto(out *TiKVMasterKeyConfig) {
 	*out = *in
@@ -7331,6 +7421,22 @@ func (in *TiKVPessimisticTxn) DeepCopy() *TiKVPessimisticTxn {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVPreStopSpec) DeepCopyInto(out *TiKVPreStopSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVPreStopSpec.
+func (in *TiKVPreStopSpec) DeepCopy() *TiKVPreStopSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVPreStopSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVRaftDBConfig) DeepCopyInto(out *TiKVRaftDBConfig) {
 	*out = *in
@@ -8142,7 +8248,47 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PodLabelsAsStoreLabels != nil {
+		in, out := &in.PodLabelsAsStoreLabels, &out.PodLabelsAsStoreLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.ScalePolicy.DeepCopyInto(&out.ScalePolicy)
+	if in.EncryptionConfig != nil {
+		in, out := &in.EncryptionConfig, &out.EncryptionConfig
+		*out = new(TiKVEncryptionSecretConfig)
+		**out = **in
+	}
+	if in.PreStop != nil {
+		in, out := &in.PreStop, &out.PreStop
+		*out = new(TiKVPreStopSpec)
+		**out = **in
+	}
+	if in.LogFile != nil {
+		in, out := &in.LogFile, &out.LogFile
+		*out = new(TiKVLogFileSpec)
+		**out = **in
+	}
+	if in.AdditionalStartupFlags != nil {
+		in, out := &in.AdditionalStartupFlags, &out.AdditionalStartupFlags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinFreeSpace != nil {
+		in, out := &in.MinFreeSpace, &out.MinFreeSpace
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.RegionSplitSize != nil {
+		in, out := &in.RegionSplitSize, &out.RegionSplitSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.RegionMaxSize != nil {
+		in, out := &in.RegionMaxSize, &out.RegionMaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 	return
 }
 
@@ -9031,6 +9177,11 @@ func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.AcrossK8sVerification != nil {
+		in, out := &in.AcrossK8sVerification, &out.AcrossK8sVerification
+		*out = new(AcrossK8sVerificationSpec)
+		**out = **in
+	}
 	if in.Cluster != nil {
 		in, out := &in.Cluster, &out.Cluster
 		*out = new(TidbClusterRef)
@@ -9063,6 +9214,11 @@ func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 		*out = make([]StartScriptV2FeatureFlag, len(*in))
 		copy(*out, *in)
 	}
+	if in.SidecarReadiness != nil {
+		in, out := &in.SidecarReadiness, &out.SidecarReadiness
+		*out = new(SidecarReadinessSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -310,6 +310,16 @@ func PDPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-pd-peer", clusterName)
 }
 
+// PDMSMemberName returns the member name of a PD microservice component, e.g. tso and scheduling
+func PDMSMemberName(clusterName, component string) string {
+	return fmt.Sprintf("%s-%s", clusterName, component)
+}
+
+// PDMSPeerMemberName returns the peer service name of a PD microservice component
+func PDMSPeerMemberName(clusterName, component string) string {
+	return fmt.Sprintf("%s-%s-peer", clusterName, component)
+}
+
 // TiKVMemberName returns tikv member name
 func TiKVMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tikv", clusterName)
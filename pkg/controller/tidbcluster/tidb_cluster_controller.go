@@ -19,8 +19,10 @@ import (
 
 	perrors "github.com/pingcap/errors"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -95,6 +97,19 @@ func NewController(deps *controller.Dependencies) *Controller {
 		},
 		DeleteFunc: c.deleteStatefulSet,
 	})
+	// the user templates of start scripts v2 are read by the lister of the label filtered informer, so only the
+	// ConfigMaps managed by tidb-operator are watched
+	configMapInformer := deps.LabelFilterKubeInformerFactory.Core().V1().ConfigMaps()
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueTidbClustersForConfigMap,
+		UpdateFunc: func(old, cur interface{}) {
+			if old.(*corev1.ConfigMap).ResourceVersion == cur.(*corev1.ConfigMap).ResourceVersion {
+				return
+			}
+			c.enqueueTidbClustersForConfigMap(cur)
+		},
+		DeleteFunc: c.enqueueTidbClustersForConfigMap,
+	})
 
 	return c
 }
@@ -188,6 +203,37 @@ func (c *Controller) enqueueTidbCluster(obj interface{}) {
 	c.queue.Add(key)
 }
 
+// enqueueTidbClustersForConfigMap enqueues the tidbclusters which use the ConfigMap as the user templates of
+// start scripts v2, so that the start scripts are re-rendered once the templates are changed.
+func (c *Controller) enqueueTidbClustersForConfigMap(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %+v", obj))
+			return
+		}
+		cm, ok = tombstone.Obj.(*corev1.ConfigMap)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("tombstone contained object that is not a configmap %+v", obj))
+			return
+		}
+	}
+
+	tcs, err := c.deps.TiDBClusterLister.TidbClusters(cm.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list TidbClusters in namespace %s: %v", cm.Namespace, err))
+		return
+	}
+	for _, tc := range tcs {
+		if tc.Spec.StartScriptV2TemplateConfigMap != cm.Name {
+			continue
+		}
+		klog.V(4).Infof("ConfigMap %s/%s of start script templates changed, TidbCluster: %s/%s", cm.Namespace, cm.Name, tc.Namespace, tc.Name)
+		c.enqueueTidbCluster(tc)
+	}
+}
+
 // addStatefulSet adds the tidbcluster for the statefulset to the sync queue
 func (c *Controller) addStatefulSet(obj interface{}) {
 	set := obj.(*apps.StatefulSet)
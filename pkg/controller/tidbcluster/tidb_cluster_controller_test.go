@@ -194,6 +194,44 @@ func TestTidbClusterControllerUpdateStatefulSet(t *testing.T) {
 	}
 }
 
+func TestTidbClusterControllerEnqueueTidbClustersForConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Spec.StartScriptV2TemplateConfigMap = "start-script-templates"
+	other := newTidbCluster()
+	other.Name = "other"
+
+	fakeDeps := controller.NewFakeDependencies()
+	tcc := NewController(fakeDeps)
+	tcc.control = NewFakeTidbClusterControlInterface()
+	tcIndexer := fakeDeps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer().GetIndexer()
+	g.Expect(tcIndexer.Add(tc)).To(Succeed())
+	g.Expect(tcIndexer.Add(other)).To(Succeed())
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "start-script-templates",
+			Namespace: corev1.NamespaceDefault,
+		},
+	}
+	tcc.enqueueTidbClustersForConfigMap(cm)
+	g.Expect(tcc.queue.Len()).To(Equal(1))
+	key, _ := tcc.queue.Get()
+	g.Expect(key).To(Equal(fmt.Sprintf("%s/%s", tc.Namespace, tc.Name)))
+	tcc.queue.Done(key)
+
+	tcc.enqueueTidbClustersForConfigMap(cache.DeletedFinalStateUnknown{Key: "default/start-script-templates", Obj: cm})
+	g.Expect(tcc.queue.Len()).To(Equal(1))
+	key, _ = tcc.queue.Get()
+	tcc.queue.Done(key)
+
+	otherCM := cm.DeepCopy()
+	otherCM.Name = "other"
+	tcc.enqueueTidbClustersForConfigMap(otherCM)
+	g.Expect(tcc.queue.Len()).To(Equal(0))
+}
+
 func TestTidbClusterControllerSync(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
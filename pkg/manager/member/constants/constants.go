@@ -17,12 +17,53 @@ const (
 	// TiProxyVolumeMountPath is the path for tiproxy data volume
 	TiProxyVolumeMountPath = "/var/lib/tiproxy"
 
+	// PodInfoMountPath is the mount path of the downward API volume of Pod annotations
+	PodInfoMountPath = "/etc/podinfo"
+	// PodLabelsFileName is the file of Pod labels in the downward API volume, it is only mounted to TiKV
+	// if some Pod labels are passed as store labels
+	PodLabelsFileName = "labels"
+
 	// TiKVDataVolumeMountPath is the mount path for tikv data volume
 	TiKVDataVolumeMountPath = "/var/lib/tikv"
 
+	// TiKVCPULimitEnv is the env of the CPU limit of tikv container in millicores, it is only set if the limit is configured
+	// with the TiKVCPUQuota start script feature flag
+	TiKVCPULimitEnv = "TIKV_CPU_LIMIT"
+
+	// TiKVMemoryLimitEnv is the env of the memory limit of tikv container in bytes, it is only set if the limit is configured
+	// and the block cache is sized by it
+	TiKVMemoryLimitEnv = "TIKV_MEMORY_LIMIT"
+
+	// CPULimitCoresEnv is the env of the CPU limit of Go component containers rounded up to cores,
+	// it is only set with the GoMaxProcs feature flag of start script v2 if the limit is configured
+	CPULimitCoresEnv = "CPU_LIMIT_CORES"
+
+	// TiKVEncryptionMasterKeyMountPath is the mount path for the secret of tikv encryption master key
+	TiKVEncryptionMasterKeyMountPath = "/var/lib/tikv-encryption/master-key"
+
+	// TiKVEncryptionPreviousMasterKeyMountPath is the mount path for the secret of tikv encryption previous master key
+	TiKVEncryptionPreviousMasterKeyMountPath = "/var/lib/tikv-encryption/previous-master-key"
+
+	// TiKVEncryptionMasterKeySecretKey is the key of the master key in the secret of tikv encryption
+	TiKVEncryptionMasterKeySecretKey = "master-key"
+
 	// PDDataVolumeMountPath is the mount path for pd data volume
 	PDDataVolumeMountPath = "/var/lib/pd"
 
+	// TiKVCertPath is the path for tikv cert in container
+	TiKVCertPath = "/var/lib/tikv-tls"
+
+	// TiDBCertPath is the path for tidb cluster cert in container
+	TiDBCertPath = "/var/lib/tidb-tls"
+
 	// TiCDCCertPath is the path for ticdc cert in container
 	TiCDCCertPath = "/var/lib/ticdc-tls"
+
+	// nolint: gosec
+	// TiDBAuthTokenPath is where the assets for auth tidb client stored. Such as: tidb auth token JWKS
+	TiDBAuthTokenPath = "/var/lib/tidb-auth-token"
+
+	// nolint: gosec
+	// TiDBAuthTokenJWKS is the key of the JWKS in the secret of tidb auth token, it is the file name under TiDBAuthTokenPath
+	TiDBAuthTokenJWKS = "tidb_auth_token_jwks.json"
 )
@@ -732,7 +732,7 @@ func getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (
 		Name:            v1alpha1.PDMemberType.String(),
 		Image:           tc.PDImage(),
 		ImagePullPolicy: basePDSpec.ImagePullPolicy(),
-		Command:         []string{"/bin/sh", "/usr/local/bin/pd_start_script.sh"},
+		Command:         startscript.StartScriptFileCommand(tc, "/usr/local/bin/pd_start_script.sh"),
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "server",
@@ -803,6 +803,7 @@ func getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (
 			},
 		})
 	}
+	env = append(env, cpuLimitCoresEnv(tc, v1alpha1.PDMemberType.String(), tc.Spec.PD.Limits)...)
 	pdContainer.Env = util.AppendEnv(env, basePDSpec.Env())
 	pdContainer.EnvFrom = basePDSpec.EnvFrom()
 	podSpec.Volumes = append(vols, basePDSpec.AdditionalVolumes()...)
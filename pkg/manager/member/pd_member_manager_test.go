@@ -1196,6 +1196,70 @@ func TestGetNewPDSetForTidbCluster(t *testing.T) {
 			},
 			testSts: testHostNetwork(t, true, v1.DNSClusterFirstWithHostNet),
 		},
+		{
+			name: "pd with cpu limit and GoMaxProcs feature flag",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{
+						ResourceRequirements: v1.ResourceRequirements{
+							Limits: v1.ResourceList{
+								v1.ResourceCPU: resource.MustParse("1500m"),
+							},
+						},
+					},
+					TiKV:                      &v1alpha1.TiKVSpec{},
+					TiDB:                      &v1alpha1.TiDBSpec{},
+					StartScriptVersion:        v1alpha1.StartScriptV2,
+					StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagGoMaxProcs},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				pdContainer := MapContainers(&sts.Spec.Template.Spec)[v1alpha1.PDMemberType.String()]
+				g.Expect(pdContainer.Env).To(ContainElement(v1.EnvVar{
+					Name: "CPU_LIMIT_CORES",
+					ValueFrom: &v1.EnvVarSource{
+						ResourceFieldRef: &v1.ResourceFieldSelector{
+							ContainerName: "pd",
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1"),
+						},
+					},
+				}), "Expected the CPU limit of pd in cores is injected")
+			},
+		},
+		{
+			name: "pd with cpu limit and without GoMaxProcs feature flag",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{
+						ResourceRequirements: v1.ResourceRequirements{
+							Limits: v1.ResourceList{
+								v1.ResourceCPU: resource.MustParse("1500m"),
+							},
+						},
+					},
+					TiKV:               &v1alpha1.TiKVSpec{},
+					TiDB:               &v1alpha1.TiDBSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				pdContainer := MapContainers(&sts.Spec.Template.Spec)[v1alpha1.PDMemberType.String()]
+				for _, env := range pdContainer.Env {
+					g.Expect(env.Name).NotTo(Equal("CPU_LIMIT_CORES"))
+				}
+			},
+		},
 		{
 			name: "pd network is not host when tidb is host",
 			tc: v1alpha1.TidbCluster{
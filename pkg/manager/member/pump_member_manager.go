@@ -451,11 +451,7 @@ func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*app
 			Name:            "pump",
 			Image:           *tc.PumpImage(),
 			ImagePullPolicy: spec.ImagePullPolicy(),
-			Command: []string{
-				"/bin/sh",
-				"-c",
-				startScript,
-			},
+			Command:         startscript.StartScriptCommand(tc, startScript),
 			Ports: []corev1.ContainerPort{{
 				Name:          "pump",
 				ContainerPort: v1alpha1.DefaultPumpPort,
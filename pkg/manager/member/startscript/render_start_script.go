@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	v1 "github.com/pingcap/tidb-operator/pkg/manager/member/startscript/v1"
 	v2 "github.com/pingcap/tidb-operator/pkg/manager/member/startscript/v2"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 )
 
 var (
@@ -58,12 +59,47 @@ var (
 		v1alpha1.StartScriptV1: v1.RenderTiFlashInitScript,
 		v1alpha1.StartScriptV2: v2.RenderTiFlashInitScript,
 	}
+	tiproxy = RenderMap{
+		v1alpha1.StartScriptV1: v1.RenderTiProxyStartScript,
+		v1alpha1.StartScriptV2: v2.RenderTiProxyStartScript,
+	}
 )
 
 func RenderTiKVStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	return tikv[tc.StartScriptVersion()](tc)
 }
 
+// RenderTiKVStartScriptWithInlineConfig renders TiKV start script which writes the config file of TiKV
+// by a heredoc instead of reading the mounted one, it is only supported by start script v2.
+func RenderTiKVStartScriptWithInlineConfig(tc *v1alpha1.TidbCluster, tikvConfig string) (string, error) {
+	switch tc.StartScriptVersion() {
+	case v1alpha1.StartScriptV2:
+		return v2.RenderTiKVStartScriptWithInlineConfig(tc, tikvConfig)
+	default:
+		return "", ErrVersionNotFound
+	}
+}
+
+// RenderTiKVPreStopScript renders TiKV preStop script, which is the same for all start script versions.
+func RenderTiKVPreStopScript(tc *v1alpha1.TidbCluster) (string, error) {
+	switch tc.StartScriptVersion() {
+	case v1alpha1.StartScriptV1, v1alpha1.StartScriptV2:
+		return v2.RenderTiKVPreStopScript(tc)
+	default:
+		return "", ErrVersionNotFound
+	}
+}
+
+// RenderTiDBPreStopScript renders TiDB preStop script, which is the same for all start script versions.
+func RenderTiDBPreStopScript(tc *v1alpha1.TidbCluster) (string, error) {
+	switch tc.StartScriptVersion() {
+	case v1alpha1.StartScriptV1, v1alpha1.StartScriptV2:
+		return v2.RenderTiDBPreStopScript(tc)
+	default:
+		return "", ErrVersionNotFound
+	}
+}
+
 func RenderPDStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	return pd[tc.StartScriptVersion()](tc)
 }
@@ -89,10 +125,41 @@ func RenderTiFlashInitScript(tc *v1alpha1.TidbCluster) (string, error) {
 }
 
 func RenderTiProxyStartScript(tc *v1alpha1.TidbCluster) (string, error) {
-	switch tc.StartScriptVersion() {
-	case v1alpha1.StartScriptV1, v1alpha1.StartScriptV2:
-		return v2.RenderTiProxyStartScript(tc)
-	default:
-		return "", ErrVersionNotFound
+	return tiproxy[tc.StartScriptVersion()](tc)
+}
+
+// StartScriptCommand returns the command of the container which runs the rendered start script inline,
+// which is the same for all start script versions.
+func StartScriptCommand(tc *v1alpha1.TidbCluster, script string) []string {
+	return v2.StartScriptCommand(tc, script)
+}
+
+// StartScriptFileCommand returns the command of the container which runs the start script mounted at path,
+// which is the same for all start script versions.
+func StartScriptFileCommand(tc *v1alpha1.TidbCluster, path string) []string {
+	return v2.StartScriptFileCommand(tc, path)
+}
+
+// TiKVRequiredEnvVars returns the env vars which must be set in the container to run the TiKV start script.
+// Only the v2 start script reports them, the v1 one relies on the env set by the member manager as before.
+func TiKVRequiredEnvVars(tc *v1alpha1.TidbCluster) []string {
+	if tc.StartScriptVersion() == v1alpha1.StartScriptV2 {
+		return v2.TiKVRequiredEnvVars(tc)
+	}
+	return nil
+}
+
+// NewConfigMapTemplateSource returns the source of the user templates of start scripts v2 which reads them from
+// the ConfigMap referred by StartScriptV2TemplateConfigMap of TidbCluster. The ConfigMap is read by the lister of
+// the informers filtered by the labels of the resources managed by the operator, so it must have the label
+// app.kubernetes.io/managed-by=tidb-operator.
+func NewConfigMapTemplateSource(cmLister corelisterv1.ConfigMapLister) v2.TemplateSource {
+	return func(tc *v1alpha1.TidbCluster, name string) (string, bool, error) {
+		cm, err := cmLister.ConfigMaps(tc.Namespace).Get(tc.Spec.StartScriptV2TemplateConfigMap)
+		if err != nil {
+			return "", false, err
+		}
+		text, ok := cm.Data[name]
+		return text, ok, nil
 	}
 }
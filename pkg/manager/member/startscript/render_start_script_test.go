@@ -20,6 +20,10 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 
 	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestRenderStartScriptRoute(t *testing.T) {
@@ -90,3 +94,30 @@ func mockRenderFunc(expectedVer v1alpha1.StartScriptVersion) {
 		}
 	}
 }
+
+func TestConfigMapTemplateSource(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cmInformer := kubeinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Core().V1().ConfigMaps()
+	g.Expect(cmInformer.Informer().GetIndexer().Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "start-script-templates"},
+		Data:       map[string]string{"tikv-start-script": "#!/bin/sh\nexec /tikv-server\n"},
+	})).Should(gomega.Succeed())
+	source := NewConfigMapTemplateSource(cmInformer.Lister())
+
+	tc := &v1alpha1.TidbCluster{}
+	tc.Namespace = "ns"
+	tc.Spec.StartScriptV2TemplateConfigMap = "start-script-templates"
+	text, found, err := source(tc, "tikv-start-script")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(found).Should(gomega.BeTrue())
+	g.Expect(text).Should(gomega.Equal("#!/bin/sh\nexec /tikv-server\n"))
+
+	_, found, err = source(tc, "tidb-start-script")
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(found).Should(gomega.BeFalse())
+
+	tc.Spec.StartScriptV2TemplateConfigMap = "not-found"
+	_, _, err = source(tc, "tikv-start-script")
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
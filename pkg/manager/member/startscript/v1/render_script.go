@@ -220,3 +220,8 @@ sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
 
 	return script, nil
 }
+
+// RenderTiProxyStartScript renders the start script of TiProxy, TiProxy is started with the config file only.
+func RenderTiProxyStartScript(tc *v1alpha1.TidbCluster) (string, error) {
+	return tiproxyStartScript, nil
+}
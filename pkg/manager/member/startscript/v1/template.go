@@ -670,3 +670,27 @@ type DMWorkerStartScriptModel struct {
 func RenderDMWorkerStartScript(model *DMWorkerStartScriptModel) (string, error) {
 	return renderTemplateFunc(dmWorkerStartScriptTpl, model)
 }
+
+const tiproxyStartScript = `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config=/etc/proxy/proxy.toml"
+echo "starting: tiproxy ${ARGS}"
+exec /bin/tiproxy ${ARGS}
+`
@@ -1601,6 +1601,49 @@ func TestRenderTiFlashStartScript(t *testing.T) {
 	}
 }
 
+func TestRenderTiProxyStartScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiProxy: &v1alpha1.TiProxySpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	expectScript := `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config=/etc/proxy/proxy.toml"
+echo "starting: tiproxy ${ARGS}"
+exec /bin/tiproxy ${ARGS}
+`
+
+	script, err := RenderTiProxyStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	if diff := cmp.Diff(expectScript, script); diff != "" {
+		t.Errorf("unexpected (-want, +got): %s", diff)
+	}
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+}
+
 func TestRenderTiFlashInitScript(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -0,0 +1,111 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+// The advertise addresses returned by the functions in this file are the same as the ones rendered
+// into the start scripts, their hosts refer to the pod name variable of the script, e.g. ${TIKV_POD_NAME}.
+// Use PodAdvertiseAddr to get the address of a specific Pod.
+
+// podNameVarRegexp matches the pod name variables of start scripts
+var podNameVarRegexp = regexp.MustCompile(`\$\{[A-Z]+_POD_NAME\}`)
+
+// PodAdvertiseAddr replaces the pod name variable in the advertise address with podName
+func PodAdvertiseAddr(addr, podName string) string {
+	return podNameVarRegexp.ReplaceAllLiteralString(addr, podName)
+}
+
+// podNameAssignmentRegexp matches the line assigning the pod name variable in start scripts
+var podNameAssignmentRegexp = regexp.MustCompile(`(?m)^([A-Z]+_POD_NAME)=.*$`)
+
+// PodScript returns the script for inspecting what the Pod podName runs, the pod name variable is
+// assigned podName and its references are replaced by podName.
+func PodScript(script, podName string) string {
+	script = podNameAssignmentRegexp.ReplaceAllString(script, "${1}="+podName)
+	return podNameVarRegexp.ReplaceAllLiteralString(script, podName)
+}
+
+// TiKVAdvertiseAddr returns the address passed to TiKV by --advertise-addr
+func TiKVAdvertiseAddr(tc *v1alpha1.TidbCluster) string {
+	return fmt.Sprintf("%s:%d", tikvAdvertiseHost(tc), tikvAdvertisePort(tc))
+}
+
+// tikvAdvertisePort returns the port advertised by TiKV, it differs from the listening port
+// only if it is mapped by NAT.
+func tikvAdvertisePort(tc *v1alpha1.TidbCluster) int32 {
+	if port := tc.Spec.TiKV.AdvertisePort; port != 0 {
+		return port
+	}
+	return v1alpha1.DefaultTiKVServerPort
+}
+
+// TiDBAdvertiseAddr returns the address passed to TiDB by --advertise-address, which only contains the host
+func TiDBAdvertiseAddr(tc *v1alpha1.TidbCluster) string {
+	if useLocalhost(tc) {
+		return localhostHost
+	}
+	addr := fmt.Sprintf("${TIDB_POD_NAME}.%s.%s.svc", controller.TiDBPeerMemberName(tc.Name), tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		addr = addr + "." + tc.Spec.ClusterDomain
+	}
+	return addr
+}
+
+// TiCDCAdvertiseAddr returns the address passed to TiCDC by --advertise-addr
+func TiCDCAdvertiseAddr(tc *v1alpha1.TidbCluster) string {
+	// NB: TiCDC control relies the format.
+	addr := fmt.Sprintf("${TICDC_POD_NAME}.%s.%s.svc", controller.TiCDCPeerMemberName(tc.Name), tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		addr = addr + "." + tc.Spec.ClusterDomain
+	}
+	return fmt.Sprintf("%s:%d", addr, v1alpha1.DefaultTiCDCPort)
+}
+
+// PumpAdvertiseAddr returns the address passed to Pump by -advertise-addr
+func PumpAdvertiseAddr(tc *v1alpha1.TidbCluster) string {
+	addr := fmt.Sprintf("${PUMP_POD_NAME}.%s", controller.PumpPeerMemberName(tc.Name))
+	if tc.Spec.ClusterDomain != "" {
+		addr = addr + fmt.Sprintf(".%s.svc.%s", tc.Namespace, tc.Spec.ClusterDomain)
+	} else if tc.AcrossK8s() {
+		addr = addr + fmt.Sprintf(".%s.svc", tc.Namespace)
+	}
+	return fmt.Sprintf("%s:%d", addr, v1alpha1.DefaultPumpPort)
+}
+
+// TiProxyAdvertiseAddr returns the address passed to TiProxy by --advertise-addr, which only contains the host
+func TiProxyAdvertiseAddr(tc *v1alpha1.TidbCluster) string {
+	addr := fmt.Sprintf("${TIPROXY_POD_NAME}.%s.%s.svc", controller.TiProxyPeerMemberName(tc.Name), tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		addr = addr + "." + tc.Spec.ClusterDomain
+	}
+	return addr
+}
+
+// DrainerAdvertiseAddr returns the address passed to Drainer by -advertise-addr
+func DrainerAdvertiseAddr(tc *v1alpha1.TidbCluster, drainer *Drainer) string {
+	addr := fmt.Sprintf("${DRAINER_POD_NAME}.%s", drainer.Name)
+	if tc.Spec.ClusterDomain != "" {
+		addr = addr + fmt.Sprintf(".%s.svc.%s", tc.Namespace, tc.Spec.ClusterDomain)
+	} else if tc.AcrossK8s() {
+		addr = addr + fmt.Sprintf(".%s.svc", tc.Namespace)
+	}
+	return fmt.Sprintf("%s:%d", addr, v1alpha1.DefaultDrainerPort)
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestAdvertiseAddrMatchesStartScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := map[string]struct {
+		render    func(tc *v1alpha1.TidbCluster) (string, error)
+		addr      func(tc *v1alpha1.TidbCluster) string
+		argPrefix string
+	}{
+		"tikv":    {RenderTiKVStartScript, TiKVAdvertiseAddr, "--advertise-addr="},
+		"tidb":    {RenderTiDBStartScript, TiDBAdvertiseAddr, "--advertise-address="},
+		"ticdc":   {RenderTiCDCStartScript, TiCDCAdvertiseAddr, "--advertise-addr="},
+		"pump":    {RenderPumpStartScript, PumpAdvertiseAddr, "-advertise-addr="},
+		"tiproxy": {RenderTiProxyStartScript, TiProxyAdvertiseAddr, "--advertise-addr="},
+	}
+	modifies := map[string]func(tc *v1alpha1.TidbCluster){
+		"basic": func(tc *v1alpha1.TidbCluster) {},
+		"cluster domain": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.ClusterDomain = "cluster.local"
+		},
+		"across k8s": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.AcrossK8s = true
+		},
+		"tikv advertise host suffix": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.AdvertiseHostSuffix = "tikv.example.com"
+		},
+		"tikv peer service name": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.PeerServiceName = "legacy-tikv-peer"
+		},
+		"tikv advertise port": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.AdvertisePort = 30160
+		},
+	}
+
+	for component, c := range components {
+		for name, modify := range modifies {
+			tc := &v1alpha1.TidbCluster{
+				Spec: v1alpha1.TidbClusterSpec{
+					PD:      &v1alpha1.PDSpec{},
+					TiKV:    &v1alpha1.TiKVSpec{},
+					TiDB:    &v1alpha1.TiDBSpec{},
+					Pump:    &v1alpha1.PumpSpec{},
+					TiCDC:   &v1alpha1.TiCDCSpec{},
+					TiProxy: &v1alpha1.TiProxySpec{},
+				},
+			}
+			// the advertise address of TiProxy is only rendered with the feature flag
+			tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiProxyAdvertiseAddr}
+			tc.Name = "start-script-test"
+			tc.Namespace = "start-script-test-ns"
+			modify(tc)
+
+			script, err := c.render(tc)
+			g.Expect(err).Should(gomega.Succeed(), "component %s, case %s", component, name)
+			// the address is followed by the line continuation or the end of ARGS
+			g.Expect(script).Should(gomega.MatchRegexp(regexp.QuoteMeta(c.argPrefix+c.addr(tc))+`[ "]`), "component %s, case %s", component, name)
+		}
+	}
+}
+
+func TestPodAdvertiseAddr(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{},
+		},
+	}
+	tc.Name = "basic"
+	tc.Namespace = "ns"
+
+	g.Expect(PodAdvertiseAddr(TiKVAdvertiseAddr(tc), "basic-tikv-0")).Should(gomega.Equal("basic-tikv-0.basic-tikv-peer.ns.svc:20160"))
+	g.Expect(PodAdvertiseAddr(TiDBAdvertiseAddr(tc), "basic-tidb-1")).Should(gomega.Equal("basic-tidb-1.basic-tidb-peer.ns.svc"))
+	g.Expect(PodAdvertiseAddr("basic-pd:2379", "basic-pd-0")).Should(gomega.Equal("basic-pd:2379"))
+}
+
+func TestPodScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	script := `#!/bin/sh
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+ARGS="--advertise-addr=${TIKV_POD_NAME}.basic-tikv-peer.ns.svc:20160 \
+--status-addr=${POD_IP}:20180"
+`
+	g.Expect(PodScript(script, "basic-tikv-2")).Should(gomega.Equal(`#!/bin/sh
+TIKV_POD_NAME=basic-tikv-2
+ARGS="--advertise-addr=basic-tikv-2.basic-tikv-peer.ns.svc:20160 \
+--status-addr=${POD_IP}:20180"
+`))
+}
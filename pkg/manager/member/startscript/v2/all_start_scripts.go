@@ -0,0 +1,97 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	tikvPreStopScriptKey = "tikv-prestop"
+	tidbPreStopScriptKey = "tidb-prestop"
+	tiflashInitScriptKey = "tiflash-init"
+)
+
+// RenderAllStartScripts renders the scripts of all components configured in TidbCluster without deploying it,
+// the scripts are keyed by the component name, e.g. "pd", "tikv" and "tiflash-init".
+// If some of the components fail to render, the scripts of the others are still returned
+// together with an aggregated error.
+func RenderAllStartScripts(tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	return renderAllStartScripts(tc, nil)
+}
+
+// RenderAllStartScriptsForOrdinal renders the scripts like RenderAllStartScripts, but for the Pods with
+// the given ordinal, e.g. basic-tikv-2. The pod name variables, e.g. ${TIKV_POD_NAME}, are replaced by
+// the concrete pod names, so the scripts show the exact addresses used by the Pods. They are only for
+// inspection, the scripts in ConfigMaps are shared by all Pods and keep the variables.
+func RenderAllStartScriptsForOrdinal(tc *v1alpha1.TidbCluster, ordinal int32) (map[string]string, error) {
+	return renderAllStartScripts(tc, &ordinal)
+}
+
+func renderAllStartScripts(tc *v1alpha1.TidbCluster, ordinal *int32) (map[string]string, error) {
+	type renderer struct {
+		name   string
+		member v1alpha1.MemberType
+		render func(tc *v1alpha1.TidbCluster) (string, error)
+	}
+
+	renderers := []renderer{}
+	if tc.Spec.PD != nil {
+		renderers = append(renderers, renderer{v1alpha1.PDMemberType.String(), v1alpha1.PDMemberType, RenderPDStartScript})
+	}
+	if tc.Spec.TiKV != nil {
+		renderers = append(renderers, renderer{v1alpha1.TiKVMemberType.String(), v1alpha1.TiKVMemberType, RenderTiKVStartScript})
+		if tc.Spec.TiKV.PreStop != nil {
+			renderers = append(renderers, renderer{tikvPreStopScriptKey, v1alpha1.TiKVMemberType, RenderTiKVPreStopScript})
+		}
+	}
+	if tc.Spec.TiDB != nil {
+		renderers = append(renderers, renderer{v1alpha1.TiDBMemberType.String(), v1alpha1.TiDBMemberType, RenderTiDBStartScript})
+		if tc.Spec.TiDB.PreStop != nil {
+			renderers = append(renderers, renderer{tidbPreStopScriptKey, v1alpha1.TiDBMemberType, RenderTiDBPreStopScript})
+		}
+	}
+	if tc.Spec.TiFlash != nil {
+		renderers = append(renderers, renderer{v1alpha1.TiFlashMemberType.String(), v1alpha1.TiFlashMemberType, RenderTiFlashStartScript})
+		renderers = append(renderers, renderer{tiflashInitScriptKey, v1alpha1.TiFlashMemberType, RenderTiFlashInitScript})
+	}
+	if tc.Spec.Pump != nil {
+		renderers = append(renderers, renderer{v1alpha1.PumpMemberType.String(), v1alpha1.PumpMemberType, RenderPumpStartScript})
+	}
+	if tc.Spec.TiCDC != nil {
+		renderers = append(renderers, renderer{v1alpha1.TiCDCMemberType.String(), v1alpha1.TiCDCMemberType, RenderTiCDCStartScript})
+	}
+	if tc.Spec.TiProxy != nil {
+		renderers = append(renderers, renderer{v1alpha1.TiProxyMemberType.String(), v1alpha1.TiProxyMemberType, RenderTiProxyStartScript})
+	}
+
+	scripts := make(map[string]string, len(renderers))
+	var errs []error
+	for _, r := range renderers {
+		script, err := r.render(tc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("render %s script failed: %v", r.name, err))
+			continue
+		}
+		if ordinal != nil {
+			script = PodScript(script, fmt.Sprintf("%s-%d", controller.MemberName(tc.Name, r.member), *ordinal))
+		}
+		scripts[r.name] = script
+	}
+	return scripts, errorutils.NewAggregate(errs)
+}
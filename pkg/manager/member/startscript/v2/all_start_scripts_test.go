@@ -0,0 +1,129 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/onsi/gomega"
+)
+
+func newAllComponentsTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			PD: &v1alpha1.PDSpec{},
+			TiKV: &v1alpha1.TiKVSpec{
+				PreStop: &v1alpha1.TiKVPreStopSpec{},
+			},
+			TiDB: &v1alpha1.TiDBSpec{
+				PreStop: &v1alpha1.TiDBPreStopSpec{},
+			},
+			TiFlash: &v1alpha1.TiFlashSpec{},
+			Pump:    &v1alpha1.PumpSpec{},
+			TiCDC:   &v1alpha1.TiCDCSpec{},
+			TiProxy: &v1alpha1.TiProxySpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+	return tc
+}
+
+func TestRenderAllStartScripts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := newAllComponentsTidbCluster()
+	scripts, err := RenderAllStartScripts(tc)
+	g.Expect(err).Should(gomega.Succeed())
+
+	renderers := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":           RenderPDStartScript,
+		"tikv":         RenderTiKVStartScript,
+		"tikv-prestop": RenderTiKVPreStopScript,
+		"tidb":         RenderTiDBStartScript,
+		"tidb-prestop": RenderTiDBPreStopScript,
+		"tiflash":      RenderTiFlashStartScript,
+		"tiflash-init": RenderTiFlashInitScript,
+		"pump":         RenderPumpStartScript,
+		"ticdc":        RenderTiCDCStartScript,
+		"tiproxy":      RenderTiProxyStartScript,
+	}
+	g.Expect(scripts).Should(gomega.HaveLen(len(renderers)))
+	for name, render := range renderers {
+		expected, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(scripts).Should(gomega.HaveKeyWithValue(name, expected), "script of %s", name)
+	}
+
+	// only the configured components are rendered
+	tc.Spec.TiKV.PreStop = nil
+	tc.Spec.TiDB.PreStop = nil
+	tc.Spec.TiFlash = nil
+	tc.Spec.Pump = nil
+	scripts, err = RenderAllStartScripts(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(scripts).Should(gomega.HaveLen(5))
+	g.Expect(scripts).ShouldNot(gomega.HaveKey("tiflash-init"))
+}
+
+func TestRenderAllStartScriptsWithError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/numa-node": "invalid"}
+
+	scripts, err := RenderAllStartScripts(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("render tikv script failed"))
+	g.Expect(scripts).ShouldNot(gomega.HaveKey("tikv"))
+	g.Expect(scripts).Should(gomega.HaveKey("tikv-prestop"))
+	g.Expect(scripts).Should(gomega.HaveKey("pd"))
+	g.Expect(scripts).Should(gomega.HaveLen(9))
+}
+
+func TestRenderAllStartScriptsForOrdinal(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := newAllComponentsTidbCluster()
+	templated, err := RenderAllStartScripts(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	scripts, err := RenderAllStartScriptsForOrdinal(tc, 2)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(scripts).Should(gomega.HaveLen(len(templated)))
+
+	podNames := map[string]string{
+		"pd":           "start-script-test-pd-2",
+		"tikv":         "start-script-test-tikv-2",
+		"tikv-prestop": "start-script-test-tikv-2",
+		"tidb":         "start-script-test-tidb-2",
+		"tidb-prestop": "start-script-test-tidb-2",
+		"tiflash":      "start-script-test-tiflash-2",
+		"tiflash-init": "start-script-test-tiflash-2",
+		"pump":         "start-script-test-pump-2",
+		"ticdc":        "start-script-test-ticdc-2",
+		"tiproxy":      "start-script-test-tiproxy-2",
+	}
+	for name, script := range scripts {
+		g.Expect(script).Should(gomega.Equal(PodScript(templated[name], podNames[name])), "script of %s", name)
+		g.Expect(script).ShouldNot(gomega.MatchRegexp(`\$\{[A-Z]+_POD_NAME\}`), "script of %s", name)
+		g.Expect(validateScript(script)).Should(gomega.Succeed(), "script of %s", name)
+	}
+
+	g.Expect(scripts["tikv"]).Should(gomega.ContainSubstring("\nTIKV_POD_NAME=start-script-test-tikv-2\n"))
+	g.Expect(scripts["tikv"]).Should(gomega.ContainSubstring("--advertise-addr=start-script-test-tikv-2.start-script-test-tikv-peer.start-script-test-ns.svc:20160"))
+	g.Expect(templated["tikv"]).Should(gomega.ContainSubstring("--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160"))
+	g.Expect(scripts["pump"]).Should(gomega.ContainSubstring("-advertise-addr=start-script-test-pump-2.start-script-test-pump:8250"))
+}
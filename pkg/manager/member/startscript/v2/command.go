@@ -0,0 +1,30 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// StartScriptCommand returns the command of the container which runs the rendered start script inline,
+// the script is passed to the shell of tc by "-c", e.g. for pump, ticdc and tiflash.
+func StartScriptCommand(tc *v1alpha1.TidbCluster, script string) []string {
+	return []string{tc.StartScriptShell(), "-c", script}
+}
+
+// StartScriptFileCommand returns the command of the container which runs the start script mounted at path
+// from the ConfigMap of the component by the shell of tc, e.g. for pd, tikv and tidb.
+func StartScriptFileCommand(tc *v1alpha1.TidbCluster, path string) []string {
+	return []string{tc.StartScriptShell(), path}
+}
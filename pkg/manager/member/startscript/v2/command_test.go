@@ -0,0 +1,50 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestStartScriptCommand(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			Pump:               &v1alpha1.PumpSpec{},
+			StartScriptVersion: v1alpha1.StartScriptV2,
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	script, err := RenderPumpStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(StartScriptCommand(tc, script)).Should(gomega.Equal([]string{"/bin/sh", "-c", script}))
+	g.Expect(StartScriptFileCommand(tc, "/usr/local/bin/tikv_start_script.sh")).Should(gomega.Equal(
+		[]string{"/bin/sh", "/usr/local/bin/tikv_start_script.sh"}))
+
+	// the script is run by the shell which it is rendered for
+	tc.Spec.StartScriptV2Shell = "/bin/bash"
+	script, err = RenderPumpStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.HavePrefix("#!/bin/bash\n"))
+	g.Expect(StartScriptCommand(tc, script)).Should(gomega.Equal([]string{"/bin/bash", "-c", script}))
+	g.Expect(StartScriptFileCommand(tc, "/usr/local/bin/tikv_start_script.sh")).Should(gomega.Equal(
+		[]string{"/bin/bash", "/usr/local/bin/tikv_start_script.sh"}))
+}
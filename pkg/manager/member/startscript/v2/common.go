@@ -15,7 +15,24 @@ package v2
 
 import (
 	"bytes"
+	"fmt"
+	"net"
+	"net/netip"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	"github.com/pingcap/tidb-operator/pkg/manager/utils"
+	"github.com/pingcap/tidb-operator/pkg/util/cmpver"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -40,16 +57,172 @@ fi
 `
 	dnsAwaitPart = "<<dns-await-part>>"
 
+	// defaultShell is the shell in the shebang of start scripts unless it is configured in the spec
+	defaultShell = "/bin/sh"
+	// defaultShellOptions are the options set by start scripts, strictShellOptions are the ones with the
+	// StrictMode feature flag which also exit on the first failed command.
+	defaultShellOptions = "\nset -uo pipefail\n"
+	strictShellOptions  = "\nset -euo pipefail\n"
+	// sourceAnnotationsLine is the line of componentCommonScript which sources the annotations of the pod
+	sourceAnnotationsLine = "source ${ANNOTATIONS} 2>/dev/null"
+
+	// extraEnvFileName is the name of the env file sourced by start scripts with the SourceExtraEnvFile feature flag
+	extraEnvFileName = "extra.env"
+
+	// podNameFallbackScript is appended to componentCommonScript with the PodNameFallback feature flag,
+	// it makes sure that POD_NAME is never empty so that the components do not advertise an empty host.
+	podNameFallbackScript = `
+POD_NAME=${POD_NAME:-${HOSTNAME:-}}
+if [[ -z "${POD_NAME}" && -f /etc/hostname ]]
+then
+    POD_NAME=$(cat /etc/hostname)
+fi
+if [[ -z "${POD_NAME}" ]]
+then
+    echo "failed to determine the pod name from POD_NAME, HOSTNAME and /etc/hostname, exiting." >&2
+    exit 1
+fi
+`
+
+	// extraEnvFileScript is appended to componentCommonScript with the SourceExtraEnvFile feature flag,
+	// the variables in the env file are exported and may override the ones of the container.
+	extraEnvFileScript = `
+EXTRA_ENV_FILE="%s"
+if [[ -f "${EXTRA_ENV_FILE}" ]]
+then
+    echo "sourcing ${EXTRA_ENV_FILE}"
+    set -a
+    source ${EXTRA_ENV_FILE}
+    set +a
+fi
+`
+
+	// sidecarReadinessScript is appended to componentCommonScript if SidecarReadiness is set, it waits for
+	// the health endpoint of the sidecar before any network operation of the start script.
+	sidecarReadinessScript = `
+sidecar_ready_url=%s
+sidecar_wait_elapsed=0
+until wget -qO /dev/null -T 2 ${sidecar_ready_url} 2>/dev/null
+do
+    echo "waiting for the sidecar to be ready at ${sidecar_ready_url}"%s
+    sleep 1
+    sidecar_wait_elapsed=$((sidecar_wait_elapsed + 1))
+done
+echo "the sidecar is ready after ${sidecar_wait_elapsed}s"
+`
+
+	// sidecarReadinessTimeoutScript is put in the loop of sidecarReadinessScript if the timeout is set.
+	sidecarReadinessTimeoutScript = `
+    if [[ ${sidecar_wait_elapsed} -ge %d ]]
+    then
+        echo "the sidecar is not ready after %ds, exiting." >&2
+        exit %d
+    fi`
+
+	// goMaxProcsScript is appended to the common part of start scripts of Go components with the GoMaxProcs
+	// feature flag, GOMAXPROCS set explicitly in env is kept.
+	goMaxProcsScript = `
+CPU_LIMIT_CORES=${%s:-}
+if [[ -z "${GOMAXPROCS:-}" && -n "${CPU_LIMIT_CORES}" ]]
+then
+    export GOMAXPROCS=${CPU_LIMIT_CORES}
+    echo "GOMAXPROCS is set to ${GOMAXPROCS} by the CPU limit"
+fi
+`
+
+	// acrossK8sVerifySubScript is the request of verifying the PD endpoints through discovery,
+	// the certificate of discovery is verified by the CA bundle if it is accessed by https.
+	acrossK8sVerifySubScript = `wget -qO- -T {{ .AcrossK8s.VerifyTimeout }}` +
+		`{{ if .AcrossK8s.CABundlePath }} --ca-certificate={{ .AcrossK8s.CABundlePath }} https{{ else }} http{{ end }}` +
+		`://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null`
+
+	// base64EncodeCmd encodes stdin by base64 in one line for the URLs of discovery.
+	base64EncodeCmd = `base64 | tr "\n" " " | sed "s/ //g"`
+	// base64FallbackEncodeCmd is base64EncodeCmd used with the Base64Fallback feature flag. base64 wraps the output
+	// at different columns in GNU coreutils and BusyBox, and old BusyBox does not support -w 0 to disable it, so all
+	// the line breaks are removed by tr, including the CR of CRLF. openssl is used if there is no base64 in the image.
+	base64FallbackEncodeCmd = `{ base64 2>/dev/null || openssl base64; } | tr -d "\r\n"`
+	// base64EncodeSubScript encodes stdin by base64 in the across-k8s subscripts
+	base64EncodeSubScript = `{{ if .AcrossK8s.Base64Fallback }}` + base64FallbackEncodeCmd + `{{ else }}` + base64EncodeCmd + `{{ end }}`
+
+	// acrossK8sStripPDSchemeSubScript strips the scheme of PD URLs returned by discovery for the components
+	// which accept PD addresses without scheme, both http and https are stripped.
+	acrossK8sStripPDSchemeSubScript = ` | sed 's/http:\/\///g' | sed 's/https:\/\///g'`
+
+	// acrossK8sPDAddrCacheReadSubScript and acrossK8sPDAddrCacheWriteSubScript wrap the verification loop
+	// of across-k8s subscripts, the loop is skipped if PD is reachable by the addr cached by the last start.
+	acrossK8sPDAddrCacheReadSubScript = `
+{{- if .AcrossK8s.PDAddrCache }}
+pd_addr_cache={{ .AcrossK8s.PDAddrCache.File }}
+result=""
+if [ -s ${pd_addr_cache} ]; then
+    cached_pd_addr=$(cat ${pd_addr_cache})
+    if curl {{ .AcrossK8s.PDAddrCache.CurlArgs }} -m {{ .AcrossK8s.VerifyTimeout }} {{ .AcrossK8s.PDAddrCache.PDScheme }}://${cached_pd_addr%%,*}/pd/api/v1/health >/dev/null 2>&1; then
+        result=${cached_pd_addr}
+        echo "using the cached PD endpoints ${result}"
+    else
+        echo "PD is unreachable by the cached endpoints ${cached_pd_addr}, verifying by discovery"
+    fi
+fi
+if [ -z "${result}" ]; then
+{{- end }}`
+	acrossK8sPDAddrCacheWriteSubScript = `
+{{- if .AcrossK8s.PDAddrCache }}
+mkdir -p $(dirname ${pd_addr_cache})
+echo "${result}" > ${pd_addr_cache}
+fi
+{{- end }}`
+
+	// acrossK8sVerboseSubScript echoes the PD endpoints verified by discovery to stderr if the verification is
+	// verbose, it is rendered after the verification loop of across-k8s subscripts.
+	acrossK8sVerboseSubScript = `
+{{- if .AcrossK8s.Verbose }}
+echo "[start-script] effective PD endpoints: ${result}" >&2
+{{- end }}`
+
+	// acrossK8sMaxRetriesSubScript is rendered in the verification loop of across-k8s subscripts
+	acrossK8sMaxRetriesSubScript = `
+{{- if .AcrossK8s.MaxRetries }}
+    retries=$(( ${retries:-0}+1 ))
+    if [ ${retries} -gt {{ .AcrossK8s.MaxRetries }} ]; then
+        echo "failed to verify PD endpoints after {{ .AcrossK8s.MaxRetries }} retries" >&2
+        exit {{ exitCode "PDVerifyFailed" }}
+    fi
+{{- end }}`
+
+	// acrossK8sDeadlineSubScript gives up the verification once the wall clock reaches ${deadline} which is set
+	// by the script before the subscript, the script exits successfully as the verification is best effort.
+	acrossK8sDeadlineSubScript = `
+{{- if .AcrossK8s.Deadline }}
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "failed to verify PD endpoints before the deadline, skip" >&2
+        exit 0
+    fi
+{{- end }}`
+
+	// dnsWaitAttemptsInit and dnsWaitAttemptsElapse are the lines of the loops waiting for DNS which count
+	// the attempts, they are replaced by the ones counting the seconds with the seconds threshold unit.
+	dnsWaitAttemptsInit   = "\nelapseTime=0\n"
+	dnsWaitAttemptsElapse = "    elapseTime=$(( elapseTime+period ))\n"
+	dnsWaitSecondsInit    = "\nwaitStartTime=$(date +%s)\nelapseTime=0\n"
+	dnsWaitSecondsElapse  = "    elapseTime=$(( $(date +%s)-waitStartTime ))\n"
+
+	// dnsWaitSleep is the sleep of the loops waiting for DNS, it is replaced by dnsWaitJitterSleep with the
+	// DnsWaitJitter feature flag, which sleeps up to 2 more seconds to spread the lookups of the Pods
+	// restarted at the same time.
+	dnsWaitSleep       = "    sleep ${period}\n"
+	dnsWaitJitterSleep = "    sleep $(( period+RANDOM % 3 ))\n"
+
 	componentCommonWaitForDnsIpMatchScript = `
 elapseTime=0
-period=1
+period={{ or .DnsWaitInterval 1 }}
 while true; do
     sleep ${period}
     elapseTime=$(( elapseTime+period ))
 
     if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
         echo "waiting for cluster ready timeout" >&2
-        exit 1
+        exit {{ exitCode "DnsWaitTimeout" }}
     fi
 
     digRes=$(eval "$nsLookupCmd")
@@ -104,17 +277,517 @@ type AcrossK8sScriptModel struct {
 	// DiscoveryAddr is the address of the discovery service.
 	//
 	// When cluster is deployed across k8s, all components except pd will get the pd addr from discovery.
+	// Note that discovery is accessed by http even if TLS is enabled for the cluster, unless CABundlePath is set.
 	DiscoveryAddr string
 
-	// PDAddr is the address used by discovery to get the actual pd addr.
+	// PDAddr is the url used by discovery to get the actual pd addr, its scheme follows the TLS setting
+	// of the cluster so that discovery returns the pd addr with the same scheme.
 	PDAddr string
+
+	// VerifyTimeout is the timeout (in seconds) of each verification request.
+	VerifyTimeout int32
+	// MaxRetries is the max retries of the verification, 0 means retrying until it succeeds.
+	MaxRetries int32
+	// MaxBackoff is the ceiling (in seconds) of the random backoff between two retries,
+	// 0 means using the default backoff of the component.
+	MaxBackoff int32
+	// CABundlePath is the CA bundle to verify the certificate of discovery, discovery is
+	// accessed by https instead of http if it is set.
+	CABundlePath string
+
+	// PDAddrCache is set if the verified PD addr is cached for the next start.
+	PDAddrCache *AcrossK8sPDAddrCache
+
+	// Verbose is set if the verified PD addr is echoed to stderr for diagnostics.
+	Verbose bool
+
+	// Base64Fallback is set if the URL of PD is encoded by base64FallbackEncodeCmd.
+	Base64Fallback bool
+
+	// Deadline is set if the verification is bounded by ${deadline} of the script, e.g. the preStop script.
+	Deadline bool
+}
+
+// AcrossK8sPDAddrCache contains fields for caching the PD addr verified by discovery in a file,
+// the verification is skipped on the next start if PD is reachable by the cached addr.
+type AcrossK8sPDAddrCache struct {
+	File     string
+	PDScheme string
+	// CurlArgs are the args of curl to check the health of PD by the cached addr.
+	CurlArgs string
+}
+
+// Validate checks the fields required by caching the PD addr, nil is valid as the cache is optional
+func (c *AcrossK8sPDAddrCache) Validate() error {
+	if c == nil {
+		return nil
+	}
+	return validateModel("PD addr cache",
+		validateRequired("File", c.File),
+		validateRequired("PDScheme", c.PDScheme),
+	)
+}
+
+// Validate checks the fields required by the across-k8s subscript, a nil model is valid.
+func (m *AcrossK8sScriptModel) Validate() error {
+	if m == nil {
+		return nil
+	}
+	var caBundlePathErr error
+	if m.CABundlePath != "" {
+		caBundlePathErr = validateAbsPath("CABundlePath", m.CABundlePath)
+	}
+	return validateModel("across k8s",
+		validateAddr("DiscoveryAddr", m.DiscoveryAddr),
+		validateURL("PDAddr", m.PDAddr),
+		caBundlePathErr,
+		m.PDAddrCache.Validate(),
+	)
+}
+
+const defaultAcrossK8sVerifyTimeout = 3
+
+// newAcrossK8sScriptModel returns the model of across-k8s subscript with the retry bounds
+// configured in TidbClusterSpec.AcrossK8sVerification.
+//
+// The PD of tc is verified by the discovery of tc, while a heterogeneous cluster without local PD
+// verifies the PD of the reference cluster by the discovery of the reference cluster.
+func newAcrossK8sScriptModel(tc *v1alpha1.TidbCluster) *AcrossK8sScriptModel {
+	pdClusterName, discovery := tc.Name, discoveryAddr(tc)
+	if tc.Heterogeneous() && tc.WithoutLocalPD() {
+		pdClusterName, discovery = tc.Spec.Cluster.Name, referenceDiscoveryAddr(tc)
+	}
+	m := &AcrossK8sScriptModel{
+		// the PD address is not qualified, discovery looks up the cluster by it in the namespace of discovery
+		PDAddr:        fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(pdClusterName), v1alpha1.DefaultPDClientPort),
+		DiscoveryAddr: discovery,
+		VerifyTimeout: defaultAcrossK8sVerifyTimeout,
+		// the fallback is not used by default, so that the start scripts of the existing clusters are not changed
+		Base64Fallback: slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagBase64Fallback),
+	}
+	if spec := tc.Spec.AcrossK8sVerification; spec != nil {
+		if spec.Timeout > 0 {
+			m.VerifyTimeout = spec.Timeout
+		}
+		m.MaxRetries = spec.MaxRetries
+		m.MaxBackoff = spec.MaxBackoff
+		m.CABundlePath = spec.CABundlePath
+		m.Verbose = spec.Verbose
+	}
+	return m
+}
+
+// commonScript returns the common part of start scripts for TidbCluster
+// configDir is the dir where the ConfigMap of the component is mounted, the extra env file is looked up in it.
+func commonScript(tc *v1alpha1.TidbCluster, configDir string) string {
+	script := componentCommonScript
+	if shell := tc.StartScriptShell(); shell != defaultShell {
+		script = strings.Replace(script, "#!"+defaultShell+"\n", "#!"+shell+"\n", 1)
+	}
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagExitCodes) {
+		script = strings.Replace(script, defaultShellOptions, defaultShellOptions+exitCodeTrapLine, 1)
+	}
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagStrictMode) {
+		// the annotations file may contain lines which are not valid assignments, e.g. the keys with dots
+		script = strings.Replace(script, defaultShellOptions, strictShellOptions, 1)
+		script = strings.Replace(script, sourceAnnotationsLine, sourceAnnotationsLine+" || true", 1)
+	}
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagSourceExtraEnvFile) {
+		script += fmt.Sprintf(extraEnvFileScript, filepath.Join(configDir, extraEnvFileName))
+	}
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagPodNameFallback) {
+		script += podNameFallbackScript
+	}
+	if spec := tc.Spec.SidecarReadiness; spec != nil {
+		timeoutScript := ""
+		if spec.Timeout > 0 {
+			timeoutScript = fmt.Sprintf(sidecarReadinessTimeoutScript, spec.Timeout, spec.Timeout, ExitCodeSidecarNotReady)
+		}
+		script += fmt.Sprintf(sidecarReadinessScript, shellQuote(spec.URL), timeoutScript)
+	}
+	return script
+}
+
+// goCommonScript returns the common part of start scripts for the components written in Go
+func goCommonScript(tc *v1alpha1.TidbCluster, configDir string) string {
+	script := commonScript(tc, configDir)
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagGoMaxProcs) {
+		script += fmt.Sprintf(goMaxProcsScript, constants.CPULimitCoresEnv)
+	}
+	return script
+}
+
+// replaceDnsLookupForStrictMode checks the status of the DNS lookups in the loops waiting for DNS in
+// the condition of if with the StrictMode feature flag, so that a failed lookup is retried instead of
+// exiting the start script by `set -e`.
+func replaceDnsLookupForStrictMode(tc *v1alpha1.TidbCluster, startScript string) string {
+	if !slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagStrictMode) {
+		return startScript
+	}
+	return dnsLookupStatusRegexp.ReplaceAllString(startScript, "${1}if ! digRes=$$(${2}); then\n")
+}
+
+// replaceDnsWaitThresholdUnit makes the loops waiting for DNS in the start script count the threshold by
+// the seconds elapsed if it is configured, they count the attempts of resolving the domain by default.
+func replaceDnsWaitThresholdUnit(tc *v1alpha1.TidbCluster, startScript string) string {
+	if tc.Spec.DnsWaitThresholdUnit != v1alpha1.DnsWaitThresholdUnitSeconds {
+		return startScript
+	}
+	startScript = strings.ReplaceAll(startScript, dnsWaitAttemptsInit, dnsWaitSecondsInit)
+	return strings.ReplaceAll(startScript, dnsWaitAttemptsElapse, dnsWaitSecondsElapse)
+}
+
+// replaceDnsWaitSleep adds a jitter to the sleeps of the loops waiting for DNS in the start script with the
+// DnsWaitJitter feature flag.
+func replaceDnsWaitSleep(tc *v1alpha1.TidbCluster, startScript string) string {
+	if !slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDnsWaitJitter) {
+		return startScript
+	}
+	return strings.ReplaceAll(startScript, dnsWaitSleep, dnsWaitJitterSleep)
+}
+
+// discoveryAddr returns the address used by start scripts to access the discovery service
+func discoveryAddr(tc *v1alpha1.TidbCluster) string {
+	return fmt.Sprintf("%s.%s:%d", controller.DiscoveryMemberName(tc.Name), tc.Namespace, tc.DiscoveryPort())
+}
+
+// referenceDiscoveryAddr returns the discovery address of the cluster referenced by a heterogeneous cluster,
+// it is qualified by the domain of the reference cluster in the same way as referencePDAddr.
+// The discovery of the reference cluster is assumed to listen on the same port as the one of tc.
+func referenceDiscoveryAddr(tc *v1alpha1.TidbCluster) string {
+	ref := tc.Spec.Cluster
+	ns := ref.Namespace
+	if ns == "" {
+		ns = tc.Namespace
+	}
+	host := fmt.Sprintf("%s.%s", controller.DiscoveryMemberName(ref.Name), ns)
+	if ref.ClusterDomain != "" {
+		host = fmt.Sprintf("%s.svc%s", host, controller.FormatClusterDomain(ref.ClusterDomain))
+	}
+	return fmt.Sprintf("%s:%d", host, tc.DiscoveryPort())
+}
+
+// referencePDAddr returns the PD address of the cluster referenced by a heterogeneous cluster.
+// The PD service name is qualified by the namespace and domain of the reference cluster if its
+// ClusterDomain is set, so that it can be resolved from a k8s cluster with a different domain.
+func referencePDAddr(tc *v1alpha1.TidbCluster) string {
+	ref := tc.Spec.Cluster
+	pdHost := controller.PDMemberName(ref.Name)
+	if ref.ClusterDomain != "" {
+		ns := ref.Namespace
+		if ns == "" {
+			ns = tc.Namespace
+		}
+		pdHost = fmt.Sprintf("%s.%s.svc%s", pdHost, ns, controller.FormatClusterDomain(ref.ClusterDomain))
+	}
+	return fmt.Sprintf("%s:%d", pdHost, v1alpha1.DefaultPDClientPort)
+}
+
+// localhostHost is the host advertised and reached by PD, TiKV and TiDB with the Localhost feature flag
+const localhostHost = "127.0.0.1"
+
+// useLocalhost reports whether the components address each other by localhostHost, it is only for the
+// single-node test clusters running all the components in one network namespace, where the DNS names
+// of the services are not available.
+func useLocalhost(tc *v1alpha1.TidbCluster) bool {
+	return slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagLocalhost)
+}
+
+// skipDnsWait reports whether start scripts skip waiting for their DNS names, which are not used
+// with the Localhost feature flag.
+func skipDnsWait(tc *v1alpha1.TidbCluster) bool {
+	return slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagSkipDnsWait) || useLocalhost(tc)
+}
+
+// tidbTiFlashWaitForDnsNameIpMatch reports whether TiDB and TiFlash wait until the IP of the pod is published
+// to DNS on startup, it requires the TiDBTiFlashDnsNameIpMatch feature flag besides WaitForDnsNameIpMatch.
+func tidbTiFlashWaitForDnsNameIpMatch(tc *v1alpha1.TidbCluster) bool {
+	return slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch) &&
+		slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch)
+}
+
+// versionAtLeast returns whether the version of a component is at least minVersion, the versions
+// which can not be parsed, e.g. custom image tags, are regarded as new ones to keep the flags.
+func versionAtLeast(version, minVersion string) bool {
+	ok, err := cmpver.Compare(version, cmpver.GreaterOrEqual, minVersion)
+	return err != nil || ok
+}
+
+// listenOnIPv6 reports whether components listen on the IPv6 wildcard.
+//
+// With the DualStack feature flag, the IPv6 wildcard is used instead of binding IPv4 and IPv6
+// separately, because a socket bound to "[::]" also accepts IPv4 connections as v4-mapped
+// addresses on Linux (net.ipv6.bindv6only defaults to 0) and the components can only listen
+// on one address.
+func listenOnIPv6(tc *v1alpha1.TidbCluster, preferIPv6 bool) bool {
+	return preferIPv6 || slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDualStack)
+}
+
+// preferIPv6ListenHost returns whether the components which always listened on the IPv4 wildcard address
+// listen on the IPv6 one if PreferIPv6 is set, it requires the PreferIPv6ListenHost feature flag.
+func preferIPv6ListenHost(tc *v1alpha1.TidbCluster) bool {
+	return tc.Spec.PreferIPv6 && slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagPreferIPv6ListenHost)
+}
+
+// listenHost returns the wildcard host which components listen on, see listenOnIPv6.
+func listenHost(tc *v1alpha1.TidbCluster, preferIPv6 bool) string {
+	return formatHost(wildcardHost(listenOnIPv6(tc, preferIPv6)))
+}
+
+// wildcardHost returns the unspecified address of IPv6 or IPv4.
+func wildcardHost(ipv6 bool) string {
+	if ipv6 {
+		return "::"
+	}
+	return "0.0.0.0"
+}
+
+// formatListenAddr returns the address to listen on port of host, the wildcard host is used if
+// host is empty. All the renderers build listen addresses by it to bracket IPv6 hosts consistently.
+func formatListenAddr(host string, port int32, preferIPv6 bool) string {
+	if host == "" {
+		host = wildcardHost(preferIPv6)
+	}
+	return fmt.Sprintf("%s:%d", formatHost(host), port)
+}
+
+// formatHost returns host in the form used in addresses, IPv6 addresses are enclosed in brackets
+// with the zone index kept, e.g. [fe80::1%eth0], and the other hosts are returned as they are.
+func formatHost(host string) string {
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil || addr.Is4() {
+		return host
+	}
+	return "[" + addr.String() + "]"
+}
+
+// urlHost returns host formatted by formatHost for URLs, the '%' before the zone index
+// is escaped as "%25" as required by RFC 6874.
+func urlHost(host string) string {
+	return strings.Replace(formatHost(host), "%", "%25", 1)
+}
+
+// The commands used by the DNS-await subscripts to resolve ${componentDomain}, they print one IP per line.
+// They are rendered into a double-quoted variable and eval-ed later, so '$' of awk fields is escaped.
+const (
+	getentNsLookupCmd   = `getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'`
+	digNsLookupCmd      = `dig ${componentDomain} A ${componentDomain} AAAA +search +short`
+	nslookupNsLookupCmd = `nslookup $componentDomain 2>/dev/null | awk '/^Name:/ {found=1; next} found && /^Address/ {print (\$2 ~ /:$/) ? \$3 : \$2}'`
+)
+
+// nsLookupCmd returns the DNS probe command selected by feature flags, getent is used by default.
+// If resolver is set, the command queries it explicitly and dig is used instead of getent, which
+// can only query the resolvers configured by nsswitch.
+func nsLookupCmd(tc *v1alpha1.TidbCluster, resolver string) string {
+	switch {
+	case slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup):
+		if resolver != "" {
+			return strings.Replace(nslookupNsLookupCmd, "$componentDomain", "$componentDomain "+resolver, 1)
+		}
+		return nslookupNsLookupCmd
+	case resolver != "":
+		return strings.Replace(digNsLookupCmd, "dig ", "dig @"+resolver+" ", 1)
+	case slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig):
+		return digNsLookupCmd
+	default:
+		return getentNsLookupCmd
+	}
+}
+
+// dataDir returns the data dir under the mount path of data volume, it returns an error
+// if the sub dir is an absolute path or contains '..', which may point outside the volume.
+func dataDir(mountPath, subDir string) (string, error) {
+	if path.IsAbs(subDir) {
+		return "", newRenderError(ErrInvalidDataSubDir, fmt.Errorf("data sub dir %q must be a relative path", subDir))
+	}
+	for _, item := range strings.Split(filepath.ToSlash(subDir), "/") {
+		if item == ".." {
+			return "", newRenderError(ErrInvalidDataSubDir, fmt.Errorf("data sub dir %q must not contain '..'", subDir))
+		}
+	}
+	return filepath.Join(mountPath, subDir), nil
+}
+
+// shellQuote quotes s by single quotes, so that s is passed to the command as one argument
+// without any expansion by shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellQuoteArgs quotes each of args by shellQuote and joins them by space.
+func shellQuoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// validateModel returns an error describing all the invalid fields of a script model,
+// it returns nil if all errors are nil.
+func validateModel(name string, errs ...error) error {
+	if agg := errorutils.NewAggregate(errs); agg != nil {
+		return newRenderError(ErrModelValidation, fmt.Errorf("invalid %s script model: %v", name, agg))
+	}
+	return nil
+}
+
+func validateRequired(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	return nil
+}
+
+func validatePositive(field string, value int) error {
+	if value <= 0 {
+		return fmt.Errorf("%s %d must be positive", field, value)
+	}
+	return nil
+}
+
+// validateAddr checks that addr is in the form of host:port, the host may refer to shell variables.
+func validateAddr(field, addr string) error {
+	if addr == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%s %q is invalid: %v", field, addr, err)
+	}
+	if host == "" {
+		return fmt.Errorf("%s %q has no host", field, addr)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("%s %q has invalid port %q", field, addr, port)
+	}
+	return nil
+}
+
+// validateResolver checks that resolver is an IP address if it is set, it is rendered into the DNS lookup
+// commands without quoting.
+func validateResolver(field, resolver string) error {
+	if resolver != "" && net.ParseIP(resolver) == nil {
+		return fmt.Errorf("%s %q must be an IP address", field, resolver)
+	}
+	return nil
+}
+
+// validatePort checks that port is in the range of 1 to 65535
+func validatePort(field string, port int32) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("%s %d must be in the range of 1 to 65535", field, port)
+	}
+	return nil
+}
+
+// validateAbsPath checks that path is a clean absolute path, only letters, digits and "._-/" are
+// allowed as it is rendered into the script without quoting.
+func validateAbsPath(field, path string) error {
+	if path == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf("%s %q must be a clean absolute path", field, path)
+	}
+	if !absPathRegexp.MatchString(path) {
+		return fmt.Errorf("%s %q contains characters other than letters, digits and \"._-/\"", field, path)
+	}
+	return nil
+}
+
+// validateLabelKeys checks that the keys are qualified names of labels, which are safe to be rendered
+// into the script without quoting.
+func validateLabelKeys(field string, keys []string) error {
+	for _, key := range keys {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			return fmt.Errorf("%s %q is invalid: %s", field, key, strings.Join(msgs, "; "))
+		}
+	}
+	return nil
+}
+
+// validateURL checks that url is in the form of scheme://host:port[/path], the scheme is optional
+// and the host may refer to shell variables. A url only referring to a shell variable is valid,
+// as it is got at runtime.
+func validateURL(field, url string) error {
+	if url == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if strings.HasPrefix(url, "${") && strings.HasSuffix(url, "}") {
+		return nil
+	}
+	_, addr, found := strings.Cut(url, "://")
+	if !found {
+		addr = url
+	}
+	addr, _, _ = strings.Cut(addr, "/")
+	return validateAddr(field, addr)
+}
+
+// validateURLs checks each url of a comma-separated list by validateURL.
+func validateURLs(field, urls string) error {
+	if urls == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	for _, url := range strings.Split(urls, ",") {
+		if err := validateURL(field, url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTemplate parses texts in order into the template named name,
+// the templates defined in the former texts can be used in the latter ones.
+// The funcs registered by RegisterTemplateFunc are available in the texts.
+func parseTemplate(name string, texts ...string) (*template.Template, error) {
+	tpl := template.New(name).Funcs(builtinTemplateFuncs).Funcs(registeredTemplateFuncs())
+	for _, text := range texts {
+		if _, err := tpl.Parse(text); err != nil {
+			return nil, newRenderError(ErrTemplateParse, err)
+		}
+	}
+	return tpl, nil
 }
 
 func renderTemplateFunc(tpl *template.Template, model interface{}) (string, error) {
 	buff := new(bytes.Buffer)
 	err := tpl.Execute(buff, model)
 	if err != nil {
-		return "", err
+		return "", newRenderError(ErrTemplateParse, err)
 	}
 	return buff.String(), nil
 }
+
+var absPathRegexp = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+
+// dnsLookupStatusRegexp matches the DNS lookup whose status is checked by $? in the next line of the DNS-await subscripts
+var dnsLookupStatusRegexp = regexp.MustCompile(`(\n *)digRes=\$\((.+)\)\n *if \[ \$\? -ne 0  \]; then\n`)
+
+// modelChecksumPrefix is the prefix of the header comment which carries the checksum of a script model
+const modelChecksumPrefix = "# model-checksum: "
+
+// withModelChecksum adds a header comment with the checksum of model after the shebang of script.
+// The model is marshaled to JSON to get the checksum, the fields are in the order of declaration
+// and the keys of maps are sorted, so the same model always has the same checksum.
+func withModelChecksum(script string, model interface{}) (string, error) {
+	sum, err := utils.Sha256Sum(model)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the checksum of script model: %v", err)
+	}
+	shebang, rest, _ := strings.Cut(script, "\n")
+	return shebang + "\n" + modelChecksumPrefix + sum + "\n" + rest, nil
+}
+
+// ScriptModelChecksum returns the checksum in the header comment of a start script,
+// it returns an empty string if the script is rendered without the checksum.
+func ScriptModelChecksum(script string) string {
+	_, rest, _ := strings.Cut(script, "\n")
+	header, _, _ := strings.Cut(rest, "\n")
+	sum, found := strings.CutPrefix(header, modelChecksumPrefix)
+	if !found {
+		return ""
+	}
+	return sum
+}
@@ -14,11 +14,27 @@
 package v2
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
 )
 
@@ -28,8 +44,13 @@ func TestScriptFormat(t *testing.T) {
 	scripts := []string{
 		componentCommonScript,
 		componentCommonWaitForDnsIpMatchScript,
+		podNameFallbackScript,
+		extraEnvFileScript,
+		goMaxProcsScript,
 		pdStartScript,
 		pdStartSubScript,
+		pdmsStartScript,
+		pdmsStartSubScript,
 		pumpStartScript,
 		pumpStartSubScript,
 		ticdcStartScript,
@@ -37,11 +58,16 @@ func TestScriptFormat(t *testing.T) {
 		tidbStartScript,
 		tidbStartSubScript,
 		tiflashInitScript,
+		tikvInitScript,
 		tiflashInitSubScript,
 		tiflashStartScript,
 		tiflashStartSubScript,
 		tikvStartScript,
 		tikvStartSubScript,
+		tikvPreStopScript,
+		tikvReadinessScript,
+		tiproxyStartScript,
+		tiproxyStartSubScript,
 	}
 
 	blankLineRegexp := regexp.MustCompile(`^\s*$`)
@@ -57,7 +83,1154 @@ func TestScriptFormat(t *testing.T) {
 	}
 }
 
+// validateScript checks the syntax of a rendered script by the parser of mvdan.cc/sh and shellSyntaxCheck.
 func validateScript(script string) error {
-	_, err := syntax.NewParser().Parse(strings.NewReader(script), "")
-	return err
+	if _, err := syntax.NewParser().Parse(strings.NewReader(script), ""); err != nil {
+		return err
+	}
+	return shellSyntaxCheck(script)
+}
+
+var (
+	shellSyntaxCheckOnce  sync.Once
+	shellSyntaxCheckShell []string
+)
+
+// shellSyntaxCheck checks the syntax of a rendered script by `sh -n`, which parses the script without executing
+// it, so that the script is also checked by the shell which runs it besides the parser of mvdan.cc/sh.
+// The scripts rely on /bin/sh of the images being bash, which runs in the POSIX mode as sh, so `bash --posix`
+// is used if sh is another shell, e.g. dash, and bash is used for the scripts rendered for bash.
+// The check is skipped if the shell is not available, except in CI, i.e. the CI env is set, where it fails.
+func shellSyntaxCheck(script string) error {
+	shellSyntaxCheckOnce.Do(func() {
+		if out, err := exec.Command("sh", "-c", "echo ${BASH_VERSION:-}").Output(); err == nil && strings.TrimSpace(string(out)) != "" {
+			shellSyntaxCheckShell = []string{"sh"}
+		} else if _, err := exec.LookPath("bash"); err == nil {
+			shellSyntaxCheckShell = []string{"bash", "--posix"}
+		}
+	})
+	shell := shellSyntaxCheckShell
+	if strings.HasPrefix(script, "#!") && strings.HasSuffix(strings.SplitN(script, "\n", 2)[0], "/bash") {
+		shell = []string{"bash"}
+	}
+	if len(shell) == 0 {
+		return shellNotFound("sh")
+	}
+	if _, err := exec.LookPath(shell[0]); err != nil {
+		return shellNotFound(shell[0])
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(shell[0], append(shell[1:], "-n")...)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s -n: %v: %s", strings.Join(shell, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// shellNotFound returns the error of shellSyntaxCheck if the shell is not available, it is nil unless in CI.
+func shellNotFound(shell string) error {
+	if os.Getenv("CI") == "" {
+		return nil
+	}
+	return fmt.Errorf("%s is not found, the syntax of scripts must be checked in CI", shell)
+}
+
+func TestShellSyntaxCheck(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		g.Expect(shellNotFound("sh")).Should(gomega.Succeed())
+		t.Skip("sh is not found")
+	}
+
+	g.Expect(shellSyntaxCheck("#!/bin/sh\nif true; then\n    echo ok\nfi\n")).Should(gomega.Succeed())
+	// the arrays are used by the scripts for /bin/sh
+	g.Expect(shellSyntaxCheck("#!/bin/sh\nips=($(hostname -I))\necho ${ips[@]}\n")).Should(gomega.Succeed())
+	g.Expect(shellSyntaxCheck("#!/bin/bash\n[[ -n \"${a:-}\" ]] && echo ok\n")).Should(gomega.Succeed())
+
+	err := shellSyntaxCheck("#!/bin/sh\nif true; then\n    echo ok\n")
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring(" -n: "))
+
+	// the check is skipped if the shell is not available, except in CI
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("CI", "")
+	g.Expect(shellSyntaxCheck("#!/bin/sh\nif true; then\n    echo ok\n")).Should(gomega.Succeed())
+	t.Setenv("CI", "true")
+	g.Expect(shellSyntaxCheck("#!/bin/sh\nif true; then\n    echo ok\n")).Should(gomega.MatchError(gomega.ContainSubstring("is not found")))
+}
+
+// TestGoldenScriptsSyntax checks the syntax of all the golden scripts, i.e. the expectScript of the test cases,
+// so that none of them is left unchecked if a test does not validate the rendered script.
+func TestGoldenScriptsSyntax(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	files, err := filepath.Glob("*_test.go")
+	g.Expect(err).Should(gomega.Succeed())
+	fset := token.NewFileSet()
+	count := 0
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		g.Expect(err).Should(gomega.Succeed())
+		ast.Inspect(f, func(n ast.Node) bool {
+			kv, ok := n.(*ast.KeyValueExpr)
+			if !ok {
+				return true
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok || key.Name != "expectScript" {
+				return true
+			}
+			lit, ok := kv.Value.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			script, err := strconv.Unquote(lit.Value)
+			g.Expect(err).Should(gomega.Succeed())
+			if !strings.HasPrefix(script, "#!") {
+				return true
+			}
+			count++
+			g.Expect(validateScript(script)).Should(gomega.Succeed(), "golden script at %s", fset.Position(lit.Pos()))
+			return true
+		})
+	}
+	g.Expect(count).Should(gomega.BeNumerically(">", 0))
+}
+
+func TestDataDir(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		subDir  string
+		expect  string
+		wantErr bool
+	}{
+		{subDir: "", expect: "/var/lib/tikv"},
+		{subDir: "data", expect: "/var/lib/tikv/data"},
+		{subDir: "data/tikv/", expect: "/var/lib/tikv/data/tikv"},
+		{subDir: "./data", expect: "/var/lib/tikv/data"},
+		{subDir: "/data", wantErr: true},
+		{subDir: "..", wantErr: true},
+		{subDir: "data/../../tikv", wantErr: true},
+	}
+
+	for _, c := range cases {
+		dir, err := dataDir("/var/lib/tikv", c.subDir)
+		if c.wantErr {
+			g.Expect(err).Should(gomega.HaveOccurred(), "sub dir %q", c.subDir)
+			continue
+		}
+		g.Expect(err).ShouldNot(gomega.HaveOccurred(), "sub dir %q", c.subDir)
+		g.Expect(dir).Should(gomega.Equal(c.expect), "sub dir %q", c.subDir)
+	}
+}
+
+func TestPodNameFallback(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"pump":    RenderPumpStartScript,
+		"ticdc":   RenderTiCDCStartScript,
+		"tiproxy": RenderTiProxyStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	// the tiers are tried in order, the last one exits if the pod name can not be determined
+	tiers := []string{
+		`POD_NAME=${POD_NAME:-${HOSTNAME:-}}`,
+		`POD_NAME=$(cat /etc/hostname)`,
+		`if [[ -z "${POD_NAME}" ]]`,
+		`exit 1`,
+	}
+
+	for component, render := range renders {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:      &v1alpha1.PDSpec{},
+				TiKV:    &v1alpha1.TiKVSpec{},
+				TiDB:    &v1alpha1.TiDBSpec{},
+				TiFlash: &v1alpha1.TiFlashSpec{},
+				Pump:    &v1alpha1.PumpSpec{},
+				TiCDC:   &v1alpha1.TiCDCSpec{},
+				TiProxy: &v1alpha1.TiProxySpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring(tiers[0]), "component %s", component)
+
+		// the pod name of TiProxy is only used by its advertise address
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{
+			v1alpha1.StartScriptV2FeatureFlagPodNameFallback,
+			v1alpha1.StartScriptV2FeatureFlagTiProxyAdvertiseAddr,
+		}
+		script, err = render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+
+		// the fallback is done before the pod name is used by the component
+		podNameIndex := strings.Index(script, "_POD_NAME=")
+		g.Expect(podNameIndex).Should(gomega.BeNumerically(">", 0), "component %s", component)
+		last := 0
+		for _, tier := range tiers {
+			index := strings.Index(script[last:], tier)
+			g.Expect(index).Should(gomega.BeNumerically(">=", 0), "component %s, tier %q", component, tier)
+			last += index + len(tier)
+		}
+		g.Expect(last).Should(gomega.BeNumerically("<", podNameIndex), "component %s", component)
+	}
+}
+
+func TestSourceExtraEnvFile(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]struct {
+		render  func(tc *v1alpha1.TidbCluster) (string, error)
+		envFile string
+	}{
+		"pd":      {RenderPDStartScript, "/etc/pd/extra.env"},
+		"tikv":    {RenderTiKVStartScript, "/etc/tikv/extra.env"},
+		"tidb":    {RenderTiDBStartScript, "/etc/tidb/extra.env"},
+		"tiflash": {RenderTiFlashStartScript, "/etc/tiflash/extra.env"},
+		"pump":    {RenderPumpStartScript, "/etc/pump/extra.env"},
+		"ticdc":   {RenderTiCDCStartScript, "/etc/ticdc/extra.env"},
+		"tiproxy": {RenderTiProxyStartScript, "/etc/proxy/extra.env"},
+		"tso":     {RenderPDTSOStartScript, "/etc/pd/extra.env"},
+	}
+
+	for component, r := range renders {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:      &v1alpha1.PDSpec{},
+				TiKV:    &v1alpha1.TiKVSpec{},
+				TiDB:    &v1alpha1.TiDBSpec{},
+				TiFlash: &v1alpha1.TiFlashSpec{},
+				Pump:    &v1alpha1.PumpSpec{},
+				TiCDC:   &v1alpha1.TiCDCSpec{},
+				TiProxy: &v1alpha1.TiProxySpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := r.render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("EXTRA_ENV_FILE"), "component %s", component)
+
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{
+			v1alpha1.StartScriptV2FeatureFlagSourceExtraEnvFile,
+			v1alpha1.StartScriptV2FeatureFlagPodNameFallback,
+		}
+		script, err = r.render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.ContainSubstring(`EXTRA_ENV_FILE="`+r.envFile+`"`), "component %s", component)
+
+		// the env file is sourced before the pod name fallback and the args are computed
+		sourceIndex := strings.Index(script, "source ${EXTRA_ENV_FILE}")
+		g.Expect(sourceIndex).Should(gomega.BeNumerically(">", 0), "component %s", component)
+		g.Expect(sourceIndex).Should(gomega.BeNumerically("<", strings.Index(script, "POD_NAME=${POD_NAME:-${HOSTNAME:-}}")), "component %s", component)
+	}
+}
+
+func TestGoMaxProcs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":    RenderPDStartScript,
+		"tidb":  RenderTiDBStartScript,
+		"ticdc": RenderTiCDCStartScript,
+	}
+	for component, render := range renders {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:    &v1alpha1.PDSpec{},
+				TiKV:  &v1alpha1.TiKVSpec{},
+				TiDB:  &v1alpha1.TiDBSpec{},
+				TiCDC: &v1alpha1.TiCDCSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("GOMAXPROCS"), "component %s", component)
+
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagGoMaxProcs}
+		script, err = render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.ContainSubstring("export GOMAXPROCS=${CPU_LIMIT_CORES}"), "component %s", component)
+	}
+
+	// TiKV is not written in Go
+	tc := &v1alpha1.TidbCluster{Spec: v1alpha1.TidbClusterSpec{PD: &v1alpha1.PDSpec{}, TiKV: &v1alpha1.TiKVSpec{}}}
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagGoMaxProcs}
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("GOMAXPROCS"))
+
+	cases := []struct {
+		name   string
+		env    []string
+		expect string
+	}{
+		{name: "cpu limit", env: []string{"CPU_LIMIT_CORES=4"}, expect: "4"},
+		{name: "no cpu limit", env: nil, expect: ""},
+		{name: "GOMAXPROCS set in env", env: []string{"CPU_LIMIT_CORES=4", "GOMAXPROCS=2"}, expect: "2"},
+	}
+	for _, c := range cases {
+		file, err := syntax.NewParser().Parse(strings.NewReader(fmt.Sprintf(goMaxProcsScript, "CPU_LIMIT_CORES")+`printf "%s" "${GOMAXPROCS:-}" >&2`), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var stderr bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron(c.env...)), interp.StdIO(nil, io.Discard, &stderr))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(stderr.String()).Should(gomega.Equal(c.expect), "case %s", c.name)
+	}
+}
+
+func TestDnsWaitThresholdUnit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	attemptsLines := []string{"elapseTime=$(( elapseTime+period ))"}
+	secondsLines := []string{"waitStartTime=$(date +%s)\nelapseTime=0\n", "elapseTime=$(( $(date +%s)-waitStartTime ))"}
+
+	for component, render := range renders {
+		// PD waits for DNS without the feature flag
+		for _, flags := range [][]v1alpha1.StartScriptV2FeatureFlag{nil, {v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch}} {
+			for _, unit := range []v1alpha1.DnsWaitThresholdUnit{"", v1alpha1.DnsWaitThresholdUnitAttempts, v1alpha1.DnsWaitThresholdUnitSeconds} {
+				tc := &v1alpha1.TidbCluster{
+					Spec: v1alpha1.TidbClusterSpec{
+						PD:                        &v1alpha1.PDSpec{},
+						TiKV:                      &v1alpha1.TiKVSpec{},
+						TiDB:                      &v1alpha1.TiDBSpec{},
+						TiFlash:                   &v1alpha1.TiFlashSpec{},
+						StartScriptV2FeatureFlags: flags,
+						DnsWaitThresholdUnit:      unit,
+					},
+				}
+				tc.Name = "start-script-test"
+				tc.Namespace = "start-script-test-ns"
+
+				script, err := render(tc)
+				g.Expect(err).Should(gomega.Succeed())
+				g.Expect(validateScript(script)).Should(gomega.Succeed())
+				if !strings.Contains(script, "waitThreshold=") && !strings.Contains(script, "threshold=") {
+					continue
+				}
+				expect, unexpect := attemptsLines, secondsLines
+				if unit == v1alpha1.DnsWaitThresholdUnitSeconds {
+					expect, unexpect = secondsLines, attemptsLines
+				}
+				for _, line := range expect {
+					g.Expect(script).Should(gomega.ContainSubstring(line), "component %s, flags %v, unit %q", component, flags, unit)
+				}
+				for _, line := range unexpect {
+					g.Expect(script).ShouldNot(gomega.ContainSubstring(line), "component %s, flags %v, unit %q", component, flags, unit)
+				}
+			}
+		}
+	}
+}
+
+func TestDnsWaitInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	cases := []struct {
+		interval int32
+		expect   string
+	}{
+		{interval: 0, expect: "\nperiod=1\n"},
+		{interval: 1, expect: "\nperiod=1\n"},
+		{interval: 5, expect: "\nperiod=5\n"},
+	}
+
+	for component, render := range renders {
+		for _, unit := range []v1alpha1.DnsWaitThresholdUnit{v1alpha1.DnsWaitThresholdUnitAttempts, v1alpha1.DnsWaitThresholdUnitSeconds} {
+			for _, c := range cases {
+				tc := &v1alpha1.TidbCluster{
+					Spec: v1alpha1.TidbClusterSpec{
+						PD:                        &v1alpha1.PDSpec{},
+						TiKV:                      &v1alpha1.TiKVSpec{},
+						TiDB:                      &v1alpha1.TiDBSpec{},
+						TiFlash:                   &v1alpha1.TiFlashSpec{},
+						StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch},
+						DnsWaitThresholdUnit:      unit,
+						DnsWaitIntervalSeconds:    c.interval,
+					},
+				}
+				tc.Name = "start-script-test"
+				tc.Namespace = "start-script-test-ns"
+
+				script, err := render(tc)
+				g.Expect(err).Should(gomega.Succeed())
+				g.Expect(validateScript(script)).Should(gomega.Succeed())
+				g.Expect(script).Should(gomega.ContainSubstring(c.expect), "component %s, unit %q, interval %d", component, unit, c.interval)
+				g.Expect(script).Should(gomega.ContainSubstring("    sleep ${period}\n"), "component %s, unit %q, interval %d", component, unit, c.interval)
+			}
+		}
+	}
+}
+
+func TestDnsWaitJitter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	for component, render := range renders {
+		tc := newAllComponentsTidbCluster()
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{
+			v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch,
+			v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch,
+			v1alpha1.StartScriptV2FeatureFlagDnsWaitJitter,
+		}
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(validateScript(script)).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring(dnsWaitJitterSleep), "component %s", component)
+		g.Expect(script).ShouldNot(gomega.ContainSubstring(dnsWaitSleep), "component %s", component)
+	}
+
+	// the loop of PD waiting for its DNS name only
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDnsWaitJitter}
+	script, err := RenderPDStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("threshold=30\nwhile true; do\n" + dnsWaitJitterSleep))
+
+	// the sleep is in [period, period+2] whole seconds
+	for i := 0; i < 10; i++ {
+		file, err := syntax.NewParser().Parse(strings.NewReader("period=5\n"+strings.Replace(dnsWaitJitterSleep, "sleep", "echo", 1)), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var stdout bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, io.Discard))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+		g.Expect(stdout.String()).Should(gomega.MatchRegexp(`^[5-7]\n$`))
+	}
+}
+
+func TestLocalhost(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(flags ...v1alpha1.StartScriptV2FeatureFlag) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:                        &v1alpha1.PDSpec{},
+				TiKV:                      &v1alpha1.TiKVSpec{},
+				TiDB:                      &v1alpha1.TiDBSpec{},
+				StartScriptV2FeatureFlags: flags,
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	for _, tc := range []*v1alpha1.TidbCluster{
+		newTC(v1alpha1.StartScriptV2FeatureFlagLocalhost),
+		// the DNS names are not waited for even if it is required by the other flags
+		newTC(v1alpha1.StartScriptV2FeatureFlagLocalhost, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch),
+	} {
+		pd, err := RenderPDStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(pd)).Should(gomega.Succeed())
+		g.Expect(pd).Should(gomega.ContainSubstring("\nPD_DOMAIN=127.0.0.1\n"))
+		g.Expect(pd).Should(gomega.ContainSubstring("--advertise-peer-urls=http://${PD_DOMAIN}:2380 \\\n"))
+		g.Expect(pd).Should(gomega.ContainSubstring("--advertise-client-urls=http://${PD_DOMAIN}:2379 \\\n"))
+		// PD bootstraps without discovery
+		g.Expect(pd).Should(gomega.ContainSubstring(
+			"elif [[ ! -d /var/lib/pd/member/wal ]]; then\n    ARGS=\"${ARGS} --initial-cluster=${PD_POD_NAME}=http://${PD_DOMAIN}:2380\"\nfi\n"))
+		g.Expect(pd).ShouldNot(gomega.ContainSubstring("start-script-test-discovery"))
+		g.Expect(pd).ShouldNot(gomega.ContainSubstring("dig "))
+
+		tikv, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(tikv)).Should(gomega.Succeed())
+		g.Expect(tikv).Should(gomega.ContainSubstring("--pd=127.0.0.1:2379 \\\n"))
+		g.Expect(tikv).Should(gomega.ContainSubstring("--advertise-addr=127.0.0.1:20160 \\\n"))
+		g.Expect(tikv).ShouldNot(gomega.ContainSubstring("componentDomain="))
+		g.Expect(TiKVAdvertiseAddr(tc)).Should(gomega.Equal("127.0.0.1:20160"))
+
+		tidb, err := RenderTiDBStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(tidb)).Should(gomega.Succeed())
+		g.Expect(tidb).Should(gomega.ContainSubstring("--advertise-address=127.0.0.1 \\\n"))
+		g.Expect(tidb).Should(gomega.ContainSubstring("--path=127.0.0.1:2379 \\\n"))
+		g.Expect(tidb).ShouldNot(gomega.ContainSubstring("componentDomain="))
+		g.Expect(TiDBAdvertiseAddr(tc)).Should(gomega.Equal("127.0.0.1"))
+	}
+
+	// the addresses of services are used without the flag
+	tc := newTC()
+	for _, render := range []func(tc *v1alpha1.TidbCluster) (string, error){RenderPDStartScript, RenderTiKVStartScript, RenderTiDBStartScript} {
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("127.0.0.1"))
+	}
+	pd, err := RenderPDStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(pd).ShouldNot(gomega.ContainSubstring("--initial-cluster"))
+	g.Expect(pd).Should(gomega.ContainSubstring("http://start-script-test-discovery.start-script-test-ns:10261/new/"))
+}
+
+func TestSidecarReadiness(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"pump":    RenderPumpStartScript,
+		"ticdc":   RenderTiCDCStartScript,
+		"tiproxy": RenderTiProxyStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	newTC := func(spec *v1alpha1.SidecarReadinessSpec) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:               &v1alpha1.PDSpec{},
+				TiKV:             &v1alpha1.TiKVSpec{},
+				TiDB:             &v1alpha1.TiDBSpec{},
+				TiFlash:          &v1alpha1.TiFlashSpec{},
+				Pump:             &v1alpha1.PumpSpec{},
+				TiCDC:            &v1alpha1.TiCDCSpec{},
+				TiProxy:          &v1alpha1.TiProxySpec{},
+				SidecarReadiness: spec,
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	for component, render := range renders {
+		script, err := render(newTC(nil))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("sidecar_ready_url"), "component %s", component)
+
+		script, err = render(newTC(&v1alpha1.SidecarReadinessSpec{URL: "http://localhost:15021/healthz/ready"}))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.ContainSubstring("\nsidecar_ready_url='http://localhost:15021/healthz/ready'\n"), "component %s", component)
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("is not ready after"), "component %s", component)
+		// the sidecar is waited for before the network operations, e.g. resolving the domain
+		for _, op := range []string{"nslookup ", "wget -qO- "} {
+			if i := strings.Index(script, op); i >= 0 {
+				g.Expect(strings.Index(script, "sidecar_ready_url=")).Should(gomega.BeNumerically("<", i), "component %s", component)
+			}
+		}
+
+		script, err = render(newTC(&v1alpha1.SidecarReadinessSpec{URL: "http://localhost:15021/healthz/ready", Timeout: 30}))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.ContainSubstring("    if [[ ${sidecar_wait_elapsed} -ge 30 ]]\n"), "component %s", component)
+	}
+
+	cases := []struct {
+		name     string
+		timeout  int32
+		failures int
+		fail     bool
+	}{
+		{name: "ready", failures: 0},
+		{name: "ready after retries", failures: 3},
+		{name: "ready before timeout", timeout: 5, failures: 3},
+		{name: "timeout", timeout: 2, failures: 3, fail: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+			script, err := RenderTiKVStartScript(newTC(&v1alpha1.SidecarReadinessSpec{URL: "http://localhost:15021/healthz/ready", Timeout: c.timeout}))
+			g.Expect(err).Should(gomega.Succeed())
+			begin := strings.Index(script, "\nsidecar_ready_url=")
+			end := strings.Index(script, "is ready after ${sidecar_wait_elapsed}s\"\n") + len("is ready after ${sidecar_wait_elapsed}s\"\n")
+			stubs := fmt.Sprintf(`
+failures=%d
+wget() { [[ "${@: -1}" == http://localhost:15021/healthz/ready ]] || return 2; failures=$((failures - 1)); [[ ${failures} -lt 0 ]]; }
+sleep() { :; }
+`, c.failures)
+			file, err := syntax.NewParser().Parse(strings.NewReader(stubs+script[begin:end]), "")
+			g.Expect(err).Should(gomega.Succeed())
+			var stdout bytes.Buffer
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if c.fail {
+				g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeSidecarNotReady)))
+				return
+			}
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(stdout.String()).Should(gomega.ContainSubstring(fmt.Sprintf("the sidecar is ready after %ds", c.failures)))
+		})
+	}
+}
+
+func TestShellAndStrictMode(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"pump":    RenderPumpStartScript,
+		"ticdc":   RenderTiCDCStartScript,
+		"tiproxy": RenderTiProxyStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	newTC := func() *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:                 &v1alpha1.PDSpec{},
+				TiKV:               &v1alpha1.TiKVSpec{},
+				TiDB:               &v1alpha1.TiDBSpec{},
+				TiFlash:            &v1alpha1.TiFlashSpec{},
+				Pump:               &v1alpha1.PumpSpec{},
+				TiCDC:              &v1alpha1.TiCDCSpec{},
+				TiProxy:            &v1alpha1.TiProxySpec{},
+				StartScriptVersion: v1alpha1.StartScriptV2,
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	for component, render := range renders {
+		tc := newTC()
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.HavePrefix("#!/bin/sh\n\nset -uo pipefail\n"), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring("source ${ANNOTATIONS} 2>/dev/null\n"), "component %s", component)
+
+		tc.Spec.StartScriptV2Shell = "/bin/bash"
+		script, err = render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.HavePrefix("#!/bin/bash\n\nset -uo pipefail\n"), "component %s", component)
+
+		// the lookups of all the DNS-await subscripts are checked in the condition of if
+		for _, flags := range [][]v1alpha1.StartScriptV2FeatureFlag{
+			{v1alpha1.StartScriptV2FeatureFlagStrictMode},
+			{v1alpha1.StartScriptV2FeatureFlagStrictMode, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch},
+			{v1alpha1.StartScriptV2FeatureFlagStrictMode, v1alpha1.StartScriptV2FeatureFlagPodNameFallback, v1alpha1.StartScriptV2FeatureFlagSourceExtraEnvFile},
+		} {
+			tc.Spec.StartScriptV2FeatureFlags = flags
+			script, err = render(tc)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.HavePrefix("#!/bin/bash\n\nset -euo pipefail\n"), "component %s, flags %v", component, flags)
+			g.Expect(script).Should(gomega.ContainSubstring("source ${ANNOTATIONS} 2>/dev/null || true\n"), "component %s, flags %v", component, flags)
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("if [ $? -ne 0  ]; then"), "component %s, flags %v", component, flags)
+			if strings.Contains(script, "digRes=") {
+				g.Expect(script).Should(gomega.MatchRegexp(`\n    if ! digRes=\$\((dig|eval) .+\); then\n        echo "domain resolve \$\{\w+\} failed"\n`),
+					"component %s, flags %v", component, flags)
+			}
+		}
+
+		// the shell of start script v1 is not configurable
+		tc.Spec.StartScriptVersion = v1alpha1.StartScriptV1
+		g.Expect(tc.StartScriptShell()).Should(gomega.Equal("/bin/sh"))
+	}
+
+	// the other scripts are run by /bin/sh
+	tc := newTC()
+	tc.Spec.StartScriptV2Shell = "/bin/bash"
+	tc.Spec.TiKV.PreStop = &v1alpha1.TiKVPreStopSpec{}
+	script, err := RenderTiKVPreStopScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.HavePrefix("#!/bin/sh\n"))
+}
+
+func TestStrictModeAcrossK8s(t *testing.T) {
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV:      &v1alpha1.TiKVSpec{},
+			AcrossK8s: true,
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	cases := []struct {
+		name   string
+		strict bool
+		// brokenDataDir makes the PD addr verified by discovery fail to be cached in the data dir
+		brokenDataDir bool
+		fail          bool
+	}{
+		{
+			name: "verified",
+		},
+		{
+			name:   "verified in strict mode",
+			strict: true,
+		},
+		{
+			name:          "failed to cache pd addr",
+			brokenDataDir: true,
+		},
+		{
+			name:          "failed to cache pd addr in strict mode",
+			strict:        true,
+			brokenDataDir: true,
+			fail:          true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+			if c.strict {
+				tc.Spec.StartScriptV2FeatureFlags = append(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagStrictMode)
+			}
+			script, err := RenderTiKVStartScript(tc)
+			g.Expect(err).Should(gomega.Succeed())
+
+			tmp := t.TempDir()
+			// the annotations contain keys which are not valid variable names
+			annotations := filepath.Join(tmp, "annotations")
+			g.Expect(os.WriteFile(annotations, []byte("prometheus.io/port=\"20180\"\nrunmode=\"normal\"\n"), 0644)).Should(gomega.Succeed())
+			dataDir := filepath.Join(tmp, "data")
+			if c.brokenDataDir {
+				g.Expect(os.WriteFile(filepath.Join(tmp, "file"), nil, 0644)).Should(gomega.Succeed())
+				dataDir = filepath.Join(tmp, "file", "data")
+			}
+			script = strings.NewReplacer(
+				"/etc/podinfo/annotations", annotations,
+				"/var/lib/tikv", dataDir,
+				"exec /tikv-server ${ARGS}", `echo "started ${ARGS}"`,
+			).Replace(script)
+
+			stubs := `wget() { echo "http://pd-0.pd-peer:2379"; }` + "\n"
+			file, err := syntax.NewParser().Parse(strings.NewReader(stubs+script), "")
+			g.Expect(err).Should(gomega.Succeed())
+			var stdout bytes.Buffer
+			env := expand.ListEnviron("PATH="+os.Getenv("PATH"), "HOSTNAME=tikv-0", "CAPACITY=0")
+			runner, err := interp.New(interp.Env(env), interp.StdIO(nil, &stdout, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if c.fail {
+				g.Expect(err).Should(gomega.HaveOccurred())
+				g.Expect(stdout.String()).ShouldNot(gomega.ContainSubstring("started"))
+				return
+			}
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(stdout.String()).Should(gomega.ContainSubstring("started --pd=pd-0.pd-peer:2379 "))
+		})
+	}
+}
+
+func TestValidateAddrAndURL(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	validAddrs := []string{"0.0.0.0:20160", "[::]:20160", "${TIKV_POD_NAME}.tikv-peer.ns.svc:20160", "basic-pd:2379"}
+	for _, addr := range validAddrs {
+		g.Expect(validateAddr("Addr", addr)).Should(gomega.Succeed(), "addr %q", addr)
+	}
+	invalidAddrs := []string{"", "0.0.0.0", ":20160", "basic-pd:0", "basic-pd:65536", "basic-pd:port", "::1:2379"}
+	for _, addr := range invalidAddrs {
+		g.Expect(validateAddr("Addr", addr)).ShouldNot(gomega.Succeed(), "addr %q", addr)
+	}
+
+	validURLs := []string{"http://basic-pd:2379", "basic-pd:2379", "https://[::1]:20180/status", "${result}"}
+	for _, url := range validURLs {
+		g.Expect(validateURL("URL", url)).Should(gomega.Succeed(), "url %q", url)
+	}
+	invalidURLs := []string{"", "http://", "http://basic-pd", "https://:2379/status"}
+	for _, url := range invalidURLs {
+		g.Expect(validateURL("URL", url)).ShouldNot(gomega.Succeed(), "url %q", url)
+	}
+
+	g.Expect(validateURLs("PDAddr", "pd-0:2379,pd-1:2379")).Should(gomega.Succeed())
+	g.Expect(validateURLs("PDAddr", "pd-0:2379,")).ShouldNot(gomega.Succeed())
+}
+
+func TestValidateAbsPath(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, path := range []string{"/tikv-server", "/usr/local/bin/tikv-server", "/opt/tikv_v8.5.0/bin/tikv-server"} {
+		g.Expect(validateAbsPath("BinaryPath", path)).Should(gomega.Succeed(), "path %q", path)
+	}
+	for _, path := range []string{"", "tikv-server", "/usr/local/bin/", "/opt/../tikv-server", "/opt/tikv server", "/tikv-server;id", "/$(id)"} {
+		g.Expect(validateAbsPath("BinaryPath", path)).ShouldNot(gomega.Succeed(), "path %q", path)
+	}
+}
+
+func TestScriptModelValidate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type validator interface {
+		Validate() error
+	}
+	cases := []struct {
+		name      string
+		model     validator
+		expectErr []string
+	}{
+		{
+			name: "valid tikv start script model",
+			model: &TiKVStartScriptModel{
+				PDAddr:        "basic-pd:2379",
+				Addr:          "0.0.0.0:20160",
+				StatusAddr:    "0.0.0.0:20180",
+				AdvertiseHost: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc",
+				AdvertisePort: 20160,
+				AdvertiseAddr: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc:20160",
+				DataDir:       "/var/lib/tikv",
+				Capacity:      "${CAPACITY}",
+				BinaryPath:    "/tikv-server",
+				ExecAttempts:  1,
+				ConfigPath:    "/etc/tikv/tikv.toml",
+			},
+		},
+		{
+			name: "tikv start script model without pd addr and data dir",
+			model: &TiKVStartScriptModel{
+				Addr:          "0.0.0.0:20160",
+				StatusAddr:    "0.0.0.0:20180",
+				AdvertiseHost: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc",
+				AdvertisePort: 20160,
+				AdvertiseAddr: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc:20160",
+				Capacity:      "${CAPACITY}",
+			},
+			expectErr: []string{"invalid TiKV start script model", "PDAddr is required", "DataDir is required"},
+		},
+		{
+			name: "tikv start script model with invalid port and across k8s model",
+			model: &TiKVStartScriptModel{
+				PDAddr:        "${result}",
+				Addr:          "0.0.0.0:0",
+				StatusAddr:    "0.0.0.0:20180",
+				AdvertiseHost: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc",
+				AdvertisePort: 20160,
+				AdvertiseAddr: "${TIKV_POD_NAME}.basic-tikv-peer.ns.svc:20160",
+				DataDir:       "/var/lib/tikv",
+				Capacity:      "${CAPACITY}",
+				BinaryPath:    "/tikv-server",
+				ExecAttempts:  1,
+				ConfigPath:    "/etc/tikv/tikv.toml",
+				AcrossK8s:     &AcrossK8sScriptModel{PDAddr: "http://basic-pd:2379"},
+			},
+			expectErr: []string{`Addr "0.0.0.0:0" has invalid port "0"`, "invalid across k8s script model", "DiscoveryAddr is required"},
+		},
+		{
+			name:      "empty pd start script model",
+			model:     &PDStartScriptModel{},
+			expectErr: []string{"PDDomain is required", "PeerURL is required", "DiscoveryAddr is required"},
+		},
+		{
+			name:      "tikv prestop script model without timeout",
+			model:     &TiKVPreStopScriptModel{PDAddr: "basic-pd:2379", PDScheme: "http", AdvertiseAddr: "basic-tikv-0:20160"},
+			expectErr: []string{"Timeout 0 must be positive"},
+		},
+		{
+			name:      "tikv readiness script model without port",
+			model:     &TiKVReadinessScriptModel{StatusURL: "http://127.0.0.1/status"},
+			expectErr: []string{"StatusURL"},
+		},
+		{
+			name:      "tidb start script model without advertise addr",
+			model:     &TiDBStartScriptModel{PDAddr: "basic-pd:2379", ListenHost: "0.0.0.0"},
+			expectErr: []string{"AdvertiseAddr is required"},
+		},
+		{
+			name:      "ticdc start script model with pd addr without port",
+			model:     &TiCDCStartScriptModel{Addr: "0.0.0.0:8301", AdvertiseAddr: "basic-ticdc-0:8301", PDAddr: "http://basic-pd"},
+			expectErr: []string{"PDAddr"},
+		},
+		{
+			name:  "valid tiflash init script model",
+			model: &TiFlashInitScriptModel{},
+		},
+		{
+			name:      "tiproxy start script model without config path",
+			model:     &TiProxyStartScriptModel{AdvertiseAddr: "basic-tiproxy-0"},
+			expectErr: []string{"ConfigPath is required"},
+		},
+	}
+
+	for _, c := range cases {
+		err := c.model.Validate()
+		if len(c.expectErr) == 0 {
+			g.Expect(err).Should(gomega.Succeed(), c.name)
+			continue
+		}
+		g.Expect(err).Should(gomega.HaveOccurred(), c.name)
+		for _, msg := range c.expectErr {
+			g.Expect(err.Error()).Should(gomega.ContainSubstring(msg), c.name)
+		}
+	}
+}
+
+func TestShellQuoteArgs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	args := []string{
+		"--security-redact-info-log",
+		"--log-file=/var/log/tikv log.log",
+		`--labels=zone="z1"`,
+		"--config-check='it''s'",
+		"--data-dir=${HOME}/$(hostname)/`id`",
+		`--pd=\\pd;exit 1`,
+		"",
+	}
+	quoted := shellQuoteArgs(args)
+
+	f, err := syntax.NewParser().Parse(strings.NewReader("set -- "+quoted), "")
+	g.Expect(err).Should(gomega.Succeed())
+	call := f.Stmts[0].Cmd.(*syntax.CallExpr)
+	fields, err := expand.Fields(&expand.Config{Env: expand.ListEnviron("HOME=/root")}, call.Args[2:]...)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(fields).Should(gomega.Equal(args))
+}
+
+func TestAcrossK8sCABundle(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"tikv":         RenderTiKVStartScript,
+		"tidb":         RenderTiDBStartScript,
+		"tiflash-init": RenderTiFlashInitScript,
+		"pump":         RenderPumpStartScript,
+		"ticdc":        RenderTiCDCStartScript,
+		"tiproxy":      RenderTiProxyStartScript,
+		"tso":          RenderPDTSOStartScript,
+	}
+	for component, render := range renders {
+		for _, caBundlePath := range []string{"", "/var/lib/discovery-ca/ca.crt", "ca.crt"} {
+			tc := newAllComponentsTidbCluster()
+			tc.Spec.AcrossK8s = true
+			tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{CABundlePath: caBundlePath}
+
+			script, err := render(tc)
+			if caBundlePath == "ca.crt" {
+				g.Expect(err).Should(gomega.HaveOccurred(), "component %s", component)
+				continue
+			}
+			g.Expect(err).Should(gomega.Succeed(), "component %s, CA bundle %q", component, caBundlePath)
+			if caBundlePath == "" {
+				g.Expect(script).Should(gomega.ContainSubstring("wget -qO- -T 3 http://${discovery_url}/verify/"), "component %s", component)
+				g.Expect(script).ShouldNot(gomega.ContainSubstring("--ca-certificate"), "component %s", component)
+				continue
+			}
+			g.Expect(script).Should(gomega.ContainSubstring("wget -qO- -T 3 --ca-certificate=/var/lib/discovery-ca/ca.crt https://${discovery_url}/verify/"), "component %s", component)
+			g.Expect(validateScript(script)).Should(gomega.Succeed(), "component %s", component)
+		}
+	}
+}
+
+func TestAcrossK8sEncodeDomainURL(t *testing.T) {
+	base64Bin, err := exec.LookPath("base64")
+	if err != nil {
+		t.Skip("base64 is not found")
+	}
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"tikv":         RenderTiKVStartScript,
+		"tidb":         RenderTiDBStartScript,
+		"tiflash-init": RenderTiFlashInitScript,
+		"pump":         RenderPumpStartScript,
+		"ticdc":        RenderTiCDCStartScript,
+		"tiproxy":      RenderTiProxyStartScript,
+		"tso":          RenderPDTSOStartScript,
+	}
+	// the toolchains of images are emulated by the stubs, the encoded URL is longer than any wrapping column
+	toolchains := map[string]string{
+		"coreutils":        "",
+		"busybox":          fmt.Sprintf("base64() { %s -w 64; }", base64Bin),
+		"crlf":             fmt.Sprintf("base64() { %s -w 16 | sed 's/$/\\r/'; }", base64Bin),
+		"without base64":   fmt.Sprintf("base64() { return 127; }\nopenssl() { [[ $1 == base64 ]] && %s -w 64; }", base64Bin),
+		"no line wrapping": fmt.Sprintf("base64() { %s -w 0; }", base64Bin),
+	}
+	// the toolchains which are only supported with the Base64Fallback feature flag
+	fallbackToolchains := map[string]bool{
+		"crlf":           true,
+		"without base64": true,
+	}
+	pdURL := "http://start-script-test-pd.start-script-test-ns.svc.cluster-a.example.com:2379"
+	expected := base64.StdEncoding.EncodeToString([]byte(pdURL + "\n"))
+	encodeLine := regexp.MustCompile(`(?m)^encoded_domain_url=.*$`)
+
+	for _, fallback := range []bool{false, true} {
+		for component, render := range renders {
+			tc := newAllComponentsTidbCluster()
+			tc.Spec.AcrossK8s = true
+			encodeCmd := base64EncodeCmd
+			if fallback {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagBase64Fallback}
+				encodeCmd = base64FallbackEncodeCmd
+			}
+			script, err := render(tc)
+			g := gomega.NewGomegaWithT(t)
+			g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+			line := encodeLine.FindString(script)
+			g.Expect(line).Should(gomega.Equal(fmt.Sprintf("encoded_domain_url=$(echo $pd_url | %s)", encodeCmd)), "component %s", component)
+
+			for name, stubs := range toolchains {
+				if fallbackToolchains[name] && !fallback {
+					continue
+				}
+				fragment := fmt.Sprintf("%s\npd_url=%s\n%s\necho -n ${encoded_domain_url}\n", stubs, pdURL, line)
+				file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+				g.Expect(err).Should(gomega.Succeed())
+				var stdout bytes.Buffer
+				runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, io.Discard))
+				g.Expect(err).Should(gomega.Succeed())
+				g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "component %s, toolchain %s, fallback %v", component, name, fallback)
+				g.Expect(stdout.String()).Should(gomega.Equal(expected), "component %s, toolchain %s, fallback %v", component, name, fallback)
+			}
+		}
+	}
+}
+
+func TestAcrossK8sVerbose(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"tikv":         RenderTiKVStartScript,
+		"tidb":         RenderTiDBStartScript,
+		"tiflash-init": RenderTiFlashInitScript,
+		"pump":         RenderPumpStartScript,
+		"ticdc":        RenderTiCDCStartScript,
+		"tiproxy":      RenderTiProxyStartScript,
+		"tso":          RenderPDTSOStartScript,
+	}
+	verboseLine := "\ndone\necho \"[start-script] effective PD endpoints: ${result}\" >&2\n"
+	for component, render := range renders {
+		for _, verbose := range []bool{false, true} {
+			tc := newAllComponentsTidbCluster()
+			tc.Spec.AcrossK8s = true
+			tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{Verbose: verbose}
+
+			script, err := render(tc)
+			g.Expect(err).Should(gomega.Succeed(), "component %s, verbose %v", component, verbose)
+			g.Expect(validateScript(script)).Should(gomega.Succeed(), "component %s, verbose %v", component, verbose)
+			if !verbose {
+				g.Expect(script).ShouldNot(gomega.ContainSubstring("[start-script]"), "component %s", component)
+				continue
+			}
+			g.Expect(script).Should(gomega.ContainSubstring(verboseLine), "component %s", component)
+		}
+	}
+
+	// the verbose line follows the write of the PD addr cache
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.AcrossK8s = true
+	tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{Verbose: true}
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("echo \"${result}\" > ${pd_addr_cache}\nfi\necho \"[start-script] effective PD endpoints: ${result}\" >&2\n"))
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		version    string
+		minVersion string
+		expect     bool
+	}{
+		{version: "v6.5.0", minVersion: "v7.1.0", expect: false},
+		{version: "v7.1.0", minVersion: "v7.1.0", expect: true},
+		{version: "v7.1.0-20230601", minVersion: "v7.1.0", expect: true},
+		{version: "7.5.1", minVersion: "v7.1.0", expect: true},
+		{version: "latest", minVersion: "v7.1.0", expect: true},
+		{version: "", minVersion: "v7.1.0", expect: true},
+		{version: "custom", minVersion: "v7.1.0", expect: true},
+	}
+	for _, c := range cases {
+		g.Expect(versionAtLeast(c.version, c.minVersion)).Should(gomega.Equal(c.expect), "version %q", c.version)
+	}
+}
+
+func TestFormatHost(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		host    string
+		addr    string
+		urlHost string
+	}{
+		{host: "10.0.0.1", addr: "10.0.0.1", urlHost: "10.0.0.1"},
+		{host: "tikv.example.com", addr: "tikv.example.com", urlHost: "tikv.example.com"},
+		{host: "[::]", addr: "[::]", urlHost: "[::]"},
+		{host: "fd00::1", addr: "[fd00::1]", urlHost: "[fd00::1]"},
+		{host: "[fd00:0::1]", addr: "[fd00::1]", urlHost: "[fd00::1]"},
+		{host: "::ffff:10.0.0.1", addr: "[::ffff:10.0.0.1]", urlHost: "[::ffff:10.0.0.1]"},
+		{host: "fe80::1%eth0", addr: "[fe80::1%eth0]", urlHost: "[fe80::1%25eth0]"},
+		{host: "[fe80::1%2]", addr: "[fe80::1%2]", urlHost: "[fe80::1%252]"},
+	}
+	for _, c := range cases {
+		g.Expect(formatHost(c.host)).Should(gomega.Equal(c.addr), "host %q", c.host)
+		g.Expect(urlHost(c.host)).Should(gomega.Equal(c.urlHost), "host %q", c.host)
+		g.Expect(validateAddr("addr", c.addr+":20180")).Should(gomega.Succeed(), "host %q", c.host)
+		g.Expect(validateURL("url", "http://"+c.urlHost+":20180")).Should(gomega.Succeed(), "host %q", c.host)
+	}
+}
+
+func TestFormatListenAddr(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		host       string
+		preferIPv6 bool
+		addr       string
+	}{
+		{host: "", addr: "0.0.0.0:20160"},
+		{host: "", preferIPv6: true, addr: "[::]:20160"},
+		{host: "10.0.0.1", addr: "10.0.0.1:20160"},
+		{host: "10.0.0.1", preferIPv6: true, addr: "10.0.0.1:20160"},
+		{host: "::", addr: "[::]:20160"},
+		{host: "[::]", addr: "[::]:20160"},
+		{host: "fd00::1", addr: "[fd00::1]:20160"},
+		{host: "[fd00::1]", preferIPv6: true, addr: "[fd00::1]:20160"},
+		{host: "fe80::1%eth0", addr: "[fe80::1%eth0]:20160"},
+		{host: "tikv.example.com", addr: "tikv.example.com:20160"},
+		{host: "${POD_NAME}.tikv-peer", preferIPv6: true, addr: "${POD_NAME}.tikv-peer:20160"},
+	}
+	for _, c := range cases {
+		g.Expect(formatListenAddr(c.host, 20160, c.preferIPv6)).Should(gomega.Equal(c.addr), "host %q", c.host)
+	}
+
+	tc := &v1alpha1.TidbCluster{}
+	g.Expect(listenHost(tc, false)).Should(gomega.Equal("0.0.0.0"))
+	g.Expect(listenHost(tc, true)).Should(gomega.Equal("[::]"))
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+	g.Expect(listenHost(tc, false)).Should(gomega.Equal("[::]"))
 }
@@ -0,0 +1,221 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// DrainerDestDBType is the type of the downstream which Drainer replicates binlog to
+type DrainerDestDBType string
+
+const (
+	// DrainerDestDBTypeMySQL replicates binlog to a MySQL compatible database
+	DrainerDestDBTypeMySQL DrainerDestDBType = "mysql"
+	// DrainerDestDBTypeTiDB replicates binlog to a TiDB cluster
+	DrainerDestDBTypeTiDB DrainerDestDBType = "tidb"
+	// DrainerDestDBTypeKafka replicates binlog to Kafka
+	DrainerDestDBTypeKafka DrainerDestDBType = "kafka"
+	// DrainerDestDBTypeFile writes binlog to files
+	DrainerDestDBTypeFile DrainerDestDBType = "file"
+)
+
+const (
+	// drainerDataDir is the mount path of the Drainer data volume
+	drainerDataDir = "/data"
+	// drainerConfigPath is the path of the config file mounted from the ConfigMap
+	drainerConfigPath = "/etc/drainer/drainer.toml"
+)
+
+// Drainer contains the settings of a Drainer which replicates binlog of a TidbCluster.
+// Drainer is not a component of TidbCluster, so these settings are passed along with it.
+type Drainer struct {
+	// Name is the name of the Drainer StatefulSet and its headless service
+	Name string
+	// LogLevel defaults to info
+	LogLevel string
+	// DestDBType defaults to mysql
+	DestDBType DrainerDestDBType
+	// ConfigPath defaults to /etc/drainer/drainer.toml
+	ConfigPath string
+
+	// DestHost, DestPort and DestUser are used to connect to a mysql or tidb downstream.
+	// The password is read by Drainer from the MYSQL_PSWD env, which is usually set from a Secret.
+	DestHost string
+	DestPort int32
+	DestUser string
+
+	// KafkaAddrs are the brokers of a kafka downstream
+	KafkaAddrs []string
+}
+
+// DrainerStartScriptModel contain fields for rendering Drainer start script
+type DrainerStartScriptModel struct {
+	PDAddr        string
+	LogLevel      string
+	Addr          string
+	AdvertiseAddr string
+	DataDir       string
+	ConfigPath    string
+	DestDBType    string
+
+	// DestHost, DestPort and DestUser are exported as the env used by Drainer if they are
+	// not set in the config file, they are only set when the downstream is mysql or tidb.
+	DestHost string
+	DestPort int32
+	DestUser string
+
+	// KafkaAddrs is the comma-separated broker list, it is only set when the downstream is kafka.
+	KafkaAddrs string
+
+	AcrossK8s *AcrossK8sScriptModel
+}
+
+// Validate checks the fields required by Drainer start script
+func (m *DrainerStartScriptModel) Validate() error {
+	errs := []error{
+		validateURL("PDAddr", m.PDAddr),
+		validateRequired("LogLevel", m.LogLevel),
+		validateAddr("Addr", m.Addr),
+		validateAddr("AdvertiseAddr", m.AdvertiseAddr),
+		validateRequired("DataDir", m.DataDir),
+		validateRequired("ConfigPath", m.ConfigPath),
+		m.AcrossK8s.Validate(),
+	}
+	switch DrainerDestDBType(m.DestDBType) {
+	case DrainerDestDBTypeMySQL, DrainerDestDBTypeTiDB:
+		errs = append(errs,
+			validateRequired("DestHost", m.DestHost),
+			validatePositive("DestPort", int(m.DestPort)),
+		)
+	case DrainerDestDBTypeKafka:
+		if m.KafkaAddrs == "" {
+			errs = append(errs, fmt.Errorf("KafkaAddrs is required"))
+		}
+		for _, addr := range strings.Split(m.KafkaAddrs, ",") {
+			errs = append(errs, validateAddr("KafkaAddrs", addr))
+		}
+	case DrainerDestDBTypeFile:
+	default:
+		errs = append(errs, fmt.Errorf("DestDBType %q is not supported", m.DestDBType))
+	}
+	return validateModel("Drainer start", errs...)
+}
+
+// RenderDrainerStartScript renders the start script of a Drainer which replicates binlog of TidbCluster
+func RenderDrainerStartScript(tc *v1alpha1.TidbCluster, drainer *Drainer) (string, error) {
+	if drainer == nil || drainer.Name == "" {
+		return "", fmt.Errorf("the name of Drainer is required")
+	}
+	m := &DrainerStartScriptModel{}
+
+	m.PDAddr, m.AcrossK8s = binlogPDAddr(tc)
+
+	m.LogLevel = drainer.LogLevel
+	if m.LogLevel == "" {
+		m.LogLevel = "info"
+	}
+
+	m.Addr = formatListenAddr("", v1alpha1.DefaultDrainerPort, listenOnIPv6(tc, tc.Spec.PreferIPv6))
+	m.AdvertiseAddr = DrainerAdvertiseAddr(tc, drainer)
+
+	m.DataDir = drainerDataDir
+
+	m.ConfigPath = drainer.ConfigPath
+	if m.ConfigPath == "" {
+		m.ConfigPath = drainerConfigPath
+	}
+
+	m.DestDBType = string(drainer.DestDBType)
+	if m.DestDBType == "" {
+		m.DestDBType = string(DrainerDestDBTypeMySQL)
+	}
+	switch DrainerDestDBType(m.DestDBType) {
+	case DrainerDestDBTypeMySQL, DrainerDestDBTypeTiDB:
+		m.DestHost = drainer.DestHost
+		m.DestPort = drainer.DestPort
+		m.DestUser = drainer.DestUser
+	case DrainerDestDBTypeKafka:
+		m.KafkaAddrs = strings.Join(drainer.KafkaAddrs, ",")
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	drainerStartScriptTpl, err := parseStartScriptTemplate(tc, "drainer-start-script", drainerStartSubScript,
+		commonScript(tc, "/etc/drainer")+drainerStartScript)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(drainerStartScriptTpl, m)
+}
+
+const (
+	// drainerStartSubScript contains optional subscripts used in start script.
+	drainerStartSubScript = `
+{{ define "AcrossK8sSubscript" }}
+pd_url={{ .AcrossK8s.PDAddr }}
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
+discovery_url={{ .AcrossK8s.DiscoveryAddr }}
+until result=$(` + acrossK8sVerifySubScript + `); do` + acrossK8sMaxRetriesSubScript + `
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
+{{- end}}
+
+{{ define "DownstreamSubscript" }}
+{{- if .DestHost }}
+# the downstream in the config file takes precedence over the env
+export MYSQL_HOST={{ .DestHost }}
+export MYSQL_PORT={{ .DestPort }}
+{{- if .DestUser }}
+export MYSQL_USER={{ .DestUser }}
+{{- end }}
+{{- else if .KafkaAddrs }}
+# the downstream in the config file takes precedence over the env
+export KAFKA_ADDRS={{ .KafkaAddrs }}
+{{- end }}
+{{- end }}
+`
+
+	// drainerStartScript is the template of start script.
+	drainerStartScript = `
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+{{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
+{{- template "DownstreamSubscript" . }}
+
+ARGS="-pd-urls={{ .PDAddr }} \
+-L {{ .LogLevel }} \
+-log-file= \
+-addr={{ .Addr }} \
+-advertise-addr={{ .AdvertiseAddr }} \
+-data-dir={{ .DataDir }} \
+-dest-db-type={{ .DestDBType }} \
+-config={{ .ConfigPath }}"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`
+)
@@ -0,0 +1,473 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+)
+
+func TestRenderDrainerStartScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name string
+
+		modify       func(tc *v1alpha1.TidbCluster, drainer *Drainer)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name: "mysql downstream",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				drainer.DestHost = "mysql.demo"
+				drainer.DestPort = 3306
+				drainer.DestUser = "root"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the downstream in the config file takes precedence over the env
+export MYSQL_HOST=mysql.demo
+export MYSQL_PORT=3306
+export MYSQL_USER=root
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=mysql \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "tidb downstream",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				drainer.DestDBType = DrainerDestDBTypeTiDB
+				drainer.DestHost = "downstream-tidb.default.svc"
+				drainer.DestPort = 4000
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the downstream in the config file takes precedence over the env
+export MYSQL_HOST=downstream-tidb.default.svc
+export MYSQL_PORT=4000
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=tidb \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "kafka downstream",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				drainer.DestDBType = DrainerDestDBTypeKafka
+				drainer.KafkaAddrs = []string{"kafka-0.kafka:9092", "kafka-1.kafka:9092"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the downstream in the config file takes precedence over the env
+export KAFKA_ADDRS=kafka-0.kafka:9092,kafka-1.kafka:9092
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=kafka \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "file downstream",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				drainer.DestDBType = DrainerDestDBTypeFile
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=file \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "file downstream with log level and config path",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				drainer.DestDBType = DrainerDestDBTypeFile
+				drainer.LogLevel = "debug"
+				drainer.ConfigPath = "/etc/drainer/custom.toml"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L debug \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=file \
+-config=/etc/drainer/custom.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "prefer ipv6 with cluster domain",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.ClusterDomain = "demo.com"
+				drainer.DestDBType = DrainerDestDBTypeFile
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-addr=[::]:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer.start-script-test-ns.svc.demo.com:8249 \
+-data-dir=/data \
+-dest-db-type=file \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "across k8s",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				tc.Spec.AcrossK8s = true
+				drainer.DestDBType = DrainerDestDBTypeFile
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="-pd-urls=${result} \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer.start-script-test-ns.svc:8249 \
+-data-dir=/data \
+-dest-db-type=file \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "heterogeneous cluster without local pd",
+			modify: func(tc *v1alpha1.TidbCluster, drainer *Drainer) {
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster"}
+				drainer.DestDBType = DrainerDestDBTypeFile
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+DRAINER_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="-pd-urls=http://target-cluster-pd:2379 \
+-L info \
+-log-file= \
+-addr=0.0.0.0:8249 \
+-advertise-addr=${DRAINER_POD_NAME}.start-script-test-drainer:8249 \
+-data-dir=/data \
+-dest-db-type=file \
+-config=/etc/drainer/drainer.toml"
+
+echo "start drainer ..."
+echo "/drainer ${ARGS}"
+exec /drainer ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "drainer offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				Pump: &v1alpha1.PumpSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		drainer := &Drainer{Name: "start-script-test-drainer"}
+		if c.modify != nil {
+			c.modify(tc, drainer)
+		}
+
+		script, err := RenderDrainerStartScript(tc, drainer)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestRenderDrainerStartScriptWithInvalidDownstream(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := map[string]*Drainer{
+		"no name":             {DestDBType: DrainerDestDBTypeFile},
+		"mysql without host":  {Name: "drainer", DestPort: 3306},
+		"tidb without port":   {Name: "drainer", DestDBType: DrainerDestDBTypeTiDB, DestHost: "tidb"},
+		"kafka without addrs": {Name: "drainer", DestDBType: DrainerDestDBTypeKafka},
+		"kafka with invalid addr": {
+			Name:       "drainer",
+			DestDBType: DrainerDestDBTypeKafka,
+			KafkaAddrs: []string{"kafka-0.kafka"},
+		},
+		"unsupported type": {Name: "drainer", DestDBType: "s3"},
+	}
+
+	for name, drainer := range cases {
+		tc := &v1alpha1.TidbCluster{}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		_, err := RenderDrainerStartScript(tc, drainer)
+		g.Expect(err).Should(gomega.HaveOccurred(), "case %s", name)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+)
+
+// tikvCapacityEnv is the env of the capacity passed to TiKV by --capacity unless it is pre-computed
+const tikvCapacityEnv = "CAPACITY"
+
+// TiKVRequiredEnvVars returns the env vars which the TiKV start script rendered from tc references without a default,
+// the container of TiKV must set them, otherwise the script exits on the unbound variables by `set -u`.
+// The optional ones, e.g. POD_NAME and STORE_LABELS, and the ones set by the shell, e.g. HOSTNAME, are not included.
+func TiKVRequiredEnvVars(tc *v1alpha1.TidbCluster) []string {
+	var envs []string
+	if !tc.Spec.TiKV.PreComputeCapacity {
+		envs = append(envs, tikvCapacityEnv)
+	}
+	if tc.TiKVCPULimitUsed() {
+		envs = append(envs, constants.TiKVCPULimitEnv)
+	}
+	if tc.Spec.TiKV.BlockCacheSizedByMemoryLimit() {
+		envs = append(envs, constants.TiKVMemoryLimitEnv)
+	}
+	return envs
+}
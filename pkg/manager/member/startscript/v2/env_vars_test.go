@@ -0,0 +1,163 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// unboundEnvVars returns the variables which the script references but does not assign, i.e. the ones which
+// must be set by the environment. The variables which are referenced with a default or an alternate somewhere are
+// treated as optional, because the script checks them before the references without them.
+func unboundEnvVars(g *gomega.WithT, script string) []string {
+	f, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	g.Expect(err).Should(gomega.Succeed())
+
+	assigned := map[string]bool{
+		// set by the shell
+		"HOSTNAME": true,
+		"RANDOM":   true,
+	}
+	referenced := map[string]bool{}
+	optional := map[string]bool{}
+	syntax.Walk(f, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Assign:
+			assigned[n.Name.Value] = true
+		case *syntax.WordIter:
+			assigned[n.Name.Value] = true
+		case *syntax.CallExpr:
+			if len(n.Args) > 0 && n.Args[0].Lit() == "read" {
+				for _, arg := range n.Args[1:] {
+					if name := arg.Lit(); syntax.ValidName(name) {
+						assigned[name] = true
+					}
+				}
+			}
+		case *syntax.ParamExp:
+			if n.Exp != nil {
+				switch n.Exp.Op {
+				case syntax.DefaultUnset, syntax.DefaultUnsetOrNull:
+					// the default is expanded only if the variable is unset
+					optional[n.Param.Value] = true
+					return true
+				case syntax.AlternateUnset, syntax.AlternateUnsetOrNull:
+					// the alternate is expanded only if the variable is set
+					optional[n.Param.Value] = true
+					return false
+				}
+			}
+			if syntax.ValidName(n.Param.Value) {
+				referenced[n.Param.Value] = true
+			}
+		}
+		return true
+	})
+
+	var envs []string
+	for name := range referenced {
+		if !assigned[name] && !optional[name] {
+			envs = append(envs, name)
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+func TestTiKVRequiredEnvVars(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(tc *v1alpha1.TidbCluster)
+		envs   []string
+	}{
+		{
+			name:   "basic",
+			modify: func(tc *v1alpha1.TidbCluster) {},
+			envs:   []string{"CAPACITY"},
+		},
+		{
+			name: "pre-computed capacity",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreComputeCapacity = true
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")}
+			},
+			envs: nil,
+		},
+		{
+			name: "cpu limit",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+			},
+			envs: []string{"CAPACITY"},
+		},
+		{
+			name: "cpu quota",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota}
+			},
+			envs: []string{"CAPACITY", "TIKV_CPU_LIMIT"},
+		},
+		{
+			name: "block cache sized by memory limit",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi")}
+				tc.Spec.TiKV.BlockCacheMemoryPercent = 40
+			},
+			envs: []string{"CAPACITY", "TIKV_MEMORY_LIMIT"},
+		},
+		{
+			name: "strict mode across k8s",
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{
+					v1alpha1.StartScriptV2FeatureFlagStrictMode,
+					v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch,
+					v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch,
+				}
+			},
+			envs: []string{"CAPACITY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tc := &v1alpha1.TidbCluster{
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{},
+					PD:   &v1alpha1.PDSpec{},
+				},
+			}
+			tc.Name = "start-script-test"
+			tc.Namespace = "start-script-test-ns"
+			tt.modify(tc)
+
+			script, err := RenderTiKVStartScript(tc)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(TiKVRequiredEnvVars(tc)).Should(gomega.Equal(tt.envs))
+			g.Expect(unboundEnvVars(g, script)).Should(gomega.Equal(tt.envs))
+		})
+	}
+}
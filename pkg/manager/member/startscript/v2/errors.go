@@ -0,0 +1,46 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"errors"
+)
+
+// The kinds of errors returned by the render functions, callers can check them by errors.Is.
+var (
+	// ErrTemplateParse means that the template of the script fails to be parsed or executed.
+	ErrTemplateParse = errors.New("failed to render script template")
+	// ErrModelValidation means that the model built from TidbCluster is invalid.
+	ErrModelValidation = errors.New("invalid script model")
+	// ErrInvalidDataSubDir means that the data sub dir in the spec is not a relative path in the data volume.
+	ErrInvalidDataSubDir = errors.New("invalid data sub dir")
+)
+
+// renderError marks err as one of the kinds above, its message is the one of err.
+type renderError struct {
+	kind error
+	err  error
+}
+
+func newRenderError(kind, err error) error {
+	return &renderError{kind: kind, err: err}
+}
+
+func (e *renderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *renderError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestRenderTiKVStartScriptErrors(t *testing.T) {
+	kinds := []error{ErrTemplateParse, ErrModelValidation, ErrInvalidDataSubDir}
+
+	cases := []struct {
+		name     string
+		modifyTC func(tc *v1alpha1.TidbCluster)
+		kind     error
+		msg      string
+	}{
+		{
+			name: "invalid model",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "log/tikv.log"}
+			},
+			kind: ErrModelValidation,
+			msg:  "invalid TiKV start script model",
+		},
+		{
+			name: "absolute data sub dir",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "/data"
+			},
+			kind: ErrInvalidDataSubDir,
+			msg:  `data sub dir "/data" must be a relative path`,
+		},
+		{
+			name: "data sub dir out of the data volume",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "data/../../etc"
+			},
+			kind: ErrInvalidDataSubDir,
+			msg:  `data sub dir "data/../../etc" must not contain '..'`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tc := &v1alpha1.TidbCluster{
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			}
+			tc.Name = "start-script-test"
+			tc.Namespace = "start-script-test-ns"
+			c.modifyTC(tc)
+
+			_, err := RenderTiKVStartScript(tc)
+			g.Expect(err).Should(gomega.HaveOccurred())
+			g.Expect(err.Error()).Should(gomega.HavePrefix(c.msg))
+			for _, kind := range kinds {
+				g.Expect(errors.Is(err, kind)).Should(gomega.Equal(kind == c.kind), "error kind %v", kind)
+			}
+		})
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := parseTemplate("test", `{{ define "Sub" }}sub{{ end }}`, `{{ if .A }}`)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(errors.Is(err, ErrModelValidation)).Should(gomega.BeFalse())
+
+	tpl, err := parseTemplate("test", `{{ define "Sub" }}{{ .A }}{{ end }}`, `{{ template "Sub" . }}`)
+	g.Expect(err).Should(gomega.Succeed())
+	script, err := renderTemplateFunc(tpl, struct{ A string }{A: "a"})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal("a"))
+
+	_, err = renderTemplateFunc(tpl, struct{ B string }{})
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("can't evaluate field A"))
+}
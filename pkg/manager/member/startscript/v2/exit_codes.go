@@ -0,0 +1,106 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// The exit codes of start scripts v2, the start script exits with the code of the stage which fails, so that the
+// failure can be classified by the exit code in the last termination state of the container. The codes are part
+// of the contract with the users and must not be changed, the codes of signals (> 128) are never used.
+const (
+	// ExitCodeGeneric is the code of the failures which are not classified, e.g. the annotations file is missing
+	ExitCodeGeneric = 1
+	// ExitCodeDnsWaitTimeout is the code if the DNS name of the Pod is not resolved, or not resolved to the IP of
+	// the Pod with the WaitForDnsNameIpMatch feature flag, before the start timeout, it is only used with the
+	// ExitCodes feature flag
+	ExitCodeDnsWaitTimeout = 10
+	// ExitCodePDVerifyFailed is the code if the PD endpoints are not verified by discovery within the max retries
+	// when the cluster is deployed across k8s
+	ExitCodePDVerifyFailed = 11
+	// ExitCodeSidecarNotReady is the code if the sidecar is not ready before the timeout of SidecarReadiness
+	ExitCodeSidecarNotReady = 12
+	// ExitCodeStorageCheckFailed is the code if the checks of the data dir of TiKV fail before starting, e.g. the
+	// fsync check, the min free space or the api version recorded in the data dir
+	ExitCodeStorageCheckFailed = 13
+	// ExitCodeExecFailed is the code if the binary of the component can not be executed after all the attempts
+	ExitCodeExecFailed = 14
+)
+
+// exitCodeStages are the failure stages of the exit codes, the stages are referred by exitCode in templates.
+var exitCodeStages = map[string]int{
+	"Generic":            ExitCodeGeneric,
+	"DnsWaitTimeout":     ExitCodeDnsWaitTimeout,
+	"PDVerifyFailed":     ExitCodePDVerifyFailed,
+	"SidecarNotReady":    ExitCodeSidecarNotReady,
+	"StorageCheckFailed": ExitCodeStorageCheckFailed,
+	"ExecFailed":         ExitCodeExecFailed,
+}
+
+// exitCodeTrapLine is added after the shell options of the common script with the ExitCodes feature flag,
+// it prints the exit code of the start script to stderr if it is not 0.
+const exitCodeTrapLine = `trap 'exit_code=$?; [[ ${exit_code} -eq 0 ]] || echo "the start script exits with code ${exit_code}" >&2' EXIT` + "\n"
+
+// legacyExitCodeStages are the failure stages which exited with ExitCodeGeneric before the exit codes were
+// introduced, they still exit with it unless the ExitCodes feature flag is set, so that the start scripts of
+// the existing clusters are not changed.
+var legacyExitCodeStages = []string{"DnsWaitTimeout"}
+
+// builtinTemplateFuncs are the funcs available to all the templates of scripts, they can not be overridden by
+// RegisterTemplateFunc.
+var builtinTemplateFuncs = template.FuncMap{
+	"exitCode": exitCode,
+}
+
+// exitCode returns the exit code of the failure stage, e.g. {{ exitCode "DnsWaitTimeout" }}.
+func exitCode(stage string) (int, error) {
+	code, ok := exitCodeStages[stage]
+	if !ok {
+		return 0, fmt.Errorf("unknown failure stage %q of exit code", stage)
+	}
+	return code, nil
+}
+
+// ExitCodeStage returns the failure stage of the exit code of a start script, e.g. "DnsWaitTimeout", it returns
+// an empty string if the code is not one of the exit codes of start scripts, e.g. the one of the component itself.
+func ExitCodeStage(code int32) string {
+	for stage, c := range exitCodeStages {
+		if int32(c) == code {
+			return stage
+		}
+	}
+	return ""
+}
+
+// exitCodeFuncs returns the funcs overriding exitCode for the start scripts of tc, the legacy stages exit with
+// ExitCodeGeneric unless the ExitCodes feature flag is set.
+func exitCodeFuncs(tc *v1alpha1.TidbCluster) template.FuncMap {
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagExitCodes) {
+		return builtinTemplateFuncs
+	}
+	return template.FuncMap{
+		"exitCode": func(stage string) (int, error) {
+			code, err := exitCode(stage)
+			if err == nil && slices.Contains(legacyExitCodeStages, stage) {
+				return ExitCodeGeneric, nil
+			}
+			return code, err
+		},
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestExitCodes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the codes are distinct and are not the ones of signals
+	codes := map[int]string{}
+	for stage, code := range exitCodeStages {
+		g.Expect(codes).ShouldNot(gomega.HaveKey(code), "stage %s", stage)
+		g.Expect(code).Should(gomega.BeNumerically("<", 128), "stage %s", stage)
+		codes[code] = stage
+		g.Expect(ExitCodeStage(int32(code))).Should(gomega.Equal(stage))
+	}
+	g.Expect(ExitCodeStage(137)).Should(gomega.BeEmpty())
+
+	tpl, err := parseTemplate("test", `exit {{ exitCode "DnsWaitTimeout" }}`)
+	g.Expect(err).Should(gomega.Succeed())
+	script, err := renderTemplateFunc(tpl, nil)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal("exit 10"))
+
+	tpl, err = parseTemplate("test", `exit {{ exitCode "NoSuchStage" }}`)
+	g.Expect(err).Should(gomega.Succeed())
+	_, err = renderTemplateFunc(tpl, nil)
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring(`unknown failure stage "NoSuchStage"`))
+
+	g.Expect(RegisterTemplateFunc("exitCode", func() int { return 0 })).Should(gomega.MatchError(`template func "exitCode" is a builtin one`))
+}
+
+func TestRenderStartScriptsWithExitCodes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"pump":    RenderPumpStartScript,
+		"ticdc":   RenderTiCDCStartScript,
+		"tiproxy": RenderTiProxyStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	trap := "\nset -uo pipefail\ntrap 'exit_code=$?; [[ ${exit_code} -eq 0 ]] || echo \"the start script exits with code ${exit_code}\" >&2' EXIT\n"
+	for component, render := range renders {
+		tc := newAllComponentsTidbCluster()
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("trap"), "component %s", component)
+
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagExitCodes}
+		script, err = render(tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring(trap), "component %s", component)
+	}
+
+	// the timeout of waiting for DNS exits with the generic code without the ExitCodes feature flag
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch}
+	for _, component := range []string{"pd", "tikv", "tidb", "tiflash"} {
+		script, err := renders[component](tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring("waiting for cluster ready timeout\" >&2\n        exit 1\n"), "component %s", component)
+	}
+	tc.Spec.StartScriptV2FeatureFlags = append(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagExitCodes)
+	for _, component := range []string{"pd", "tikv", "tidb", "tiflash"} {
+		script, err := renders[component](tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring("waiting for cluster ready timeout\" >&2\n        exit 10\n"), "component %s", component)
+	}
+
+	// the verification of PD endpoints across k8s
+	tc = newAllComponentsTidbCluster()
+	tc.Spec.AcrossK8s = true
+	tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{MaxRetries: 3}
+	for component, render := range renders {
+		// the PD endpoints of TiFlash are verified by its init script
+		if component == "pd" || component == "tiflash" {
+			continue
+		}
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed(), "component %s", component)
+		g.Expect(script).Should(gomega.ContainSubstring("failed to verify PD endpoints after 3 retries\" >&2\n        exit 11\n"), "component %s", component)
+	}
+	script, err := RenderTiFlashInitScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("failed to verify PD endpoints after 3 retries\" >&2\n        exit 11\n"))
+
+	// the failure of exec
+	tc = newAllComponentsTidbCluster()
+	tc.Spec.TiKV.Annotations = map[string]string{label.AnnTiKVExecAttempts: "3"}
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.HaveSuffix("exit 14\n"))
+}
+
+func TestExitCodeTrap(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the interpreter of mvdan.cc/sh does not set $? to the exit status in the EXIT trap, so bash is used
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not found")
+	}
+
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagExitCodes}
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	header := script[:strings.Index(script, "\nANNOTATIONS=")]
+
+	cases := []struct {
+		name   string
+		body   string
+		code   int
+		stderr string
+	}{
+		{name: "success", body: "exit 0", code: 0, stderr: ""},
+		{name: "dns wait timeout", body: "exit 10", code: ExitCodeDnsWaitTimeout, stderr: "the start script exits with code 10\n"},
+		{name: "failed command in strict mode", body: "set -e\nfalse", code: ExitCodeGeneric, stderr: "the start script exits with code 1\n"},
+	}
+	for _, c := range cases {
+		var stderr bytes.Buffer
+		cmd := exec.Command(bash, "-c", header+"\n"+c.body+"\n")
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if c.code == 0 {
+			g.Expect(err).Should(gomega.Succeed(), c.name)
+		} else {
+			exitErr := &exec.ExitError{}
+			g.Expect(errors.As(err, &exitErr)).Should(gomega.BeTrue(), c.name)
+			g.Expect(exitErr.ExitCode()).Should(gomega.Equal(c.code), c.name)
+		}
+		g.Expect(stderr.String()).Should(gomega.Equal(c.stderr), c.name)
+	}
+}
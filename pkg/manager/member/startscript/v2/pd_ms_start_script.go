@@ -0,0 +1,172 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+const (
+	// PDMSTSO is the name of PD TSO microservice
+	PDMSTSO = "tso"
+	// PDMSScheduling is the name of PD scheduling microservice
+	PDMSScheduling = "scheduling"
+)
+
+// PDMSStartScriptModel contain fields for rendering PD microservice start script
+type PDMSStartScriptModel struct {
+	PDMSName            string
+	PDMSDomain          string
+	ListenAddr          string
+	AdvertiseListenAddr string
+	BackendEndpoints    string
+	PDStartTimeout      int
+	DnsWaitInterval     int
+	DnsWaitResolver     string
+	NsLookupCmd         string
+
+	AcrossK8s *AcrossK8sScriptModel
+}
+
+// Validate checks the fields required by PD microservice start script
+func (m *PDMSStartScriptModel) Validate() error {
+	return validateModel("PD microservice start",
+		validateRequired("PDMSName", m.PDMSName),
+		validateRequired("PDMSDomain", m.PDMSDomain),
+		validateURL("ListenAddr", m.ListenAddr),
+		validateURL("AdvertiseListenAddr", m.AdvertiseListenAddr),
+		validateURLs("BackendEndpoints", m.BackendEndpoints),
+		validateResolver("DnsWaitResolver", m.DnsWaitResolver),
+		m.AcrossK8s.Validate(),
+	)
+}
+
+// RenderPDTSOStartScript renders PD TSO microservice start script from TidbCluster
+func RenderPDTSOStartScript(tc *v1alpha1.TidbCluster) (string, error) {
+	return renderPDMSStartScript(tc, PDMSTSO)
+}
+
+// RenderPDSchedulingStartScript renders PD scheduling microservice start script from TidbCluster
+func RenderPDSchedulingStartScript(tc *v1alpha1.TidbCluster) (string, error) {
+	return renderPDMSStartScript(tc, PDMSScheduling)
+}
+
+func renderPDMSStartScript(tc *v1alpha1.TidbCluster, name string) (string, error) {
+	m := &PDMSStartScriptModel{}
+	tcName := tc.Name
+	tcNS := tc.Namespace
+	peerServiceName := controller.PDMSPeerMemberName(tcName, name)
+
+	m.PDMSName = name
+
+	m.PDMSDomain = fmt.Sprintf("${PDMS_POD_NAME}.%s.%s.svc", peerServiceName, tcNS)
+	if tc.Spec.ClusterDomain != "" {
+		m.PDMSDomain = m.PDMSDomain + "." + tc.Spec.ClusterDomain
+	}
+
+	m.ListenAddr = fmt.Sprintf("%s://%s", tc.Scheme(), formatListenAddr("", v1alpha1.DefaultPDClientPort, listenOnIPv6(tc, false)))
+
+	m.AdvertiseListenAddr = fmt.Sprintf("%s://${PDMS_DOMAIN}:%d", tc.Scheme(), v1alpha1.DefaultPDClientPort)
+
+	m.BackendEndpoints, m.AcrossK8s = pdmsBackendEndpoints(tc)
+
+	m.PDStartTimeout = tc.PDStartTimeout()
+	m.DnsWaitInterval = int(tc.Spec.DnsWaitIntervalSeconds)
+	m.DnsWaitResolver = tc.Spec.DnsWaitResolver
+	m.NsLookupCmd = nsLookupCmd(tc, m.DnsWaitResolver)
+
+	waitForDnsNameIpMatchOnStartup := slices.Contains(
+		tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch)
+	skipDnsWaitOnStartup := skipDnsWait(tc)
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	pdmsStartScriptTpl, err := parseStartScriptTemplate(tc, "pdms-start-script", pdmsStartSubScript,
+		commonScript(tc, "/etc/pd")+
+			replaceDnsLookupForStrictMode(tc, replaceDnsWaitThresholdUnit(tc, replaceDnsWaitSleep(tc, replacePDMSStartScriptDnsAwaitPart(pdmsStartScript, waitForDnsNameIpMatchOnStartup, skipDnsWaitOnStartup)))))
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(pdmsStartScriptTpl, m)
+}
+
+// pdmsBackendEndpoints returns the client urls of PD API service, they are resolved in the same way
+// as the PD addresses of TiKV.
+func pdmsBackendEndpoints(tc *v1alpha1.TidbCluster) (string, *AcrossK8sScriptModel) {
+	if tc.AcrossK8s() {
+		acrossK8s := newAcrossK8sScriptModel(tc)
+		return "${result}", acrossK8s // get pd addr in subscript
+	}
+
+	pdAddr, _ := tikvPDAddr(tc)
+	addrs := strings.Split(pdAddr, ",")
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("%s://%s", tc.Scheme(), addrs[i])
+	}
+	return strings.Join(addrs, ","), nil
+}
+
+func replacePDMSStartScriptDnsAwaitPart(startScript string, withLocalIpMatch, skipDnsWait bool) string {
+	if withLocalIpMatch && !skipDnsWait {
+		return strings.ReplaceAll(startScript, dnsAwaitPart, pdmsWaitForDnsIpMatchSubScript)
+	}
+	return strings.ReplaceAll(startScript, dnsAwaitPart, "")
+}
+
+const (
+	// pdmsStartSubScript contains optional subscripts used in start script.
+	pdmsStartSubScript = `
+{{ define "AcrossK8sSubscript" }}
+pd_url={{ .AcrossK8s.PDAddr }}
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
+discovery_url={{ .AcrossK8s.DiscoveryAddr }}
+until result=$(` + acrossK8sVerifySubScript + `); do` + acrossK8sMaxRetriesSubScript + `
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
+{{- end }}
+`
+
+	pdmsWaitForDnsIpMatchSubScript = `
+componentDomain=${PDMS_DOMAIN}
+waitThreshold={{ .PDStartTimeout }}
+nsLookupCmd="{{ .NsLookupCmd }}"
+` + componentCommonWaitForDnsIpMatchScript
+
+	// pdmsStartScript is the template of start script.
+	pdmsStartScript = `
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN={{ .PDMSDomain }}` +
+		dnsAwaitPart + `
+{{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
+
+ARGS="services {{ .PDMSName }} \
+--listen-addr={{ .ListenAddr }} \
+--advertise-listen-addr={{ .AdvertiseListenAddr }} \
+--backend-endpoints={{ .BackendEndpoints }} \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server {{ .PDMSName }} ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`
+)
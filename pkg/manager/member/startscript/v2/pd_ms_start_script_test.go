@@ -0,0 +1,569 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestRenderPDMSStartScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name      string
+		component string
+
+		modifyTC     func(tc *v1alpha1.TidbCluster)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name:      "basic tso",
+			component: PDMSTSO,
+			modifyTC:  func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+
+ARGS="services tso \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "basic scheduling",
+			component: PDMSScheduling,
+			modifyTC:  func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-scheduling-peer.start-script-test-ns.svc
+
+ARGS="services scheduling \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server scheduling ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "set cluster domain",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.ClusterDomain = "cluster.local"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc.cluster.local
+
+ARGS="services tso \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "enable tls",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+
+ARGS="services tso \
+--listen-addr=https://0.0.0.0:2379 \
+--advertise-listen-addr=https://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=https://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "multiple pd addresses",
+			component: PDMSScheduling,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD.Replicas = 3
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-scheduling-peer.start-script-test-ns.svc
+
+ARGS="services scheduling \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379,http://start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc:2379,http://start-script-test-pd-2.start-script-test-pd-peer.start-script-test-ns.svc:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server scheduling ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "heterogeneous cluster",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+
+ARGS="services tso \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://target-cluster-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "across k8s",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="services tso \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=${result} \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "across k8s with cluster domain",
+			component: PDMSScheduling,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.ClusterDomain = "cluster.local"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-scheduling-peer.start-script-test-ns.svc.cluster.local
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="services scheduling \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=${result} \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server scheduling ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "wait for dns name ip match",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+componentDomain=${PDMS_DOMAIN}
+waitThreshold=30
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="services tso \
+--listen-addr=http://0.0.0.0:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+		{
+			name:      "dual stack",
+			component: PDMSTSO,
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PDMS_POD_NAME=${POD_NAME:-$HOSTNAME}
+PDMS_DOMAIN=${PDMS_POD_NAME}.start-script-test-tso-peer.start-script-test-ns.svc
+
+ARGS="services tso \
+--listen-addr=http://[::]:2379 \
+--advertise-listen-addr=http://${PDMS_DOMAIN}:2379 \
+--backend-endpoints=http://start-script-test-pd:2379 \
+--config=/etc/pd/pd.toml"
+
+echo "starting pd-server tso ..."
+echo "/pd-server ${ARGS}"
+exec /pd-server ${ARGS}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD: &v1alpha1.PDSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		var script string
+		var err error
+		switch c.component {
+		case PDMSTSO:
+			script, err = RenderPDTSOStartScript(tc)
+		case PDMSScheduling:
+			script, err = RenderPDSchedulingStartScript(tc)
+		}
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestRenderPDMSStartScriptDnsWait(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		flags  []v1alpha1.StartScriptV2FeatureFlag
+		expect string
+	}{
+		{
+			flags:  []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig},
+			expect: `nsLookupCmd="dig ${componentDomain} A ${componentDomain} AAAA +search +short"`,
+		},
+		{
+			flags:  []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup},
+			expect: `nsLookupCmd="nslookup $componentDomain 2>/dev/null | awk`,
+		},
+		{
+			// the DNS names are not used in localhost mode
+			flags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagLocalhost},
+		},
+	}
+	for _, c := range cases {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:                        &v1alpha1.PDSpec{},
+				StartScriptV2FeatureFlags: c.flags,
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := RenderPDTSOStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		if c.expect == "" {
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("nsLookupCmd"), "flags %v", c.flags)
+			continue
+		}
+		g.Expect(script).Should(gomega.ContainSubstring(c.expect), "flags %v", c.flags)
+	}
+}
@@ -18,7 +18,6 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
-	"text/template"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
@@ -37,6 +36,31 @@ type PDStartScriptModel struct {
 	DiscoveryAddr      string
 	ExtraArgs          string
 	PDStartTimeout     int
+	DnsWaitInterval    int
+	DnsWaitResolver    string
+
+	// InitialCluster is passed to PD on bootstrap instead of the args returned by discovery if it is set
+	InitialCluster string
+	// Base64Fallback is set if the domain of PD is encoded by base64FallbackEncodeCmd for discovery.
+	Base64Fallback bool
+
+	ResolvedSummary *ResolvedSummary
+}
+
+// Validate checks the fields required by PD start script
+func (m *PDStartScriptModel) Validate() error {
+	return validateModel("PD start",
+		validateRequired("PDDomain", m.PDDomain),
+		validateRequired("PDName", m.PDName),
+		validateRequired("DataDir", m.DataDir),
+		validateURL("PeerURL", m.PeerURL),
+		validateURL("AdvertisePeerURL", m.AdvertisePeerURL),
+		validateURL("ClientURL", m.ClientURL),
+		validateURL("AdvertiseClientURL", m.AdvertiseClientURL),
+		validateAddr("DiscoveryAddr", m.DiscoveryAddr),
+		validateResolver("DnsWaitResolver", m.DnsWaitResolver),
+		m.ResolvedSummary.Validate(),
+	)
 }
 
 // RenderPDStartScript renders PD start script from TidbCluster
@@ -50,6 +74,9 @@ func RenderPDStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	if tc.Spec.ClusterDomain != "" {
 		m.PDDomain = m.PDDomain + "." + tc.Spec.ClusterDomain
 	}
+	if useLocalhost(tc) {
+		m.PDDomain = localhostHost
+	}
 
 	m.PDName = "${PD_POD_NAME}"
 	if tc.AcrossK8s() || tc.Spec.ClusterDomain != "" {
@@ -58,29 +85,53 @@ func RenderPDStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 
 	m.DataDir = filepath.Join(constants.PDDataVolumeMountPath, tc.Spec.PD.DataSubDir)
 
-	m.PeerURL = fmt.Sprintf("%s://0.0.0.0:%d", tc.Scheme(), v1alpha1.DefaultPDPeerPort)
+	// PD listens on IPv4 wildcard even if PreferIPv6 is set, keep it for compatibility
+	listenIPv6 := listenOnIPv6(tc, false)
+
+	m.PeerURL = fmt.Sprintf("%s://%s", tc.Scheme(), formatListenAddr("", v1alpha1.DefaultPDPeerPort, listenIPv6))
 
 	m.AdvertisePeerURL = fmt.Sprintf("%s://${PD_DOMAIN}:%d", tc.Scheme(), v1alpha1.DefaultPDPeerPort)
 
-	m.ClientURL = fmt.Sprintf("%s://0.0.0.0:%d", tc.Scheme(), v1alpha1.DefaultPDClientPort)
+	m.ClientURL = fmt.Sprintf("%s://%s", tc.Scheme(), formatListenAddr("", v1alpha1.DefaultPDClientPort, listenIPv6))
 
 	m.AdvertiseClientURL = fmt.Sprintf("%s://${PD_DOMAIN}:%d", tc.Scheme(), v1alpha1.DefaultPDClientPort)
 
-	m.DiscoveryAddr = fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS)
+	m.DiscoveryAddr = discoveryAddr(tc)
+	m.Base64Fallback = slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagBase64Fallback)
+	if useLocalhost(tc) {
+		// the single PD bootstraps by itself, discovery can not look up the Pod by the localhost address
+		m.InitialCluster = fmt.Sprintf("%s=%s", m.PDName, m.AdvertisePeerURL)
+	}
 
 	m.PDStartTimeout = tc.PDStartTimeout()
+	m.DnsWaitInterval = int(tc.Spec.DnsWaitIntervalSeconds)
+	m.DnsWaitResolver = tc.Spec.DnsWaitResolver
+
+	m.ResolvedSummary = newResolvedSummary(tc, ResolvedSummary{
+		Component:     v1alpha1.PDMemberType.String(),
+		AdvertiseAddr: m.AdvertiseClientURL,
+		DataDir:       m.DataDir,
+		Ports: []ResolvedSummaryPort{
+			{Name: "client", Port: v1alpha1.DefaultPDClientPort},
+			{Name: "peer", Port: v1alpha1.DefaultPDPeerPort},
+		},
+	})
 
 	waitForDnsNameIpMatchOnStartup := slices.Contains(
 		tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch)
+	skipDnsWaitOnStartup := skipDnsWait(tc)
 
-	pdStartScriptTpl := template.Must(
-		template.Must(
-			template.New("pd-start-script").Parse(pdStartSubScript),
-		).Parse(
-			componentCommonScript +
-				replacePdStartScriptCustomPorts(
-					replacePdStartScriptDnsAwaitPart(pdStartScript, waitForDnsNameIpMatchOnStartup))),
-	)
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	pdStartScriptTpl, err := parseStartScriptTemplate(tc, "pd-start-script", pdStartSubScript,
+		goCommonScript(tc, "/etc/pd")+
+			replacePdStartScriptCustomPorts(
+				replaceDnsLookupForStrictMode(tc, replaceDnsWaitThresholdUnit(tc, replaceDnsWaitSleep(tc, replacePdStartScriptDnsAwaitPart(pdStartScript, waitForDnsNameIpMatchOnStartup, skipDnsWaitOnStartup))))))
+	if err != nil {
+		return "", err
+	}
 
 	return renderTemplateFunc(pdStartScriptTpl, m)
 }
@@ -92,7 +143,7 @@ const (
 	pdWaitForDnsIpMatchSubScript = `
 componentDomain=${PD_DOMAIN}
 waitThreshold={{ .PDStartTimeout }}
-nsLookupCmd="dig ${componentDomain} A ${componentDomain} AAAA +search +short"
+nsLookupCmd="dig {{ with .DnsWaitResolver }}@{{ . }} {{ end }}${componentDomain} A ${componentDomain} AAAA +search +short"
 ` + componentCommonWaitForDnsIpMatchScript
 
 	pdWaitForDnsOnlySubScript = `
@@ -106,10 +157,10 @@ while true; do
 
     if [[ ${elapseTime} -ge ${threshold} ]]; then
         echo "waiting for pd cluster ready timeout" >&2
-        exit 1
+        exit {{ exitCode "DnsWaitTimeout" }}
     fi
 
-    digRes=$(dig ${PD_DOMAIN} A ${PD_DOMAIN} AAAA +search +short)
+    digRes=$(dig {{ with .DnsWaitResolver }}@{{ . }} {{ end }}${PD_DOMAIN} A ${PD_DOMAIN} AAAA +search +short)
     if [ $? -ne 0  ]; then
         echo "domain resolve ${PD_DOMAIN} failed"
         echo "$digRes"
@@ -148,15 +199,19 @@ if [[ -f {{ .DataDir }}/join ]]; then
     join=${join%,}
     ARGS="${ARGS} --join=${join}"
 elif [[ ! -d {{ .DataDir }}/member/wal ]]; then
-    encoded_domain_url=$(echo ${PD_DOMAIN}:2380 | base64 | tr "\n" " " | sed "s/ //g")
+{{- if .InitialCluster }}
+    ARGS="${ARGS} --initial-cluster={{ .InitialCluster }}"
+{{- else }}
+    encoded_domain_url=$(echo ${PD_DOMAIN}:2380 | {{ if .Base64Fallback }}` + base64FallbackEncodeCmd + `{{ else }}` + base64EncodeCmd + `{{ end }})
 
     until result=$(wget -qO- -T 3 http://{{ .DiscoveryAddr }}/new/${encoded_domain_url} 2>/dev/null); do
         echo "waiting for discovery service to return start args ..."
         sleep $((RANDOM % 5))
     done
     ARGS="${ARGS} ${result}"
+{{- end }}
 fi
-
+` + resolvedSummarySubScript + `
 echo "starting pd-server ..."
 sleep $((RANDOM % 10))
 echo "/pd-server ${ARGS}"
@@ -172,7 +227,10 @@ func replacePdStartScriptCustomPorts(startScript string) string {
 	return startScript
 }
 
-func replacePdStartScriptDnsAwaitPart(startScript string, withLocalIpMatch bool) string {
+func replacePdStartScriptDnsAwaitPart(startScript string, withLocalIpMatch, skipDnsWait bool) string {
+	if skipDnsWait {
+		return strings.ReplaceAll(startScript, dnsAwaitPart, "")
+	}
 	if withLocalIpMatch {
 		return strings.ReplaceAll(startScript, dnsAwaitPart, pdWaitForDnsIpMatchSubScript)
 	} else {
@@ -15,66 +15,94 @@ package v2
 
 import (
 	"fmt"
-	"text/template"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 )
 
+const (
+	// pumpDataDir is the mount path of the Pump data volume
+	pumpDataDir = "/data"
+)
+
 // PumpStartScriptModel contain fields for rendering Pump start script
 type PumpStartScriptModel struct {
 	PDAddr        string
 	LogLevel      string
 	AdvertiseAddr string
+	DataDir       string
 	ExtraArgs     string
 
+	// Addr is the listen address of Pump, it is only set when IPv6 is preferred,
+	// otherwise the one in the config file is used.
+	Addr string
+
 	AcrossK8s *AcrossK8sScriptModel
 }
 
+// Validate checks the fields required by Pump start script
+func (m *PumpStartScriptModel) Validate() error {
+	return validateModel("Pump start",
+		validateURL("PDAddr", m.PDAddr),
+		validateAddr("AdvertiseAddr", m.AdvertiseAddr),
+		validateRequired("DataDir", m.DataDir),
+		m.AcrossK8s.Validate(),
+	)
+}
+
 // RenderPumpStartScript renders Pump start script from TidbCluster
 func RenderPumpStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &PumpStartScriptModel{}
-	tcName := tc.Name
-	tcNS := tc.Namespace
-	peerServiceName := controller.PumpPeerMemberName(tcName)
 
-	m.PDAddr = fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort)
-	if tc.AcrossK8s() {
-		m.AcrossK8s = &AcrossK8sScriptModel{
-			PDAddr:        fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort),
-			DiscoveryAddr: fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS),
-		}
-		m.PDAddr = "${result}" // get pd addr in subscript
-	} else if tc.Heterogeneous() && tc.WithoutLocalPD() {
-		m.PDAddr = fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tc.Spec.Cluster.Name), v1alpha1.DefaultPDClientPort) // use pd of reference cluster
-	}
+	m.PDAddr, m.AcrossK8s = binlogPDAddr(tc)
 
 	m.LogLevel = tc.PumpLogLevel()
 
-	advertiseAddr := fmt.Sprintf("${PUMP_POD_NAME}.%s", peerServiceName)
-	if tc.Spec.ClusterDomain != "" {
-		advertiseAddr = advertiseAddr + fmt.Sprintf(".%s.svc.%s", tcNS, tc.Spec.ClusterDomain)
-	} else if tc.Spec.ClusterDomain == "" && tc.AcrossK8s() {
-		advertiseAddr = advertiseAddr + fmt.Sprintf(".%s.svc", tcNS)
+	m.AdvertiseAddr = PumpAdvertiseAddr(tc)
+
+	m.DataDir = pumpDataDir
+
+	if preferIPv6ListenHost(tc) {
+		m.Addr = formatListenAddr("", v1alpha1.DefaultPumpPort, true)
 	}
-	m.AdvertiseAddr = fmt.Sprintf("%s:%d", advertiseAddr, v1alpha1.DefaultPumpPort)
 
 	m.ExtraArgs = ""
 
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	pumpStartScriptTpl, err := parseStartScriptTemplate(tc, "pump-start-script", pumpStartSubScript,
+		commonScript(tc, "/etc/pump")+pumpStartScript)
+	if err != nil {
+		return "", err
+	}
+
 	return renderTemplateFunc(pumpStartScriptTpl, m)
 }
 
+// binlogPDAddr returns the PD address passed to Pump and Drainer by -pd-urls
+func binlogPDAddr(tc *v1alpha1.TidbCluster) (string, *AcrossK8sScriptModel) {
+	if tc.AcrossK8s() {
+		return "${result}", newAcrossK8sScriptModel(tc) // get pd addr in subscript
+	}
+	if tc.Heterogeneous() && tc.WithoutLocalPD() {
+		return fmt.Sprintf("%s://%s", tc.Scheme(), referencePDAddr(tc)), nil // use pd of reference cluster
+	}
+	return fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tc.Name), v1alpha1.DefaultPDClientPort), nil
+}
+
 const (
 	// pumpStartSubScript contains optional subscripts used in start script.
 	pumpStartSubScript = `
 {{ define "AcrossK8sSubscript" }}
 pd_url={{ .AcrossK8s.PDAddr }}
-encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
 discovery_url={{ .AcrossK8s.DiscoveryAddr }}
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+until result=$(` + acrossK8sVerifySubScript + `); do` + acrossK8sMaxRetriesSubScript + `
     echo "waiting for the verification of PD endpoints ..."
-    sleep $((RANDOM % 5))
-done
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
 {{- end}}
 `
 
@@ -87,8 +115,11 @@ ARGS="-pd-urls={{ .PDAddr }} \
 -L {{ .LogLevel }} \
 -log-file= \
 -advertise-addr={{ .AdvertiseAddr }} \
--data-dir=/data \
+-data-dir={{ .DataDir }} \
 --config=/etc/pump/pump.toml"
+{{- if .Addr }}
+ARGS="${ARGS} -addr={{ .Addr }}"
+{{- end }}
 {{- if .ExtraArgs }}
 ARGS="${ARGS} {{ .ExtraArgs }}"
 {{- end }}
@@ -103,9 +134,3 @@ if [ $? == 0 ]; then
 fi
 `
 )
-
-var pumpStartScriptTpl = template.Must(
-	template.Must(
-		template.New("pump-start-script").Parse(pumpStartSubScript),
-	).Parse(componentCommonScript + pumpStartScript),
-)
@@ -335,6 +335,147 @@ then
     tail -f /dev/null
 fi
 
+PUMP_POD_NAME=$HOSTNAME
+pd_url=http://target-cluster-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=target-cluster-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="-pd-urls=${result} \
+-L info \
+-log-file= \
+-advertise-addr=${PUMP_POD_NAME}.start-script-test-pump.start-script-test-ns.svc:8250 \
+-data-dir=/data \
+--config=/etc/pump/pump.toml"
+
+echo "start pump-server ..."
+echo "/pump ${ARGS}"
+exec /pump ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "pump offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "prefer ipv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PUMP_POD_NAME=$HOSTNAME
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-advertise-addr=${PUMP_POD_NAME}.start-script-test-pump:8250 \
+-data-dir=/data \
+--config=/etc/pump/pump.toml"
+
+echo "start pump-server ..."
+echo "/pump ${ARGS}"
+exec /pump ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "pump offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "prefer ipv6 listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPreferIPv6ListenHost}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+PUMP_POD_NAME=$HOSTNAME
+
+ARGS="-pd-urls=http://start-script-test-pd:2379 \
+-L info \
+-log-file= \
+-advertise-addr=${PUMP_POD_NAME}.start-script-test-pump:8250 \
+-data-dir=/data \
+--config=/etc/pump/pump.toml"
+ARGS="${ARGS} -addr=[::]:8250"
+
+echo "start pump-server ..."
+echo "/pump ${ARGS}"
+exec /pump ${ARGS}
+
+if [ $? == 0 ]; then
+    echo $(date -u +"[%Y/%m/%d %H:%M:%S.%3N %:z]") "pump offline, please delete my pod"
+    tail -f /dev/null
+fi
+`,
+		},
+		{
+			name: "prefer ipv6 listen host across k8s with setting cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPreferIPv6ListenHost}
+				tc.Spec.AcrossK8s = true
+				tc.Spec.ClusterDomain = "demo.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
 PUMP_POD_NAME=$HOSTNAME
 pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
@@ -347,9 +488,10 @@ done
 ARGS="-pd-urls=${result} \
 -L info \
 -log-file= \
--advertise-addr=${PUMP_POD_NAME}.start-script-test-pump.start-script-test-ns.svc:8250 \
+-advertise-addr=${PUMP_POD_NAME}.start-script-test-pump.start-script-test-ns.svc.demo.com:8250 \
 -data-dir=/data \
 --config=/etc/pump/pump.toml"
+ARGS="${ARGS} -addr=[::]:8250"
 
 echo "start pump-server ..."
 echo "/pump ${ARGS}"
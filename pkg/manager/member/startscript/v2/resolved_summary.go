@@ -0,0 +1,89 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// resolvedSummaryFile is the file which the start scripts write the summary of the resolved addresses to with
+// the ResolvedSummary feature flag, it is in /tmp as the root filesystem may be read-only.
+const resolvedSummaryFile = "/tmp/start-script-summary.json"
+
+// ResolvedSummary contains the fields written as JSON to resolvedSummaryFile before the component is started,
+// the addresses may refer to the variables resolved by the start script, e.g. ${TIKV_POD_NAME}.
+type ResolvedSummary struct {
+	Component     string
+	PDAddr        string
+	AdvertiseAddr string
+	DataDir       string
+	// Ports are the ports listened by the component keyed by the names, e.g. "server" and "status".
+	Ports []ResolvedSummaryPort
+}
+
+// ResolvedSummaryPort is a named port in ResolvedSummary
+type ResolvedSummaryPort struct {
+	Name string
+	Port int32
+}
+
+// Validate checks the fields required by the summary, it is valid to be nil.
+func (s *ResolvedSummary) Validate() error {
+	if s == nil {
+		return nil
+	}
+	errs := []error{
+		validateRequired("Component", s.Component),
+		validateRequired("AdvertiseAddr", s.AdvertiseAddr),
+	}
+	for _, p := range s.Ports {
+		errs = append(errs, validatePort(fmt.Sprintf("port %s", p.Name), p.Port))
+	}
+	return validateModel("resolved summary", errs...)
+}
+
+// newResolvedSummary returns the summary of the component if the ResolvedSummary feature flag is set,
+// otherwise it returns nil.
+func newResolvedSummary(tc *v1alpha1.TidbCluster, summary ResolvedSummary) *ResolvedSummary {
+	if !slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagResolvedSummary) {
+		return nil
+	}
+	return &summary
+}
+
+// resolvedSummarySubScript writes the summary of the model to resolvedSummaryFile, the strings are escaped
+// at runtime as they may contain the variables expanded by the shell. A failure to write it does not stop
+// the component from starting.
+const resolvedSummarySubScript = `
+{{- if .ResolvedSummary }}
+
+json_string() {
+    printf '"%s"' "$(printf '%s' "$1" | sed 's/[\\"]/\\&/g')"
+}
+{
+    printf '{"component":%s' "$(json_string "{{ .ResolvedSummary.Component }}")"
+{{- with .ResolvedSummary.PDAddr }}
+    printf ',"pdAddr":%s' "$(json_string "{{ . }}")"
+{{- end }}
+    printf ',"advertiseAddr":%s' "$(json_string "{{ .ResolvedSummary.AdvertiseAddr }}")"
+{{- with .ResolvedSummary.DataDir }}
+    printf ',"dataDir":%s' "$(json_string "{{ . }}")"
+{{- end }}
+    printf ',"ports":{{ "{" }}{{ range $i, $p := .ResolvedSummary.Ports }}{{ if $i }},{{ end }}"{{ $p.Name }}":{{ $p.Port }}{{ end }}}}\n'
+} > ` + resolvedSummaryFile + `.$$ && mv ` + resolvedSummaryFile + `.$$ ` + resolvedSummaryFile + ` || echo "failed to write the resolved summary to ` + resolvedSummaryFile + `"
+{{- end }}
+`
@@ -0,0 +1,115 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// runResolvedSummary runs the part of the script which writes the resolved summary with the env,
+// and returns the written JSON.
+func runResolvedSummary(g *gomega.WithT, t *testing.T, script string, env ...string) map[string]interface{} {
+	begin := strings.Index(script, "\njson_string() {")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+	end := strings.Index(script[begin:], `"failed to write the resolved summary to /tmp/start-script-summary.json"`)
+	g.Expect(end).Should(gomega.BeNumerically(">", 0))
+	summaryFile := filepath.Join(t.TempDir(), "summary.json")
+	fragment := strings.ReplaceAll(script[begin:begin+end], "/tmp/start-script-summary.json", summaryFile) + "true\n"
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+	g.Expect(err).Should(gomega.Succeed())
+	runner, err := interp.New(interp.Env(expand.ListEnviron(append(env, "PATH="+os.Getenv("PATH"))...)),
+		interp.StdIO(nil, io.Discard, io.Discard))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+	data, err := os.ReadFile(summaryFile)
+	g.Expect(err).Should(gomega.Succeed())
+	summary := map[string]interface{}{}
+	g.Expect(json.Unmarshal(data, &summary)).Should(gomega.Succeed(), string(data))
+	return summary
+}
+
+func TestResolvedSummary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := newAllComponentsTidbCluster()
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagResolvedSummary}
+
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(strings.Index(script, "/tmp/start-script-summary.json")).Should(gomega.BeNumerically("<", strings.Index(script, "exec /tikv-server")))
+	g.Expect(runResolvedSummary(g, t, script, "TIKV_POD_NAME=start-script-test-tikv-0")).Should(gomega.Equal(map[string]interface{}{
+		"component":     "tikv",
+		"pdAddr":        "start-script-test-pd:2379",
+		"advertiseAddr": "start-script-test-tikv-0.start-script-test-tikv-peer.start-script-test-ns.svc:20160",
+		"dataDir":       "/var/lib/tikv",
+		"ports":         map[string]interface{}{"server": float64(20160), "status": float64(20180)},
+	}))
+
+	script, err = RenderPDStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(runResolvedSummary(g, t, script, "PD_DOMAIN=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc")).Should(gomega.Equal(map[string]interface{}{
+		"component":     "pd",
+		"advertiseAddr": "http://start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379",
+		"dataDir":       "/var/lib/pd",
+		"ports":         map[string]interface{}{"client": float64(2379), "peer": float64(2380)},
+	}))
+
+	script, err = RenderTiDBStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(runResolvedSummary(g, t, script, "TIDB_POD_NAME=start-script-test-tidb-0")).Should(gomega.Equal(map[string]interface{}{
+		"component":     "tidb",
+		"pdAddr":        "start-script-test-pd:2379",
+		"advertiseAddr": "start-script-test-tidb-0.start-script-test-tidb-peer.start-script-test-ns.svc:4000",
+		"ports":         map[string]interface{}{"server": float64(4000), "status": float64(10080)},
+	}))
+
+	// the values expanded at runtime are escaped
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	summary := runResolvedSummary(g, t, script, `TIKV_POD_NAME=a"b\c`)
+	g.Expect(summary["advertiseAddr"]).Should(gomega.Equal(`a"b\c.start-script-test-tikv-peer.start-script-test-ns.svc:20160`))
+
+	// the status port is not listened if the status server is disabled
+	tc.Spec.TiKV.DisableStatusServer = true
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	summary = runResolvedSummary(g, t, script, "TIKV_POD_NAME=start-script-test-tikv-0")
+	g.Expect(summary["ports"]).Should(gomega.Equal(map[string]interface{}{"server": float64(20160)}))
+
+	// no summary is written without the feature flag
+	tc = newAllComponentsTidbCluster()
+	for _, render := range []func(tc *v1alpha1.TidbCluster) (string, error){RenderPDStartScript, RenderTiKVStartScript, RenderTiDBStartScript} {
+		script, err := render(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("start-script-summary.json"))
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"text/template"
+)
+
+var (
+	templateFuncsMu sync.RWMutex
+	// templateFuncs are the custom funcs registered by RegisterTemplateFunc
+	templateFuncs = template.FuncMap{}
+)
+
+// RegisterTemplateFunc registers fn as the template func named name, so that extensions of the start scripts
+// can use it in templates without forking the package. The func must follow the rules of text/template, i.e. it
+// returns one value, or two values where the second one is an error.
+//
+// It is expected to be called in init, and it returns an error if the name is already registered, including the
+// builtin funcs, e.g. exitCode, or fn is not a func.
+// The funcs are bound when the templates are parsed, so the templates parsed before the registration, e.g. the
+// ones of package level variables, can not use them.
+func RegisterTemplateFunc(name string, fn interface{}) error {
+	if fn == nil || reflect.TypeOf(fn).Kind() != reflect.Func {
+		return fmt.Errorf("template func %q must be a func, got %T", name, fn)
+	}
+
+	templateFuncsMu.Lock()
+	defer templateFuncsMu.Unlock()
+	if _, ok := builtinTemplateFuncs[name]; ok {
+		return fmt.Errorf("template func %q is a builtin one", name)
+	}
+	if _, ok := templateFuncs[name]; ok {
+		return fmt.Errorf("template func %q is already registered", name)
+	}
+	templateFuncs[name] = fn
+	return nil
+}
+
+// registeredTemplateFuncs returns a copy of the registered funcs, so that the registry is not held by templates.
+func registeredTemplateFuncs() template.FuncMap {
+	templateFuncsMu.RLock()
+	defer templateFuncsMu.RUnlock()
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
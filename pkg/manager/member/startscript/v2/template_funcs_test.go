@@ -0,0 +1,86 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// registerTestTemplateFunc registers fn and unregisters it when the test finishes
+func registerTestTemplateFunc(t *testing.T, name string, fn interface{}) {
+	if err := RegisterTemplateFunc(name, fn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		templateFuncsMu.Lock()
+		defer templateFuncsMu.Unlock()
+		delete(templateFuncs, name)
+	})
+}
+
+func TestRegisterTemplateFunc(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := parseTemplate("test", `{{ testQuote .Name }}`)
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring(`function "testQuote" not defined`))
+
+	registerTestTemplateFunc(t, "testQuote", func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	})
+	registerTestTemplateFunc(t, "testCheck", func(port int32) (string, error) {
+		if port <= 0 {
+			return "", fmt.Errorf("invalid port %d", port)
+		}
+		return fmt.Sprint(port), nil
+	})
+
+	type model struct {
+		Name string
+		Port int32
+	}
+	tpl, err := parseTemplate("test", `{{ define "Sub" }}{{ testCheck .Port }}{{ end }}`,
+		`echo {{ testQuote .Name }}:{{ template "Sub" . }}`)
+	g.Expect(err).Should(gomega.Succeed())
+	script, err := renderTemplateFunc(tpl, &model{Name: "it's", Port: 20160})
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal(`echo 'it'\''s':20160`))
+
+	_, err = renderTemplateFunc(tpl, &model{Name: "tikv"})
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("invalid port 0"))
+
+	g.Expect(RegisterTemplateFunc("testQuote", strings.ToUpper)).Should(gomega.MatchError(`template func "testQuote" is already registered`))
+	g.Expect(RegisterTemplateFunc("testNil", nil)).Should(gomega.HaveOccurred())
+	g.Expect(RegisterTemplateFunc("testString", "not a func")).Should(gomega.HaveOccurred())
+	g.Expect(registeredTemplateFuncs()).Should(gomega.HaveLen(2))
+
+	// the start scripts are rendered as usual with the registered funcs
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+}
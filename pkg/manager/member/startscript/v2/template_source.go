@@ -0,0 +1,83 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"sync"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// TemplateSource looks up the user template of the start script named name for tc, e.g. tikv-start-script,
+// found is false if there is no user template for the script so that the built-in one is used.
+type TemplateSource func(tc *v1alpha1.TidbCluster, name string) (text string, found bool, err error)
+
+var (
+	templateSourceMu sync.RWMutex
+	// templateSource is the source set by SetTemplateSource, nil means that only the built-in templates are used
+	templateSource TemplateSource
+)
+
+// SetTemplateSource sets the source of the user templates of start scripts, it is expected to be called once
+// on the start of the operator. The source is only looked up for the clusters which set
+// StartScriptV2TemplateConfigMap, and the built-in templates are always used if it is not set.
+func SetTemplateSource(src TemplateSource) {
+	templateSourceMu.Lock()
+	defer templateSourceMu.Unlock()
+	templateSource = src
+}
+
+// parseStartScriptTemplate parses the start script named name from subScript and script, script is replaced by
+// the user template of tc if there is one. A user template which fails to be parsed is rejected instead of falling
+// back to the built-in one, so that a broken template is surfaced rather than silently ignored.
+func parseStartScriptTemplate(tc *v1alpha1.TidbCluster, name, subScript, script string) (*template.Template, error) {
+	text, found, err := userTemplate(tc, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		tpl, err := parseTemplate(name, subScript, script)
+		if err != nil {
+			return nil, err
+		}
+		return tpl.Funcs(exitCodeFuncs(tc)), nil
+	}
+	tpl, err := parseTemplate(name, subScript, text)
+	if err != nil {
+		return nil, newRenderError(ErrTemplateParse, fmt.Errorf("invalid user template %s in ConfigMap %s/%s: %v",
+			name, tc.Namespace, tc.Spec.StartScriptV2TemplateConfigMap, err))
+	}
+	return tpl.Funcs(exitCodeFuncs(tc)), nil
+}
+
+// userTemplate returns the user template named name of tc from the template source
+func userTemplate(tc *v1alpha1.TidbCluster, name string) (string, bool, error) {
+	if tc.Spec.StartScriptV2TemplateConfigMap == "" {
+		return "", false, nil
+	}
+	templateSourceMu.RLock()
+	src := templateSource
+	templateSourceMu.RUnlock()
+	if src == nil {
+		return "", false, nil
+	}
+	text, found, err := src(tc, name)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get user template %s from ConfigMap %s/%s: %w",
+			name, tc.Namespace, tc.Spec.StartScriptV2TemplateConfigMap, err)
+	}
+	return text, found, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// setTestTemplateSource sets the templates as the template source and unsets it when the test finishes,
+// the names of the looked up templates are recorded in lookups.
+func setTestTemplateSource(t *testing.T, templates map[string]string, err error) *[]string {
+	lookups := []string{}
+	SetTemplateSource(func(tc *v1alpha1.TidbCluster, name string) (string, bool, error) {
+		lookups = append(lookups, name)
+		if err != nil {
+			return "", false, err
+		}
+		text, ok := templates[name]
+		return text, ok, nil
+	})
+	t.Cleanup(func() { SetTemplateSource(nil) })
+	return &lookups
+}
+
+func TestStartScriptTemplateSource(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(configMap string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:                             &v1alpha1.PDSpec{},
+				TiKV:                           &v1alpha1.TiKVSpec{},
+				TiDB:                           &v1alpha1.TiDBSpec{},
+				StartScriptV2TemplateConfigMap: configMap,
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+	builtinTiKV, err := RenderTiKVStartScript(newTC(""))
+	g.Expect(err).Should(gomega.Succeed())
+	builtinTiDB, err := RenderTiDBStartScript(newTC(""))
+	g.Expect(err).Should(gomega.Succeed())
+
+	lookups := setTestTemplateSource(t, map[string]string{
+		"tikv-start-script": `#!/bin/sh
+{{ template "TiKVExec" . }} --pd={{ .PDAddr }} --data-dir={{ .DataDir }}
+`,
+	}, nil)
+
+	// the source is not looked up for the clusters without the ConfigMap
+	script, err := RenderTiKVStartScript(newTC(""))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal(builtinTiKV))
+	g.Expect(*lookups).Should(gomega.BeEmpty())
+
+	// the user template takes precedence over the built-in one, and it can use the subscripts
+	script, err = RenderTiKVStartScript(newTC("start-script-templates"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal(`#!/bin/sh
+exec /tikv-server ${ARGS} --pd=start-script-test-pd:2379 --data-dir=/var/lib/tikv
+`))
+
+	// the built-in template is used if there is no user template for the script
+	script, err = RenderTiDBStartScript(newTC("start-script-templates"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.Equal(builtinTiDB))
+	g.Expect(*lookups).Should(gomega.Equal([]string{"tikv-start-script", "tidb-start-script"}))
+
+	// a broken user template is rejected instead of falling back to the built-in one
+	setTestTemplateSource(t, map[string]string{"tikv-start-script": `{{ if .PDAddr }}`}, nil)
+	_, err = RenderTiKVStartScript(newTC("start-script-templates"))
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("invalid user template tikv-start-script in ConfigMap start-script-test-ns/start-script-templates"))
+
+	setTestTemplateSource(t, map[string]string{"tikv-start-script": `{{ .NoSuchField }}`}, nil)
+	_, err = RenderTiKVStartScript(newTC("start-script-templates"))
+	g.Expect(errors.Is(err, ErrTemplateParse)).Should(gomega.BeTrue())
+
+	// the failure of the source is returned
+	setTestTemplateSource(t, nil, fmt.Errorf("configmaps \"start-script-templates\" not found"))
+	_, err = RenderTiKVStartScript(newTC("start-script-templates"))
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("failed to get user template tikv-start-script from ConfigMap start-script-test-ns/start-script-templates"))
+}
@@ -17,7 +17,6 @@ import (
 	"fmt"
 	"path"
 	"strings"
-	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -28,6 +27,7 @@ import (
 
 // TiCDCStartScriptModel contain fields for rendering TiCDC start script
 type TiCDCStartScriptModel struct {
+	Addr          string
 	AdvertiseAddr string
 	GCTTL         int32
 	LogFile       string
@@ -35,22 +35,34 @@ type TiCDCStartScriptModel struct {
 	PDAddr        string
 	ExtraArgs     string
 
+	// DataDir and SortDir are only set if the data volume of TiCDC is configured,
+	// the default dirs of TiCDC are used otherwise.
+	DataDir string
+	SortDir string
+
 	AcrossK8s *AcrossK8sScriptModel
 }
 
+// ticdcSortSubDir is the sort dir of TiCDC under its data dir
+const ticdcSortSubDir = "tmp/sorter"
+
+// Validate checks the fields required by TiCDC start script
+func (m *TiCDCStartScriptModel) Validate() error {
+	return validateModel("TiCDC start",
+		validateAddr("Addr", m.Addr),
+		validateAddr("AdvertiseAddr", m.AdvertiseAddr),
+		validateURL("PDAddr", m.PDAddr),
+		m.AcrossK8s.Validate(),
+	)
+}
+
 func RenderTiCDCStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &TiCDCStartScriptModel{}
 	tcName := tc.Name
-	tcNS := tc.Namespace
-	peerServiceName := controller.TiCDCPeerMemberName(tcName)
-
-	// NB: TiCDC control relies the format.
-	// TODO move advertise addr format to package controller.
-	advertiseAddr := fmt.Sprintf("${TICDC_POD_NAME}.%s.%s.svc", peerServiceName, tcNS)
-	if tc.Spec.ClusterDomain != "" {
-		advertiseAddr = advertiseAddr + "." + tc.Spec.ClusterDomain
-	}
-	m.AdvertiseAddr = fmt.Sprintf("%s:%d", advertiseAddr, v1alpha1.DefaultTiCDCPort)
+
+	m.Addr = formatListenAddr("", v1alpha1.DefaultTiCDCPort, listenOnIPv6(tc, preferIPv6ListenHost(tc)))
+
+	m.AdvertiseAddr = TiCDCAdvertiseAddr(tc)
 
 	m.GCTTL = tc.TiCDCGCTTL()
 
@@ -58,15 +70,21 @@ func RenderTiCDCStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 
 	m.LogLevel = tc.TiCDCLogLevel()
 
+	if name := tc.Spec.TiCDC.DataVolumeName; name != "" {
+		m.DataDir = tc.Spec.TiCDC.VolumeMountPath(name)
+		if m.DataDir == "" {
+			return "", fmt.Errorf("data volume %q is not mounted to TiCDC", name)
+		}
+		// it is the same as the default sort dir of TiCDC under the data dir
+		m.SortDir = path.Join(m.DataDir, ticdcSortSubDir)
+	}
+
 	m.PDAddr = fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort)
 	if tc.AcrossK8s() {
-		m.AcrossK8s = &AcrossK8sScriptModel{
-			PDAddr:        fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort),
-			DiscoveryAddr: fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS),
-		}
+		m.AcrossK8s = newAcrossK8sScriptModel(tc)
 		m.PDAddr = "${result}" // get pd addr in subscript
 	} else if tc.Heterogeneous() && tc.WithoutLocalPD() {
-		m.PDAddr = fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tc.Spec.Cluster.Name), v1alpha1.DefaultPDClientPort) // use pd of reference cluster
+		m.PDAddr = fmt.Sprintf("%s://%s", tc.Scheme(), referencePDAddr(tc)) // use pd of reference cluster
 	}
 
 	extraArgs := []string{}
@@ -83,6 +101,16 @@ func RenderTiCDCStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 		m.ExtraArgs = strings.Join(extraArgs, " ")
 	}
 
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	ticdcStartScriptTpl, err := parseStartScriptTemplate(tc, "ticdc-start-script", ticdcStartSubScript,
+		goCommonScript(tc, "/etc/ticdc")+replaceTicdcStartScriptCustomPorts(ticdcStartScript))
+	if err != nil {
+		return "", err
+	}
+
 	return renderTemplateFunc(ticdcStartScriptTpl, m)
 }
 
@@ -91,12 +119,12 @@ const (
 	ticdcStartSubScript = `
 {{ define "AcrossK8sSubscript" }}
 pd_url={{ .AcrossK8s.PDAddr }}
-encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
 discovery_url={{ .AcrossK8s.DiscoveryAddr }}
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+until result=$(` + acrossK8sVerifySubScript + `); do` + acrossK8sMaxRetriesSubScript + `
     echo "waiting for the verification of PD endpoints ..."
-    sleep $((RANDOM % 5))
-done
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
 {{- end}}
 `
 
@@ -105,12 +133,15 @@ done
 TICDC_POD_NAME=${POD_NAME}
 {{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
 
-ARGS="--addr=0.0.0.0:8301 \
+ARGS="--addr={{ .Addr }} \
 --advertise-addr={{ .AdvertiseAddr }} \
 --gc-ttl={{ .GCTTL }} \
 --log-file={{ .LogFile }} \
 --log-level={{ .LogLevel }} \
 --pd={{ .PDAddr }}"
+{{- if .DataDir }}
+ARGS="${ARGS} --data-dir={{ .DataDir }} --sort-dir={{ .SortDir }}"
+{{- end }}
 {{- if .ExtraArgs }}
 ARGS="${ARGS} {{ .ExtraArgs }}"
 {{- end }}
@@ -128,9 +159,3 @@ func replaceTicdcStartScriptCustomPorts(startScript string) string {
 	}
 	return startScript
 }
-
-var ticdcStartScriptTpl = template.Must(
-	template.Must(
-		template.New("ticdc-start-script").Parse(ticdcStartSubScript),
-	).Parse(componentCommonScript + replaceTicdcStartScriptCustomPorts(ticdcStartScript)),
-)
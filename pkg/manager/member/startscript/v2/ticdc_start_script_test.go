@@ -393,6 +393,299 @@ ARGS="--addr=0.0.0.0:8301 \
 --pd=http://start-script-test-pd:2379"
 ARGS="${ARGS} --config=/etc/ticdc/ticdc.toml"
 
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "dual stack",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=[::]:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://start-script-test-pd:2379"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "pod name fallback",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPodNameFallback}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+POD_NAME=${POD_NAME:-${HOSTNAME:-}}
+if [[ -z "${POD_NAME}" && -f /etc/hostname ]]
+then
+    POD_NAME=$(cat /etc/hostname)
+fi
+if [[ -z "${POD_NAME}" ]]
+then
+    echo "failed to determine the pod name from POD_NAME, HOSTNAME and /etc/hostname, exiting." >&2
+    exit 1
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=0.0.0.0:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://start-script-test-pd:2379"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd in another cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.ClusterDomain = "cluster-2.com"
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster", ClusterDomain: "cluster-1.com"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=0.0.0.0:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc.cluster-2.com:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://target-cluster-pd.start-script-test-ns.svc.cluster-1.com:2379"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "prefer ipv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=0.0.0.0:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://start-script-test-pd:2379"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "prefer ipv6 listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPreferIPv6ListenHost}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=[::]:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://start-script-test-pd:2379"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "data volume",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiCDC.StorageVolumes = []v1alpha1.StorageVolume{{Name: "data", StorageSize: "10Gi", MountPath: "/var/lib/ticdc"}}
+				tc.Spec.TiCDC.DataVolumeName = "data"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+
+ARGS="--addr=0.0.0.0:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=http://start-script-test-pd:2379"
+ARGS="${ARGS} --data-dir=/var/lib/ticdc --sort-dir=/var/lib/ticdc/tmp/sorter"
+
+echo "start ticdc-server ..."
+echo "/cdc server ${ARGS}"
+exec /cdc server ${ARGS}
+`,
+		},
+		{
+			name: "data volume across k8s with setting cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.ClusterDomain = "cluster-1.com"
+				tc.Spec.TiCDC.StorageVolumes = []v1alpha1.StorageVolume{{Name: "data", StorageSize: "10Gi", MountPath: "/var/lib/ticdc"}}
+				tc.Spec.TiCDC.DataVolumeName = "data"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TICDC_POD_NAME=${POD_NAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--addr=0.0.0.0:8301 \
+--advertise-addr=${TICDC_POD_NAME}.start-script-test-ticdc-peer.start-script-test-ns.svc.cluster-1.com:8301 \
+--gc-ttl=86400 \
+--log-file= \
+--log-level=info \
+--pd=${result}"
+ARGS="${ARGS} --data-dir=/var/lib/ticdc --sort-dir=/var/lib/ticdc/tmp/sorter"
+
 echo "start ticdc-server ..."
 echo "/cdc server ${ARGS}"
 exec /cdc server ${ARGS}
@@ -422,3 +715,20 @@ exec /cdc server ${ARGS}
 		g.Expect(validateScript(script)).Should(gomega.Succeed())
 	}
 }
+
+func TestRenderTiCDCStartScriptWithUnmountedDataVolume(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiCDC: &v1alpha1.TiCDCSpec{
+				DataVolumeName: "data",
+			},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	_, err := RenderTiCDCStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"path"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultTiDBPreStopTimeout = 60
+)
+
+// TiDBPreStopScriptModel contain fields for rendering TiDB preStop script
+type TiDBPreStopScriptModel struct {
+	StatusURL string
+	CurlArgs  string
+	Timeout   int
+}
+
+// Validate checks the fields required by TiDB preStop script
+func (m *TiDBPreStopScriptModel) Validate() error {
+	return validateModel("TiDB preStop",
+		validateURL("StatusURL", m.StatusURL),
+		validatePositive("Timeout", m.Timeout),
+	)
+}
+
+// RenderTiDBPreStopScript renders TiDB preStop script from TidbCluster, the status server
+// of the local TiDB is accessed in the same way as the readiness probe.
+func RenderTiDBPreStopScript(tc *v1alpha1.TidbCluster) (string, error) {
+	m := &TiDBPreStopScriptModel{}
+
+	m.StatusURL = fmt.Sprintf("%s://127.0.0.1:%d", tc.Scheme(), v1alpha1.DefaultTiDBStatusPort)
+	m.CurlArgs = tidbCurlArgs(tc)
+
+	m.Timeout = defaultTiDBPreStopTimeout
+	if preStop := tc.Spec.TiDB.PreStop; preStop != nil && preStop.Timeout > 0 {
+		m.Timeout = preStop.Timeout
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(tidbPreStopScriptTpl, m)
+}
+
+// tidbCurlArgs returns the args of curl used by TiDB scripts, the cluster certs are used if TLS is enabled.
+func tidbCurlArgs(tc *v1alpha1.TidbCluster) string {
+	args := "-s --fail"
+	if tc.IsTLSClusterEnabled() {
+		args = fmt.Sprintf("%s --cacert %s --cert %s --key %s", args,
+			path.Join(constants.TiDBCertPath, corev1.ServiceAccountRootCAKey),
+			path.Join(constants.TiDBCertPath, corev1.TLSCertKey),
+			path.Join(constants.TiDBCertPath, corev1.TLSPrivateKeyKey))
+	}
+	return args
+}
+
+var tidbPreStopScriptTpl = template.Must(template.New("tidb-prestop-script").Parse(tidbPreStopScript))
+
+// tidbPreStopScript is the template of preStop script, tidb-server is stopped by SIGTERM after it exits.
+const tidbPreStopScript = `#!/bin/sh
+
+set -uo pipefail
+
+STATUS_URL={{ .StatusURL }}
+CURL="curl {{ .CurlArgs }}"
+
+# the label is for the proxies to stop routing new connections to the server
+echo "labeling tidb-server as draining ..."
+${CURL} -X POST -d '{"draining":"true"}' ${STATUS_URL}/labels || echo "failed to label tidb-server as draining"
+
+elapseTime=0
+period=1
+threshold={{ .Timeout }}
+while true; do
+    connections=$(${CURL} ${STATUS_URL}/status | sed -n 's/.*"connections": *\([0-9]*\).*/\1/p')
+    if [[ -n "${connections}" && ${connections} -eq 0 ]]; then
+        echo "all connections of tidb-server are closed"
+        break
+    fi
+
+    if [[ ${elapseTime} -ge ${threshold} ]]; then
+        echo "waiting for the connections of tidb-server to be closed timeout, ${connections:-unknown} connections left" >&2
+        break
+    fi
+
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+done
+`
@@ -0,0 +1,217 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestRenderTiDBPreStopScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name string
+
+		modifyTC     func(tc *v1alpha1.TidbCluster)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name:     "basic",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+STATUS_URL=http://127.0.0.1:10080
+CURL="curl -s --fail"
+
+# the label is for the proxies to stop routing new connections to the server
+echo "labeling tidb-server as draining ..."
+${CURL} -X POST -d '{"draining":"true"}' ${STATUS_URL}/labels || echo "failed to label tidb-server as draining"
+
+elapseTime=0
+period=1
+threshold=60
+while true; do
+    connections=$(${CURL} ${STATUS_URL}/status | sed -n 's/.*"connections": *\([0-9]*\).*/\1/p')
+    if [[ -n "${connections}" && ${connections} -eq 0 ]]; then
+        echo "all connections of tidb-server are closed"
+        break
+    fi
+
+    if [[ ${elapseTime} -ge ${threshold} ]]; then
+        echo "waiting for the connections of tidb-server to be closed timeout, ${connections:-unknown} connections left" >&2
+        break
+    fi
+
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+done
+`,
+		},
+		{
+			name: "custom timeout",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiDB.PreStop = &v1alpha1.TiDBPreStopSpec{Timeout: 120}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+STATUS_URL=http://127.0.0.1:10080
+CURL="curl -s --fail"
+
+# the label is for the proxies to stop routing new connections to the server
+echo "labeling tidb-server as draining ..."
+${CURL} -X POST -d '{"draining":"true"}' ${STATUS_URL}/labels || echo "failed to label tidb-server as draining"
+
+elapseTime=0
+period=1
+threshold=120
+while true; do
+    connections=$(${CURL} ${STATUS_URL}/status | sed -n 's/.*"connections": *\([0-9]*\).*/\1/p')
+    if [[ -n "${connections}" && ${connections} -eq 0 ]]; then
+        echo "all connections of tidb-server are closed"
+        break
+    fi
+
+    if [[ ${elapseTime} -ge ${threshold} ]]; then
+        echo "waiting for the connections of tidb-server to be closed timeout, ${connections:-unknown} connections left" >&2
+        break
+    fi
+
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+done
+`,
+		},
+		{
+			name: "enable tls",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+STATUS_URL=https://127.0.0.1:10080
+CURL="curl -s --fail --cacert /var/lib/tidb-tls/ca.crt --cert /var/lib/tidb-tls/tls.crt --key /var/lib/tidb-tls/tls.key"
+
+# the label is for the proxies to stop routing new connections to the server
+echo "labeling tidb-server as draining ..."
+${CURL} -X POST -d '{"draining":"true"}' ${STATUS_URL}/labels || echo "failed to label tidb-server as draining"
+
+elapseTime=0
+period=1
+threshold=60
+while true; do
+    connections=$(${CURL} ${STATUS_URL}/status | sed -n 's/.*"connections": *\([0-9]*\).*/\1/p')
+    if [[ -n "${connections}" && ${connections} -eq 0 ]]; then
+        echo "all connections of tidb-server are closed"
+        break
+    fi
+
+    if [[ ${elapseTime} -ge ${threshold} ]]; then
+        echo "waiting for the connections of tidb-server to be closed timeout, ${connections:-unknown} connections left" >&2
+        break
+    fi
+
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+done
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiDB: &v1alpha1.TiDBSpec{},
+			},
+		}
+		tc.Name = "prestop-script-test"
+		tc.Namespace = "prestop-script-test-ns"
+
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		script, err := RenderTiDBPreStopScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestTiDBPreStopScriptTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		name        string
+		connections string
+		timeout     int
+		slept       string
+		expect      string
+	}{
+		{name: "no connections", connections: "0", timeout: 5, slept: "0", expect: "all connections of tidb-server are closed"},
+		{name: "connections left", connections: "3", timeout: 5, slept: "5", expect: "3 connections left"},
+		{name: "status server unavailable", connections: "", timeout: 3, slept: "3", expect: "unknown connections left"},
+	}
+	for _, c := range cases {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiDB: &v1alpha1.TiDBSpec{PreStop: &v1alpha1.TiDBPreStopSpec{Timeout: c.timeout}},
+			},
+		}
+		script, err := RenderTiDBPreStopScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+
+		// curl and sleep are replaced by functions, the seconds slept are counted to check the bound of waiting
+		fakes := `slept=0
+sleep() { slept=$(( slept+$1 )); }
+curl() {
+    case "$*" in
+    *"/status") [ -n "${CONNECTIONS}" ] && echo "{\"connections\":${CONNECTIONS},\"version\":\"8.0.11-TiDB-v8.5.0\"}" ;;
+    esac
+}
+`
+		file, err := syntax.NewParser().Parse(strings.NewReader(fakes+script+`echo "slept ${slept}"`), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var out bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("CONNECTIONS="+c.connections, "PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &out, &out))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(out.String()).Should(gomega.ContainSubstring(c.expect), "case %s", c.name)
+		g.Expect(out.String()).Should(gomega.HaveSuffix(fmt.Sprintf("slept %s\n", c.slept)), "case %s", c.name)
+	}
+}
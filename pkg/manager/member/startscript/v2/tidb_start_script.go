@@ -15,46 +15,86 @@ package v2
 
 import (
 	"fmt"
+	"path"
 	"strings"
-	"text/template"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	"k8s.io/utils/pointer"
 )
 
 // TiDBStartScriptModel contain some fields for rendering TiDB start script
 type TiDBStartScriptModel struct {
-	PDAddr        string
-	AdvertiseAddr string
-	ExtraArgs     string
+	PDAddr          string
+	AdvertiseAddr   string
+	ListenHost      string
+	ExtraArgs       string
+	StartTimeout    int
+	DnsWaitInterval int
+	NsLookupCmd     string
+	DnsWaitResolver string
+
+	// ConfigPath is the config file passed to TiDB, it is generated at runtime from the mounted one
+	// if some settings are set by the start script, e.g. AuthTokenJWKS.
+	ConfigPath string
+	// AuthTokenJWKS is the JWKS file mounted from the secret of tidb auth token, it is set as
+	// security.auth-token-jwks in the config file at runtime as tidb-server has no flag for it.
+	// It is empty if token based auth is not enabled.
+	AuthTokenJWKS string
+
+	AcrossK8s       *AcrossK8sScriptModel
+	ResolvedSummary *ResolvedSummary
+}
 
-	AcrossK8s *AcrossK8sScriptModel
+// Validate checks the fields required by TiDB start script
+func (m *TiDBStartScriptModel) Validate() error {
+	var authTokenJWKSErr error
+	if m.AuthTokenJWKS != "" {
+		authTokenJWKSErr = validateAbsPath("AuthTokenJWKS", m.AuthTokenJWKS)
+	}
+	return validateModel("TiDB start",
+		validateURL("PDAddr", m.PDAddr),
+		validateRequired("AdvertiseAddr", m.AdvertiseAddr),
+		validateRequired("ListenHost", m.ListenHost),
+		validateResolver("DnsWaitResolver", m.DnsWaitResolver),
+		validateAbsPath("ConfigPath", m.ConfigPath),
+		authTokenJWKSErr,
+		m.AcrossK8s.Validate(),
+		m.ResolvedSummary.Validate(),
+	)
 }
 
 // RenderTiDBStartScript renders TiDB start script from TidbCluster
 func RenderTiDBStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &TiDBStartScriptModel{}
 	tcName := tc.Name
-	tcNS := tc.Namespace
-	peerServiceName := controller.TiDBPeerMemberName(tcName)
 
 	m.PDAddr = fmt.Sprintf("%s:%d", controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort)
-	if tc.AcrossK8s() {
-		m.AcrossK8s = &AcrossK8sScriptModel{
-			PDAddr:        fmt.Sprintf("%s:%d", controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort),
-			DiscoveryAddr: fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS),
-		}
+	if useLocalhost(tc) {
+		m.PDAddr = fmt.Sprintf("%s:%d", localhostHost, v1alpha1.DefaultPDClientPort)
+	} else if tc.AcrossK8s() {
+		m.AcrossK8s = newAcrossK8sScriptModel(tc)
 		m.PDAddr = "${result}" // get pd addr in subscript
 	} else if tc.Heterogeneous() && tc.WithoutLocalPD() {
-		m.PDAddr = fmt.Sprintf("%s:%d", controller.PDMemberName(tc.Spec.Cluster.Name), v1alpha1.DefaultPDClientPort) // use pd of reference cluster
+		m.PDAddr = referencePDAddr(tc) // use pd of reference cluster
 	}
 
-	m.AdvertiseAddr = fmt.Sprintf("${TIDB_POD_NAME}.%s.%s.svc", peerServiceName, tcNS)
-	if tc.Spec.ClusterDomain != "" {
-		m.AdvertiseAddr = m.AdvertiseAddr + "." + tc.Spec.ClusterDomain
-	}
+	m.AdvertiseAddr = TiDBAdvertiseAddr(tc)
+
+	// TiDB listens on IPv4 wildcard unless PreferIPv6 is set with the PreferIPv6ListenHost feature flag,
+	// it is not affected by the DualStack feature flag
+	m.ListenHost = formatHost(wildcardHost(preferIPv6ListenHost(tc)))
 
 	extraArgs := []string{}
+	// `DefaultTiDBServerPort` and `DefaultTiDBStatusPort` may be changed when building the binary,
+	// pass them only if they are different from the defaults of tidb-server
+	if v1alpha1.DefaultTiDBServerPort != 4000 {
+		extraArgs = append(extraArgs, fmt.Sprintf("-P %d", v1alpha1.DefaultTiDBServerPort))
+	}
+	if v1alpha1.DefaultTiDBStatusPort != 10080 {
+		extraArgs = append(extraArgs, fmt.Sprintf("--status=%d", v1alpha1.DefaultTiDBStatusPort))
+	}
 	if tc.IsTiDBBinlogEnabled() {
 		extraArgs = append(extraArgs, "--enable-binlog=true")
 	}
@@ -66,33 +106,102 @@ func RenderTiDBStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 		m.ExtraArgs = strings.Join(extraArgs, " ")
 	}
 
+	m.ConfigPath = tidbConfigPath
+	if pointer.BoolPtrDerefOr(tc.Spec.TiDB.TokenBasedAuthEnabled, false) {
+		m.AuthTokenJWKS = path.Join(constants.TiDBAuthTokenPath, constants.TiDBAuthTokenJWKS)
+		m.ConfigPath = tidbRuntimeConfigPath
+	}
+
+	// TiDB has no start timeout of its own, reuse the one of PD like TiFlash does
+	m.StartTimeout = tc.PDStartTimeout()
+	m.DnsWaitInterval = int(tc.Spec.DnsWaitIntervalSeconds)
+	m.DnsWaitResolver = tc.Spec.DnsWaitResolver
+	m.NsLookupCmd = nsLookupCmd(tc, m.DnsWaitResolver)
+
+	m.ResolvedSummary = newResolvedSummary(tc, ResolvedSummary{
+		Component:     v1alpha1.TiDBMemberType.String(),
+		PDAddr:        m.PDAddr,
+		AdvertiseAddr: fmt.Sprintf("%s:%d", m.AdvertiseAddr, v1alpha1.DefaultTiDBServerPort),
+		Ports: []ResolvedSummaryPort{
+			{Name: "server", Port: v1alpha1.DefaultTiDBServerPort},
+			{Name: "status", Port: v1alpha1.DefaultTiDBStatusPort},
+		},
+	})
+
+	waitForDnsNameIpMatchOnStartup := tidbTiFlashWaitForDnsNameIpMatch(tc)
+	skipDnsWaitOnStartup := skipDnsWait(tc)
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	tidbStartScriptTpl, err := parseStartScriptTemplate(tc, "tidb-start-script", tidbStartSubScript,
+		goCommonScript(tc, "/etc/tidb")+
+			replaceDnsLookupForStrictMode(tc, replaceDnsWaitThresholdUnit(tc, replaceDnsWaitSleep(tc, replaceTiDBStartScriptDnsAwaitPart(tidbStartScript, waitForDnsNameIpMatchOnStartup, skipDnsWaitOnStartup)))))
+	if err != nil {
+		return "", err
+	}
+
 	return renderTemplateFunc(tidbStartScriptTpl, m)
 }
 
+func replaceTiDBStartScriptDnsAwaitPart(startScript string, withLocalIpMatch, skipDnsWait bool) string {
+	if withLocalIpMatch && !skipDnsWait {
+		return strings.ReplaceAll(startScript, dnsAwaitPart, tidbWaitForDnsIpMatchSubScript)
+	}
+	return strings.ReplaceAll(startScript, dnsAwaitPart, "")
+}
+
+const (
+	// tidbConfigPath is the path of the config file mounted from the ConfigMap
+	tidbConfigPath = "/etc/tidb/tidb.toml"
+	// tidbRuntimeConfigPath is the path of the config file generated at runtime, it is in /tmp as the
+	// root filesystem may be read-only.
+	tidbRuntimeConfigPath = "/tmp/runtime-tidb.toml"
+)
+
 const (
 	// tidbStartSubScript contains optional subscripts used in start script.
 	tidbStartSubScript = `
 {{ define "AcrossK8sSubscript" }}
 pd_url={{ .AcrossK8s.PDAddr }}
-encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
 discovery_url={{ .AcrossK8s.DiscoveryAddr }}
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(` + acrossK8sVerifySubScript + acrossK8sStripPDSchemeSubScript + `); do` + acrossK8sMaxRetriesSubScript + `
     echo "waiting for the verification of PD endpoints ..."
-    sleep $((RANDOM % 5))
-done
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
 {{- end}}
 `
 
+	tidbWaitForDnsIpMatchSubScript = `
+componentDomain={{ .AdvertiseAddr }}
+waitThreshold={{ .StartTimeout }}
+nsLookupCmd="{{ .NsLookupCmd }}"
+` + componentCommonWaitForDnsIpMatchScript
+
 	// tidbStartScript is the template of start script.
 	tidbStartScript = `
-TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}` +
+		dnsAwaitPart + `
 {{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
+{{- if .AuthTokenJWKS }}
+
+cp ` + tidbConfigPath + ` {{ .ConfigPath }}
+if awk '/^\[/ { in_security = ($0 == "[security]") } in_security && /^auth-token-jwks *=/ { found = 1 } END { exit !found }' {{ .ConfigPath }}; then
+    echo "security.auth-token-jwks is set in the config file, {{ .AuthTokenJWKS }} is not applied"
+elif grep -q '^\[security\]$' {{ .ConfigPath }}; then
+    sed -i 's|^\[security\]$|&\nauth-token-jwks = "{{ .AuthTokenJWKS }}"|' {{ .ConfigPath }}
+else
+    printf '\n[security]\nauth-token-jwks = "{{ .AuthTokenJWKS }}"\n' >> {{ .ConfigPath }}
+fi
+{{- end }}
 
 ARGS="--store=tikv \
 --advertise-address={{ .AdvertiseAddr }} \
---host=0.0.0.0 \
+--host={{ .ListenHost }} \
 --path={{ .PDAddr }} \
---config=/etc/tidb/tidb.toml"
+--config={{ .ConfigPath }}"
 {{- if .ExtraArgs }}
 ARGS="${ARGS} {{ .ExtraArgs }}"
 {{- end }}
@@ -102,15 +211,9 @@ if [[ ! -z "${SLOW_LOG_FILE}" ]]
 then
     ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
 fi
-
+` + resolvedSummarySubScript + `
 echo "start tidb-server ..."
 echo "/tidb-server ${ARGS}"
 exec /tidb-server ${ARGS}
 `
 )
-
-var tidbStartScriptTpl = template.Must(
-	template.Must(
-		template.New("tidb-start-script").Parse(tidbStartSubScript),
-	).Parse(componentCommonScript + tidbStartScript),
-)
@@ -14,12 +14,21 @@
 package v2
 
 import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 func TestRenderTiDBStartScript(t *testing.T) {
@@ -277,10 +286,10 @@ then
 fi
 
 TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -328,10 +337,10 @@ then
 fi
 
 TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -424,10 +433,491 @@ then
 fi
 
 TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
-pd_url=start-script-test-pd:2379
+pd_url=http://target-cluster-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=target-cluster-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=${result} \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "prefer ipv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "prefer ipv6 listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPreferIPv6ListenHost}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=[::] \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "dual stack does not affect tidb",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "wait for dns name ip match of pd and tikv only",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				// TiDB and TiFlash do not wait without the TiDBTiFlashDnsNameIpMatch feature flag
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "wait for dns name ip match",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc
+waitThreshold=30
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "wait for dns name ip match with cluster domain and across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.ClusterDomain = "cluster.local"
+				tc.Spec.AcrossK8s = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc.cluster.local
+waitThreshold=30
+nsLookupCmd="dig ${componentDomain} A ${componentDomain} AAAA +search +short"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc.cluster.local \
+--host=0.0.0.0 \
+--path=${result} \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "skip dns wait",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagSkipDnsWait}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc \
+--host=0.0.0.0 \
+--path=start-script-test-pd:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with tls enabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=https://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -444,6 +934,51 @@ then
     ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
 fi
 
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous cluster without local pd in another cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.ClusterDomain = "cluster-2.com"
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster", Namespace: "target-ns", ClusterDomain: "cluster-1.com"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIDB_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--store=tikv \
+--advertise-address=${TIDB_POD_NAME}.start-script-test-tidb-peer.start-script-test-ns.svc.cluster-2.com \
+--host=0.0.0.0 \
+--path=target-cluster-pd.target-ns.svc.cluster-1.com:2379 \
+--config=/etc/tidb/tidb.toml"
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
 echo "start tidb-server ..."
 echo "/tidb-server ${ARGS}"
 exec /tidb-server ${ARGS}
@@ -474,3 +1009,80 @@ exec /tidb-server ${ARGS}
 		g.Expect(validateScript(script)).Should(gomega.Succeed())
 	}
 }
+
+func TestRenderTiDBStartScriptWithAuthTokenJWKS(t *testing.T) {
+	newTC := func(tokenBasedAuthEnabled *bool) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiDB: &v1alpha1.TiDBSpec{TokenBasedAuthEnabled: tokenBasedAuthEnabled},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	cases := []struct {
+		name   string
+		config string
+		// expected is the runtime config file passed to TiDB
+		expected string
+	}{
+		{
+			name:     "no security section in the config file",
+			config:   "[log]\nlevel = \"info\"\n",
+			expected: "[log]\nlevel = \"info\"\n\n[security]\nauth-token-jwks = \"/var/lib/tidb-auth-token/tidb_auth_token_jwks.json\"\n",
+		},
+		{
+			name:     "security section in the config file",
+			config:   "[security]\nskip-grant-table = false\n",
+			expected: "[security]\nauth-token-jwks = \"/var/lib/tidb-auth-token/tidb_auth_token_jwks.json\"\nskip-grant-table = false\n",
+		},
+		{
+			name:     "auth token jwks is set in the config file",
+			config:   "[security]\nauth-token-jwks = \"/var/lib/tidb-auth-token/tidb_auth_token_jwks.json\"\n",
+			expected: "[security]\nauth-token-jwks = \"/var/lib/tidb-auth-token/tidb_auth_token_jwks.json\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			script, err := RenderTiDBStartScript(newTC(pointer.BoolPtr(true)))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.ContainSubstring("--config=/tmp/runtime-tidb.toml\"\n"))
+
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tidb.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			begin := strings.Index(script, "\ncp /etc/tidb/tidb.toml")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tidb/tidb.toml", configFile,
+				"/tmp/runtime-tidb.toml", filepath.Join(tmp, "runtime-tidb.toml"),
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tidb.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+		})
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	// the mounted config file is used as it is if token based auth is not enabled
+	for _, enabled := range []*bool{nil, pointer.BoolPtr(false)} {
+		script, err := RenderTiDBStartScript(newTC(enabled))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).Should(gomega.ContainSubstring("--config=/etc/tidb/tidb.toml\"\n"))
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("auth-token-jwks"))
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("runtime-tidb.toml"))
+	}
+}
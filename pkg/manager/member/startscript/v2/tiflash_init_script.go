@@ -14,11 +14,9 @@
 package v2
 
 import (
-	"fmt"
 	"text/template"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
-	"github.com/pingcap/tidb-operator/pkg/controller"
 )
 
 // TiFlashInitScriptModel contain fields for rendering TiFlash Init script
@@ -26,17 +24,20 @@ type TiFlashInitScriptModel struct {
 	AcrossK8s *AcrossK8sScriptModel
 }
 
+// Validate checks the fields required by TiFlash init script
+func (m *TiFlashInitScriptModel) Validate() error {
+	return validateModel("TiFlash init", m.AcrossK8s.Validate())
+}
+
 // RenderTiFlashInitScript renders TiFlash Init script from TidbCluster
 func RenderTiFlashInitScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &TiFlashInitScriptModel{}
-	tcName := tc.Name
-	tcNS := tc.Namespace
-
 	if tc.AcrossK8s() {
-		m.AcrossK8s = &AcrossK8sScriptModel{
-			PDAddr:        fmt.Sprintf("%s://%s:%d", tc.Scheme(), controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort),
-			DiscoveryAddr: fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS),
-		}
+		m.AcrossK8s = newAcrossK8sScriptModel(tc)
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
 	}
 
 	return renderTemplateFunc(tiflashInitScriptTpl, m)
@@ -47,12 +48,12 @@ const (
 	tiflashInitSubScript = `
 {{ define "AcrossK8sSubscript" }}
 pd_url={{ .AcrossK8s.PDAddr }}
-encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
 discovery_url={{ .AcrossK8s.DiscoveryAddr }}
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+until result=$(` + acrossK8sVerifySubScript + acrossK8sStripPDSchemeSubScript + `); do` + acrossK8sMaxRetriesSubScript + `
     echo "waiting for the verification of PD endpoints ..."
-    sleep 2
-done
+    {{ if .AcrossK8s.MaxBackoff }}sleep $((RANDOM % {{ .AcrossK8s.MaxBackoff }})){{ else }}sleep 2{{ end }}
+done` + acrossK8sVerboseSubScript + `
 
 sed -i s/PD_ADDR/${result}/g /data0/config.toml
 sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
@@ -74,6 +75,6 @@ sed s/POD_NUM/${ordinal}/g /etc/tiflash/proxy_templ.toml > /data0/proxy.toml
 
 var tiflashInitScriptTpl = template.Must(
 	template.Must(
-		template.New("tiflash-init-script").Parse(tiflashInitSubScript),
+		template.New("tiflash-init-script").Funcs(builtinTemplateFuncs).Parse(tiflashInitSubScript),
 	).Parse(tiflashInitScript),
 )
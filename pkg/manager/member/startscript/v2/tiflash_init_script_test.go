@@ -90,6 +90,66 @@ until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_ur
     sleep 2
 done
 
+sed -i s/PD_ADDR/${result}/g /data0/config.toml
+sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
+`,
+		},
+		{
+			name: "across k8s with verification bounds",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{Timeout: 10, MaxRetries: 30, MaxBackoff: 8}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ordinal=$(echo ${POD_NAME} | awk -F- '{print $NF}')
+sed s/POD_NUM/${ordinal}/g /etc/tiflash/config_templ.toml > /data0/config.toml
+sed s/POD_NUM/${ordinal}/g /etc/tiflash/proxy_templ.toml > /data0/proxy.toml
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 10 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    retries=$(( ${retries:-0}+1 ))
+    if [ ${retries} -gt 30 ]; then
+        echo "failed to verify PD endpoints after 30 retries" >&2
+        exit 11
+    fi
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 8))
+done
+
+sed -i s/PD_ADDR/${result}/g /data0/config.toml
+sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
+`,
+		},
+		{
+			name: "across k8s with max retries",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{MaxRetries: 10}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ordinal=$(echo ${POD_NAME} | awk -F- '{print $NF}')
+sed s/POD_NUM/${ordinal}/g /etc/tiflash/config_templ.toml > /data0/config.toml
+sed s/POD_NUM/${ordinal}/g /etc/tiflash/proxy_templ.toml > /data0/proxy.toml
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    retries=$(( ${retries:-0}+1 ))
+    if [ ${retries} -gt 10 ]; then
+        echo "failed to verify PD endpoints after 10 retries" >&2
+        exit 11
+    fi
+    echo "waiting for the verification of PD endpoints ..."
+    sleep 2
+done
+
 sed -i s/PD_ADDR/${result}/g /data0/config.toml
 sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
 `,
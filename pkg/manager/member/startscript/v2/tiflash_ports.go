@@ -0,0 +1,31 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// validateTiFlashPorts checks all the ports of TiFlash are in the valid range
+func validateTiFlashPorts(p *v1alpha1.TiFlashPorts) error {
+	return validateModel("TiFlash ports",
+		validatePort("Tcp", p.Tcp),
+		validatePort("Http", p.Http),
+		validatePort("Flash", p.Flash),
+		validatePort("Proxy", p.Proxy),
+		validatePort("Metrics", p.Metrics),
+		validatePort("ProxyStatus", p.ProxyStatus),
+		validatePort("Internal", p.Internal),
+	)
+}
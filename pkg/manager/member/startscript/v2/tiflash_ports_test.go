@@ -0,0 +1,55 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestValidateTiFlashPorts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiFlash: &v1alpha1.TiFlashSpec{},
+		},
+	}
+	g.Expect(validateTiFlashPorts(tc.TiFlashPorts())).Should(gomega.Succeed())
+
+	tc.Spec.TiFlash.BasePortOffset = 100
+	g.Expect(validateTiFlashPorts(tc.TiFlashPorts())).Should(gomega.Succeed())
+
+	tc.Spec.TiFlash.BasePortOffset = 50000
+	g.Expect(validateTiFlashPorts(tc.TiFlashPorts())).Should(gomega.MatchError(gomega.ContainSubstring("Proxy 70170 must be in the range of 1 to 65535")))
+}
+
+func TestRenderTiFlashStartScriptWithInvalidBasePortOffset(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiFlash: &v1alpha1.TiFlashSpec{BasePortOffset: 50000},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	_, err := RenderTiFlashStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("ProxyStatus 70292 must be in the range of 1 to 65535"))
+}
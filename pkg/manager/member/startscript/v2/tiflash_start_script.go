@@ -14,46 +14,173 @@
 package v2
 
 import (
-	"text/template"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
 )
 
 // TiFlashStartScriptModel contain fields for rendering TiFlash start script
 type TiFlashStartScriptModel struct {
-	ExtraArgs string
+	PDAddr                   string
+	AdvertiseHost            string
+	ListenHost               string
+	ProxyStatusAddr          string
+	ProxyAdvertiseStatusAddr string
+	ExtraArgs                string
+	StartTimeout             int
+	DnsWaitInterval          int
+	NsLookupCmd              string
+	DnsWaitResolver          string
+	// ProxyExtraArgs are the args of the proxy in the form of the overrides of the flash.proxy config,
+	// TiFlash passes the flash.proxy config to the proxy as its args.
+	ProxyExtraArgs string
+	// WaitForDnsNameIpMatch indicates whether to wait until the advertise host is resolved to the IP of the pod
+	WaitForDnsNameIpMatch bool
+
+	// Ports and AcrossK8s are not used by the default template as the ports and the PD address are set in the
+	// config files generated by the init container, they are kept for the user templates.
+	Ports     *v1alpha1.TiFlashPorts
+	AcrossK8s *AcrossK8sScriptModel
+}
+
+// Validate checks the fields required by TiFlash start script
+func (m *TiFlashStartScriptModel) Validate() error {
+	return validateModel("TiFlash start",
+		validateURL("PDAddr", m.PDAddr),
+		validateRequired("AdvertiseHost", m.AdvertiseHost),
+		validateAddr("ProxyStatusAddr", m.ProxyStatusAddr),
+		validateAddr("ProxyAdvertiseStatusAddr", m.ProxyAdvertiseStatusAddr),
+		validateResolver("DnsWaitResolver", m.DnsWaitResolver),
+		validateRequired("ListenHost", m.ListenHost),
+		validateTiFlashPorts(m.Ports),
+		m.AcrossK8s.Validate(),
+	)
 }
 
 // RenderTiFlashStartScript renders TiFlash start script from TidbCluster
 func RenderTiFlashStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &TiFlashStartScriptModel{}
 
+	m.PDAddr, m.AcrossK8s = tiflashPDAddr(tc)
+
+	m.Ports = tc.TiFlashPorts()
+	m.ListenHost = listenHost(tc, tc.Spec.PreferIPv6)
+	m.AdvertiseHost = tiflashAdvertiseHost(tc)
+	m.ProxyStatusAddr = formatListenAddr(m.ListenHost, m.Ports.ProxyStatus, false)
+	m.ProxyAdvertiseStatusAddr = fmt.Sprintf("%s:%d", m.AdvertiseHost, m.Ports.ProxyStatus)
+
+	proxyExtraArgs := []string{}
+	if tc.Spec.EnableDynamicConfiguration != nil && *tc.Spec.EnableDynamicConfiguration {
+		proxyExtraArgs = append(proxyExtraArgs, fmt.Sprintf("--flash.proxy.advertise-status-addr=%s", m.ProxyAdvertiseStatusAddr))
+	}
+	if len(proxyExtraArgs) > 0 {
+		m.ProxyExtraArgs = strings.Join(proxyExtraArgs, " ")
+	}
+
 	m.ExtraArgs = ""
 
+	// TiFlash has no start timeout of its own, reuse the one of PD like TiKV does by default
+	m.StartTimeout = tc.PDStartTimeout()
+	m.DnsWaitInterval = int(tc.Spec.DnsWaitIntervalSeconds)
+	m.DnsWaitResolver = tc.Spec.DnsWaitResolver
+	m.NsLookupCmd = nsLookupCmd(tc, m.DnsWaitResolver)
+
+	waitForDnsNameIpMatchOnStartup := tidbTiFlashWaitForDnsNameIpMatch(tc)
+	skipDnsWaitOnStartup := slices.Contains(
+		tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagSkipDnsWait)
+
+	m.WaitForDnsNameIpMatch = waitForDnsNameIpMatchOnStartup && !skipDnsWaitOnStartup
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	tiflashStartScriptTpl, err := parseStartScriptTemplate(tc, "tiflash-start-script", tiflashStartSubScript,
+		commonScript(tc, "/etc/tiflash")+
+			replaceDnsLookupForStrictMode(tc, replaceDnsWaitThresholdUnit(tc, replaceDnsWaitSleep(tc, replaceTiFlashStartScriptDnsAwaitPart(tiflashStartScript, m.WaitForDnsNameIpMatch)))))
+	if err != nil {
+		return "", err
+	}
+
 	return renderTemplateFunc(tiflashStartScriptTpl, m)
 }
 
+// tiflashAdvertiseHost returns the host advertised by TiFlash, it refers to ${TIFLASH_POD_NAME} of the script.
+func tiflashAdvertiseHost(tc *v1alpha1.TidbCluster) string {
+	advertiseHost := fmt.Sprintf("${TIFLASH_POD_NAME}.%s.%s.svc", controller.TiFlashPeerMemberName(tc.Name), tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		advertiseHost = advertiseHost + "." + tc.Spec.ClusterDomain
+	}
+	return advertiseHost
+}
+
+// tiflashPDAddr returns the PD address used by TiFlash, the across-k8s model is returned
+// if the PD address is got from the across-k8s subscript at runtime.
+func tiflashPDAddr(tc *v1alpha1.TidbCluster) (string, *AcrossK8sScriptModel) {
+	if tc.AcrossK8s() {
+		acrossK8s := newAcrossK8sScriptModel(tc)
+		return "${result}", acrossK8s // get pd addr in subscript
+	}
+	if tc.Heterogeneous() && tc.WithoutLocalPD() {
+		return fmt.Sprintf("%s.%s.svc%s:%d", controller.PDMemberName(tc.Spec.Cluster.Name), tc.Spec.Cluster.Namespace,
+			controller.FormatClusterDomain(tc.Spec.Cluster.ClusterDomain), v1alpha1.DefaultPDClientPort), nil // use pd of reference cluster
+	}
+	return fmt.Sprintf("%s.%s.svc:%d", controller.PDMemberName(tc.Name), tc.Namespace, v1alpha1.DefaultPDClientPort), nil
+}
+
+func replaceTiFlashStartScriptDnsAwaitPart(startScript string, withLocalIpMatch bool) string {
+	if withLocalIpMatch {
+		// the blank line before ARGS is in the start script
+		return strings.ReplaceAll(startScript, dnsAwaitPart, strings.TrimSuffix(tiflashWaitForDnsIpMatchSubScript, "\n"))
+	}
+	return strings.ReplaceAll(startScript, dnsAwaitPart, "")
+}
+
 const (
 	// tiflashStartSubScript contains optional subscripts used in start script.
-	tiflashStartSubScript = ``
+	tiflashStartSubScript = `
+{{ define "AcrossK8sSubscript" }}
+pd_url={{ .AcrossK8s.PDAddr }}
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
+discovery_url={{ .AcrossK8s.DiscoveryAddr }}
+until result=$(` + acrossK8sVerifySubScript + acrossK8sStripPDSchemeSubScript + `); do` + acrossK8sMaxRetriesSubScript + `
+    echo "waiting for the verification of PD endpoints ..."
+    {{ if .AcrossK8s.MaxBackoff }}sleep $((RANDOM % {{ .AcrossK8s.MaxBackoff }})){{ else }}sleep 2{{ end }}
+done` + acrossK8sVerboseSubScript + `
+{{- end }}
+`
+
+	tiflashWaitForDnsIpMatchSubScript = `
+componentDomain={{ .AdvertiseHost }}
+waitThreshold={{ .StartTimeout }}
+nsLookupCmd="{{ .NsLookupCmd }}"
+` + componentCommonWaitForDnsIpMatchScript
 
 	// tiflashStartScript is the template of start script.
 	//
 	// Because init container of tiflash have core start script, so just to start tiflash there.
+	// The pod name is only resolved if the advertise host is used.
 	tiflashStartScript = `
+{{- if or .WaitForDnsNameIpMatch .ProxyExtraArgs }}
+
+TIFLASH_POD_NAME=${POD_NAME:-$HOSTNAME}
+{{- end }}` +
+		dnsAwaitPart + `
+
 ARGS="--config-file /data0/config.toml"
 {{- if .ExtraArgs }}
 ARGS="${ARGS} {{ .ExtraArgs }}"
 {{- end }}
+{{- if .ProxyExtraArgs }}
+# the args of the proxy are passed to tiflash as the overrides of its config, which follow "--"
+ARGS="${ARGS} -- {{ .ProxyExtraArgs }}"
+{{- end }}
 
 echo "starting tiflash-server ..."
 echo "/tiflash/tiflash ${ARGS}"
 exec /tiflash/tiflash server ${ARGS}
 `
 )
-
-var tiflashStartScriptTpl = template.Must(
-	template.Must(
-		template.New("tiflash-start-script").Parse(tiflashStartSubScript),
-	).Parse(componentCommonScript + tiflashStartScript),
-)
@@ -14,12 +14,21 @@
 package v2
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 func TestRenderTiFlashStartScript(t *testing.T) {
@@ -57,6 +66,384 @@ fi
 
 ARGS="--config-file /data0/config.toml"
 
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "prefer ipv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "specify cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.ClusterDomain = "cluster-1.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target", Namespace: "target-ns", ClusterDomain: "cluster-2.com"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "wait for dns name ip match of pd and tikv only",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				// TiDB and TiFlash do not wait without the TiDBTiFlashDnsNameIpMatch feature flag
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "wait for dns name ip match",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIFLASH_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIFLASH_POD_NAME}.start-script-test-tiflash-peer.start-script-test-ns.svc
+waitThreshold=30
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "skip dns wait",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagSkipDnsWait}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "enable dynamic configuration",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+				tc.Spec.ClusterDomain = "cluster-1.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIFLASH_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--config-file /data0/config.toml"
+# the args of the proxy are passed to tiflash as the overrides of its config, which follow "--"
+ARGS="${ARGS} -- --flash.proxy.advertise-status-addr=${TIFLASH_POD_NAME}.start-script-test-tiflash-peer.start-script-test-ns.svc.cluster-1.com:20292"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "dual stack",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
+echo "starting tiflash-server ..."
+echo "/tiflash/tiflash ${ARGS}"
+exec /tiflash/tiflash server ${ARGS}
+`,
+		},
+		{
+			name: "base port offset",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiFlash = &v1alpha1.TiFlashSpec{BasePortOffset: 10000}
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+ARGS="--config-file /data0/config.toml"
+
 echo "starting tiflash-server ..."
 echo "/tiflash/tiflash ${ARGS}"
 exec /tiflash/tiflash server ${ARGS}
@@ -87,3 +474,75 @@ exec /tiflash/tiflash server ${ARGS}
 		g.Expect(validateScript(script)).Should(gomega.Succeed())
 	}
 }
+
+func TestTiFlashProxyAdvertiseStatusAddr(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tikvAdvertiseStatusAddr := regexp.MustCompile(`--advertise-status-addr=(\S+):(\d+)`)
+	tiflashAdvertiseStatusAddr := regexp.MustCompile(`--flash.proxy.advertise-status-addr=(\S+):(\d+)`)
+
+	for _, clusterDomain := range []string{"", "cluster-1.com"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV:    &v1alpha1.TiKVSpec{},
+				TiFlash: &v1alpha1.TiFlashSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.ClusterDomain = clusterDomain
+		tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+
+		tikvScript, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		tiflashScript, err := RenderTiFlashStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+
+		tikvAddr := tikvAdvertiseStatusAddr.FindStringSubmatch(tikvScript)
+		g.Expect(tikvAddr).Should(gomega.HaveLen(3))
+		tiflashAddr := tiflashAdvertiseStatusAddr.FindStringSubmatch(tiflashScript)
+		g.Expect(tiflashAddr).Should(gomega.HaveLen(3))
+
+		expected := strings.NewReplacer("TIKV_POD_NAME", "TIFLASH_POD_NAME", "-tikv-peer", "-tiflash-peer").Replace(tikvAddr[1])
+		g.Expect(tiflashAddr[1]).Should(gomega.Equal(expected))
+		g.Expect(tiflashAddr[2]).Should(gomega.Equal("20292"))
+	}
+}
+
+func TestTiFlashProxyExtraArgs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, dynamic := range []bool{false, true} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiFlash: &v1alpha1.TiFlashSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(dynamic)
+
+		script, err := RenderTiFlashStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+
+		// run the script from the pod name with the args of tiflash printed one per line instead of exec
+		begin := strings.Index(script, "\nTIFLASH_POD_NAME=")
+		if !dynamic {
+			g.Expect(begin).Should(gomega.Equal(-1))
+			begin = strings.Index(script, "\nARGS=")
+		}
+		fragment := strings.Replace(script[begin:], "exec /tiflash/tiflash", "printf '%s\\n'", 1)
+		file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var stdout bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("HOSTNAME=tiflash-0", "PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, &stdout))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+		args := "server\n--config-file\n/data0/config.toml\n"
+		if dynamic {
+			args += "--\n--flash.proxy.advertise-status-addr=tiflash-0.start-script-test-tiflash-peer.start-script-test-ns.svc:20292\n"
+		}
+		g.Expect(stdout.String()).Should(gomega.HaveSuffix(args), "dynamic configuration %v", dynamic)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+)
+
+// tikvDirs contains the dirs of TiKV in the mounted volumes, they are shared by the start script
+// and the init script so that the init script creates exactly the dirs used by TiKV.
+type tikvDirs struct {
+	DataDir string
+	// WalDir is the dir of RocksDB WAL in the WAL volume, it is set in the config file.
+	// It is empty if the WAL volume is not configured.
+	WalDir string
+	// RaftDir is the dir of Raft Engine in the raft volume, it is set in the config file.
+	// It is empty if the raft volume is not mounted.
+	RaftDir string
+	// TitanDir is the dirname of Titan, it is set in the config file.
+	// It is empty if Titan is disabled or the titan volume is not configured.
+	TitanDir string
+}
+
+// newTiKVDirs returns the dirs of TiKV, the sub dirs in volumes are validated so that
+// they do not point outside the volumes.
+func newTiKVDirs(tc *v1alpha1.TidbCluster) (*tikvDirs, error) {
+	dirs := &tikvDirs{}
+
+	dir, err := dataDir(constants.TiKVDataVolumeMountPath, tc.Spec.TiKV.DataSubDir)
+	if err != nil {
+		return nil, err
+	}
+	dirs.DataDir = dir
+
+	dirs.WalDir = tc.Spec.TiKV.WalDir()
+
+	dirs.TitanDir = tc.Spec.TiKV.TitanDir()
+
+	if mountPath := tc.Spec.TiKV.VolumeMountPath(v1alpha1.TiKVRaftVolumeName); mountPath != "" {
+		dir, err := dataDir(mountPath, tc.Spec.TiKV.RaftEngineSubDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RaftEngineSubDir of TiKV: %v", err)
+		}
+		dirs.RaftDir = dir
+	}
+
+	return dirs, nil
+}
+
+// TiKVInitScriptModel contain fields for rendering TiKV init script
+type TiKVInitScriptModel struct {
+	tikvDirs
+}
+
+// Validate checks the fields required by TiKV init script
+func (m *TiKVInitScriptModel) Validate() error {
+	return validateModel("TiKV init",
+		validateRequired("DataDir", m.DataDir),
+	)
+}
+
+// RenderTiKVInitScript renders the script of the init container which creates the dirs of TiKV,
+// e.g. the parent dirs of a nested DataSubDir, before the start script runs.
+func RenderTiKVInitScript(tc *v1alpha1.TidbCluster) (string, error) {
+	dirs, err := newTiKVDirs(tc)
+	if err != nil {
+		return "", err
+	}
+	m := &TiKVInitScriptModel{tikvDirs: *dirs}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(tikvInitScriptTpl, m)
+}
+
+// tikvInitScript is the template of init script, the exit code of mkdir is the one of the script.
+const tikvInitScript = `#!/bin/sh
+
+set -uo pipefail
+
+echo "creating the dirs of tikv ..."
+mkdir -p {{ .DataDir }}
+{{- if .WalDir }} {{ .WalDir }}{{ end }}
+{{- if .RaftDir }} {{ .RaftDir }}{{ end }}
+{{- if .TitanDir }} {{ .TitanDir }}{{ end }}
+`
+
+var tikvInitScriptTpl = template.Must(template.New("tikv-init-script").Parse(tikvInitScript))
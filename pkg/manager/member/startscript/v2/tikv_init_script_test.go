@@ -0,0 +1,178 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+)
+
+func TestRenderTiKVInitScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name string
+
+		modifyTC     func(tc *v1alpha1.TidbCluster)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name:     "basic",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+echo "creating the dirs of tikv ..."
+mkdir -p /var/lib/tikv
+`,
+		},
+		{
+			name: "nested data sub dir",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "data/tikv"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+echo "creating the dirs of tikv ..."
+mkdir -p /var/lib/tikv/data/tikv
+`,
+		},
+		{
+			name: "wal, raft and titan volumes",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "data"
+				tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+				tc.Spec.TiKV.Config.Set("rocksdb.titan.enabled", true)
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{
+					{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"},
+					{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"},
+					{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"},
+				}
+				tc.Spec.TiKV.WALVolumeName = "wal"
+				tc.Spec.TiKV.TitanVolumeName = "titan"
+				tc.Spec.TiKV.RaftEngineSubDir = "raft-engine"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+echo "creating the dirs of tikv ..."
+mkdir -p /var/lib/tikv/data /var/lib/wal /var/lib/raft/raft-engine /var/lib/titan
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		script, err := RenderTiKVInitScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+
+	for _, subDir := range []string{"/data", "../data"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{DataSubDir: subDir},
+			},
+		}
+		_, err := RenderTiKVInitScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "data sub dir %q", subDir)
+	}
+}
+
+func TestRenderTiKVInitScriptDirsMatchStartScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the dirs used by the start script are passed by flags or created before starting TiKV
+	startScriptDirRegexp := regexp.MustCompile(`(?m)--data-dir=(\S+) \\$|^mkdir -p (\S+)$`)
+
+	modifies := []func(tc *v1alpha1.TidbCluster){
+		func(tc *v1alpha1.TidbCluster) {},
+		func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.DataSubDir = "a/b/c"
+		},
+		func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"}}
+			tc.Spec.TiKV.WALVolumeName = "wal"
+		},
+		func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.TiKV.DataSubDir = "data"
+			tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+			tc.Spec.TiKV.Config.Set("rocksdb.titan.enabled", true)
+			tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{
+				{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"},
+				{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"},
+				{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"},
+			}
+			tc.Spec.TiKV.WALVolumeName = "wal"
+			tc.Spec.TiKV.TitanVolumeName = "titan"
+			tc.Spec.TiKV.RaftEngineSubDir = "raft/engine"
+		},
+	}
+	for i, modify := range modifies {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		modify(tc)
+
+		startScript, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		startScriptDirs := []string{}
+		for _, match := range startScriptDirRegexp.FindAllStringSubmatch(startScript, -1) {
+			startScriptDirs = append(startScriptDirs, match[1]+match[2])
+		}
+		// the WAL dir is set in the config file
+		if dir := tc.Spec.TiKV.WalDir(); dir != "" {
+			startScriptDirs = append(startScriptDirs, dir)
+		}
+
+		initScript, err := RenderTiKVInitScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		mkdir := initScript[strings.Index(initScript, "mkdir -p "):]
+		initScriptDirs := strings.Fields(strings.TrimPrefix(mkdir, "mkdir -p "))
+
+		g.Expect(initScriptDirs).Should(gomega.ConsistOf(startScriptDirs), "case %d", i)
+	}
+}
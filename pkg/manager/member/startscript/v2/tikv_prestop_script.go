@@ -0,0 +1,158 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"path"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultTiKVPreStopTimeout = 300
+	// tikvPreStopRequestTimeout is the timeout (in seconds) of each request to PD, so that an unresponsive PD
+	// does not block the preStop hook beyond its Timeout.
+	tikvPreStopRequestTimeout = 3
+)
+
+// TiKVPreStopScriptModel contain fields for rendering TiKV preStop script
+type TiKVPreStopScriptModel struct {
+	PDAddr               string
+	PDScheme             string
+	AdvertiseAddr        string
+	CurlArgs             string
+	LeaderCountThreshold int32
+	Timeout              int
+	RequestTimeout       int
+	// SchedulerFile records the store whose evict leader scheduler is created by this script, the scheduler is
+	// removed by the start script after the store restarts.
+	SchedulerFile string
+
+	AcrossK8s *AcrossK8sScriptModel
+}
+
+// Validate checks the fields required by TiKV preStop script
+func (m *TiKVPreStopScriptModel) Validate() error {
+	return validateModel("TiKV preStop",
+		validateURLs("PDAddr", m.PDAddr),
+		validateRequired("PDScheme", m.PDScheme),
+		validateAddr("AdvertiseAddr", m.AdvertiseAddr),
+		validatePositive("Timeout", m.Timeout),
+		validatePositive("RequestTimeout", m.RequestTimeout),
+		validateAbsPath("SchedulerFile", m.SchedulerFile),
+		m.AcrossK8s.Validate(),
+	)
+}
+
+// RenderTiKVPreStopScript renders TiKV preStop script from TidbCluster,
+// it uses the same PD address as the TiKV start script.
+func RenderTiKVPreStopScript(tc *v1alpha1.TidbCluster) (string, error) {
+	m := &TiKVPreStopScriptModel{}
+
+	m.PDAddr, m.AcrossK8s = tikvPDAddr(tc)
+	if m.AcrossK8s != nil {
+		// the verification through discovery is counted in the timeout of the preStop hook
+		m.AcrossK8s.Deadline = true
+	}
+	m.PDScheme = tc.Scheme()
+	m.AdvertiseAddr = TiKVAdvertiseAddr(tc)
+
+	m.CurlArgs = tikvCurlArgs(tc)
+
+	m.Timeout = defaultTiKVPreStopTimeout
+	m.RequestTimeout = tikvPreStopRequestTimeout
+	m.SchedulerFile = tikvEvictLeaderSchedulerFile
+	if preStop := tc.Spec.TiKV.PreStop; preStop != nil {
+		m.LeaderCountThreshold = preStop.LeaderCountThreshold
+		if preStop.Timeout > 0 {
+			m.Timeout = preStop.Timeout
+		}
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(tikvPreStopScriptTpl, m)
+}
+
+// tikvCurlArgs returns the args of curl used by TiKV scripts, the cluster certs are used if TLS is enabled.
+func tikvCurlArgs(tc *v1alpha1.TidbCluster) string {
+	args := "-s --fail"
+	if tc.IsTLSClusterEnabled() {
+		args = fmt.Sprintf("%s --cacert %s --cert %s --key %s", args,
+			path.Join(constants.TiKVCertPath, corev1.ServiceAccountRootCAKey),
+			path.Join(constants.TiKVCertPath, corev1.TLSCertKey),
+			path.Join(constants.TiKVCertPath, corev1.TLSPrivateKeyKey))
+	}
+	return args
+}
+
+var tikvPreStopScriptTpl = template.Must(
+	template.Must(
+		template.New("tikv-prestop-script").Funcs(builtinTemplateFuncs).Parse(tikvStartSubScript),
+	).Parse(tikvPreStopScript),
+)
+
+// tikvPreStopScript is the template of preStop script.
+const tikvPreStopScript = `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + {{ .Timeout }} ))
+{{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
+
+PD_ADDR={{ .PDAddr }}
+PD_URL={{ .PDScheme }}://${PD_ADDR%%,*}
+ADVERTISE_ADDR={{ .AdvertiseAddr }}
+CURL="curl {{ .CurlArgs }} --max-time {{ .RequestTimeout }}"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > {{ .SchedulerFile }}
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le {{ .LeaderCountThreshold }} ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`
@@ -0,0 +1,585 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestRenderTiKVPreStopScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name string
+
+		modifyTC     func(tc *v1alpha1.TidbCluster)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name:     "basic",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 300 ))
+
+PD_ADDR=prestop-script-test-pd:2379
+PD_URL=http://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160
+CURL="curl -s --fail --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 0 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+		{
+			name: "custom threshold and timeout",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreStop = &v1alpha1.TiKVPreStopSpec{LeaderCountThreshold: 10, Timeout: 600}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 600 ))
+
+PD_ADDR=prestop-script-test-pd:2379
+PD_URL=http://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160
+CURL="curl -s --fail --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 10 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+		{
+			name: "enable tls",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 300 ))
+
+PD_ADDR=prestop-script-test-pd:2379
+PD_URL=https://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160
+CURL="curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 0 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+		{
+			name: "across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 300 ))
+pd_url=http://prestop-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=prestop-script-test-discovery.prestop-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "failed to verify PD endpoints before the deadline, skip" >&2
+        exit 0
+    fi
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+PD_ADDR=${result}
+PD_URL=http://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160
+CURL="curl -s --fail --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 0 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+		{
+			name: "across k8s with cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.ClusterDomain = "cluster-1.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 300 ))
+pd_url=http://prestop-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=prestop-script-test-discovery.prestop-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "failed to verify PD endpoints before the deadline, skip" >&2
+        exit 0
+    fi
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+PD_ADDR=${result}
+PD_URL=http://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.prestop-script-test-tikv-peer.prestop-script-test-ns.svc.cluster-1.com:20160
+CURL="curl -s --fail --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 0 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+		{
+			name: "advertise host suffix",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdvertiseHostSuffix = "tikv.example.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+# the preStop hook is bounded by the wall clock, the time of the requests to discovery and PD is also counted
+deadline=$(( $(date +%s) + 300 ))
+
+PD_ADDR=prestop-script-test-pd:2379
+PD_URL=http://${PD_ADDR%%,*}
+ADVERTISE_ADDR=${TIKV_POD_NAME}.tikv.example.com:20160
+CURL="curl -s --fail --max-time 3"
+
+# the stores are split into lines, one store per line, regardless of the format of the JSON returned by PD
+store_id=$(${CURL} ${PD_URL}/pd/api/v1/stores | tr -d ' \t\r\n' | sed 's/{"store":{/\n/g' | grep -F "\"address\":\"${ADVERTISE_ADDR}\"" | grep -o '"id":[0-9]*' | head -n 1 | cut -d : -f 2)
+if [ -z "${store_id}" ]; then
+    echo "store of ${ADVERTISE_ADDR} is not found, skip evicting leaders"
+    exit 0
+fi
+
+# the scheduler may be created by others, e.g. the upgrader of the operator, it is only recorded to be removed
+# by the start script if it is created by this script
+schedulers=$(${CURL} ${PD_URL}/pd/api/v1/schedulers; ${CURL} ${PD_URL}/pd/api/v1/scheduler-config/evict-leader-scheduler/list)
+if echo "${schedulers}" | grep -q -e "\"evict-leader-scheduler-${store_id}\"" -e "\"${store_id}\":"; then
+    echo "leaders of store ${store_id} are already being evicted"
+else
+    echo "evicting leaders of store ${store_id} ..."
+    ${CURL} -X POST -d "{\"name\":\"evict-leader-scheduler\",\"store_id\":${store_id}}" ${PD_URL}/pd/api/v1/schedulers && echo "${store_id}" > /var/lib/tikv/.evict-leader-scheduler
+fi
+
+while true; do
+    leader_count=$(${CURL} ${PD_URL}/pd/api/v1/store/${store_id} | tr -d ' \t\r\n' | sed -n 's/.*"leader_count":\([0-9]*\).*/\1/p')
+    if [[ -n "${leader_count}" && ${leader_count} -le 0 ]]; then
+        echo "leader count of store ${store_id} is ${leader_count}"
+        break
+    fi
+
+    if [[ $(date +%s) -ge ${deadline} ]]; then
+        echo "waiting for evicting leaders of store ${store_id} timeout, the leader count is ${leader_count:-unknown}" >&2
+        break
+    fi
+
+    sleep 1
+done
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "prestop-script-test"
+		tc.Namespace = "prestop-script-test-ns"
+		tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: false}
+
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		script, err := RenderTiKVPreStopScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestTiKVPreStopScriptPDAddr(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	startScriptPDAddr := regexp.MustCompile(`--pd=(\S+) \\`)
+	preStopScriptPDAddr := regexp.MustCompile(`PD_ADDR=(\S+)`)
+
+	cases := map[string]func(tc *v1alpha1.TidbCluster){
+		"basic": func(tc *v1alpha1.TidbCluster) {},
+		"across k8s": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.AcrossK8s = true
+		},
+		"heterogeneous without local pd": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.PD = nil
+			tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target"}
+		},
+		"multiple pd addresses": func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.PD.Replicas = 3
+			tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+		},
+	}
+
+	for name, modify := range cases {
+		t.Logf("test case: %s", name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:   &v1alpha1.PDSpec{Replicas: 1},
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "prestop-script-test"
+		tc.Namespace = "prestop-script-test-ns"
+		modify(tc)
+
+		startScript, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		preStopScript, err := RenderTiKVPreStopScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+
+		expected := startScriptPDAddr.FindStringSubmatch(startScript)
+		g.Expect(expected).Should(gomega.HaveLen(2))
+		got := preStopScriptPDAddr.FindStringSubmatch(preStopScript)
+		g.Expect(got).Should(gomega.HaveLen(2))
+		g.Expect(got[1]).Should(gomega.Equal(expected[1]), "pd addr of test case %s", name)
+	}
+}
+
+func TestTiKVPreStopScriptWaitForLeaders(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		name      string
+		leaders   string
+		threshold int32
+		timeout   int
+		// existing is the evict leader scheduler of the store created by others, it is listed by the
+		// name in old PD versions and by the config in new ones
+		existing string
+		// compact is set if PD returns the JSON without indents
+		compact bool
+		// hostname is the pod of the store, defaults to tikv-0
+		hostname string
+		expect   string
+		elapsed  string
+	}{
+		{name: "leaders evicted", leaders: "3", timeout: 10, expect: "leader count of store 4 is 0", elapsed: "3"},
+		{name: "leaders under threshold", leaders: "3", threshold: 1, timeout: 10, expect: "leader count of store 4 is 1", elapsed: "2"},
+		{name: "leaders left", leaders: "100", timeout: 5, expect: "timeout, the leader count is 95", elapsed: "5"},
+		{name: "pd unavailable", leaders: "", timeout: 5, expect: "timeout, the leader count is unknown", elapsed: "5"},
+		{name: "scheduler listed by name", leaders: "3", timeout: 10, existing: "name", expect: "leader count of store 4 is 0", elapsed: "3"},
+		{name: "scheduler listed by config", leaders: "3", timeout: 10, existing: "config", expect: "leader count of store 4 is 0", elapsed: "3"},
+		{name: "compact json", leaders: "3", timeout: 10, compact: true, expect: "leader count of store 4 is 0", elapsed: "3"},
+		{name: "store not found", leaders: "3", timeout: 10, hostname: "tikv-2", expect: "store of tikv-2.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160 is not found"},
+	}
+	for _, c := range cases {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				PD:   &v1alpha1.PDSpec{Replicas: 1},
+				TiKV: &v1alpha1.TiKVSpec{PreStop: &v1alpha1.TiKVPreStopSpec{LeaderCountThreshold: c.threshold, Timeout: c.timeout}},
+			},
+		}
+		tc.Name = "prestop-script-test"
+		tc.Namespace = "prestop-script-test-ns"
+		script, err := RenderTiKVPreStopScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		// the store of the created scheduler is recorded in a temp file instead of the data volume
+		schedulerFile := filepath.Join(t.TempDir(), "evict-leader-scheduler")
+		script = strings.ReplaceAll(script, tikvEvictLeaderSchedulerFile, schedulerFile)
+		g.Expect(script).Should(gomega.ContainSubstring(fmt.Sprintf("deadline=$(( $(date +%%s) + %d ))", c.timeout)))
+		g.Expect(script).Should(gomega.ContainSubstring(fmt.Sprintf("--max-time %d", tikvPreStopRequestTimeout)))
+
+		// curl, date and sleep are replaced by functions, the clock is advanced by sleep and a leader is
+		// evicted per second, so that the elapsed seconds are counted to check the bound of waiting
+		fakes := `now=0
+date() { echo ${now}; }
+sleep() { now=$(( now+$1 )); }
+curl() {
+    case "$*" in
+    *"-X POST"*) echo "POST $*" ;;
+    *"-X DELETE"*) echo "DELETE $*" ;;
+    *"/pd/api/v1/schedulers") [ "${EXISTING}" = name ] && echo '["balance-leader-scheduler","evict-leader-scheduler-4"]' ;;
+    *"/evict-leader-scheduler/list") [ "${EXISTING}" = config ] && echo '{"store-id-ranges":{"4":[{"start-key":"","end-key":""}]}}' ;;
+    *"/pd/api/v1/stores") printf "${STORES}" ;;
+    *"/pd/api/v1/store/4") [ -n "${LEADERS}" ] && echo "  \"leader_count\": $(( LEADERS > now ? LEADERS-now : 0 )),"  ;;
+    esac
+}
+`
+		// the stores other than the one of tikv-0 are listed to check the lookup by the address
+		stores := `{\n  "count": 2,\n  "stores": [\n    {\n      "store": {\n        "id": 1,\n        "address": "tikv-1.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160",\n        "labels": [\n          {\n            "key": "zone",\n            "value": "a"\n          }\n        ]\n      },\n      "status": {\n        "leader_count": 5\n      }\n    },\n    {\n      "store": {\n        "id": 4,\n        "address": "tikv-0.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160"\n      },\n      "status": {\n        "leader_count": 3\n      }\n    }\n  ]\n}\n`
+		if c.compact {
+			stores = `{"count":2,"stores":[{"store":{"id":1,"address":"tikv-1.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160","labels":[{"key":"zone","value":"a"}]},"status":{"leader_count":5}},{"store":{"id":4,"address":"tikv-0.prestop-script-test-tikv-peer.prestop-script-test-ns.svc:20160"},"status":{"leader_count":3}}]}`
+		}
+		hostname := c.hostname
+		if hostname == "" {
+			hostname = "tikv-0"
+		}
+		file, err := syntax.NewParser().Parse(strings.NewReader(fakes+script+`echo "elapsed ${now}"`), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var out bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("LEADERS="+c.leaders, "EXISTING="+c.existing, "STORES="+stores, "HOSTNAME="+hostname, "PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &out, &out))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(out.String()).Should(gomega.ContainSubstring(c.expect), "case %s", c.name)
+		if c.hostname != "" {
+			// the script exits without evicting leaders if the store is not found
+			g.Expect(out.String()).ShouldNot(gomega.ContainSubstring("POST"), "case %s", c.name)
+			g.Expect(out.String()).ShouldNot(gomega.ContainSubstring("elapsed"), "case %s", c.name)
+			continue
+		}
+		post := `POST -s --fail --max-time 3 -X POST -d {"name":"evict-leader-scheduler","store_id":4} http://prestop-script-test-pd:2379/pd/api/v1/schedulers`
+		// the scheduler is kept while the store is stopped, it is removed by the start script after the store restarts
+		g.Expect(out.String()).ShouldNot(gomega.ContainSubstring("DELETE"), "case %s", c.name)
+		if c.existing != "" {
+			// the scheduler created by others is not recorded
+			g.Expect(out.String()).Should(gomega.ContainSubstring("leaders of store 4 are already being evicted"), "case %s", c.name)
+			g.Expect(out.String()).ShouldNot(gomega.ContainSubstring(post), "case %s", c.name)
+			g.Expect(schedulerFile).ShouldNot(gomega.BeAnExistingFile(), "case %s", c.name)
+		} else {
+			g.Expect(out.String()).Should(gomega.ContainSubstring(post), "case %s", c.name)
+			g.Expect(os.ReadFile(schedulerFile)).Should(gomega.Equal([]byte("4\n")), "case %s", c.name)
+		}
+		g.Expect(out.String()).Should(gomega.HaveSuffix(fmt.Sprintf("elapsed %s\n", c.elapsed)), "case %s", c.name)
+	}
+}
+
+func TestTiKVPreStopScriptAcrossK8sDeadline(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the interpreter of mvdan.cc/sh does not return the exit status of command substitutions in assignments,
+	// so bash is used to fail the verification
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not found")
+	}
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			PD:        &v1alpha1.PDSpec{Replicas: 1},
+			TiKV:      &v1alpha1.TiKVSpec{PreStop: &v1alpha1.TiKVPreStopSpec{Timeout: 10}},
+			AcrossK8s: true,
+		},
+	}
+	tc.Name = "prestop-script-test"
+	tc.Namespace = "prestop-script-test-ns"
+	script, err := RenderTiKVPreStopScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+
+	// discovery never verifies the PD endpoints, the verification is given up at the deadline
+	fakes := `now=0
+date() { echo ${now}; }
+sleep() { now=$(( now+1 )); }
+wget() { return 1; }
+curl() { echo "curl $*"; }
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bash, "-c", fakes+script)
+	cmd.Env = []string{"HOSTNAME=tikv-0", "PATH=" + os.Getenv("PATH")}
+	out, err := cmd.CombinedOutput()
+	g.Expect(err).Should(gomega.Succeed(), string(out))
+	g.Expect(string(out)).Should(gomega.ContainSubstring("failed to verify PD endpoints before the deadline, skip"))
+	g.Expect(string(out)).ShouldNot(gomega.ContainSubstring("curl"))
+	g.Expect(strings.Count(string(out), "waiting for the verification of PD endpoints")).Should(gomega.Equal(10))
+}
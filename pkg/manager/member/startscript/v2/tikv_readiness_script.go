@@ -0,0 +1,81 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// TiKVReadinessScriptModel contain fields for rendering TiKV readiness script
+type TiKVReadinessScriptModel struct {
+	StatusURL string
+	CurlArgs  string
+}
+
+// Validate checks the fields required by TiKV readiness script
+func (m *TiKVReadinessScriptModel) Validate() error {
+	return validateModel("TiKV readiness",
+		validateURL("StatusURL", m.StatusURL),
+	)
+}
+
+// RenderTiKVReadinessScript renders TiKV readiness script from TidbCluster,
+// it probes the status server which TiKV start script configures.
+func RenderTiKVReadinessScript(tc *v1alpha1.TidbCluster) (string, error) {
+	if tc.Spec.TiKV.DisableStatusServer {
+		return "", fmt.Errorf("the status server of TiKV is disabled, it can not be probed")
+	}
+	m := &TiKVReadinessScriptModel{}
+
+	m.StatusURL = fmt.Sprintf("%s://%s:%d/status", tc.Scheme(),
+		urlHost(tikvStatusProbeHost(tikvStatusListenHost(tc))), v1alpha1.DefaultTiKVStatusPort)
+	m.CurlArgs = tikvCurlArgs(tc)
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(tikvReadinessScriptTpl, m)
+}
+
+// tikvStatusProbeHost returns the host used to access the status server in the pod,
+// the wildcard hosts are replaced by the loopback addresses.
+func tikvStatusProbeHost(listenHost string) string {
+	switch listenHost {
+	case "0.0.0.0":
+		return "127.0.0.1"
+	case "[::]":
+		return "[::1]"
+	default:
+		return listenHost
+	}
+}
+
+var tikvReadinessScriptTpl = template.Must(template.New("tikv-readiness-script").Parse(tikvReadinessScript))
+
+// tikvReadinessScript is the template of readiness script.
+const tikvReadinessScript = `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl {{ .CurlArgs }} --globoff -o /dev/null {{ .StatusURL }}; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`
@@ -0,0 +1,223 @@
+// Copyright 2026 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega"
+)
+
+func TestRenderTiKVReadinessScript(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type testcase struct {
+		name string
+
+		modifyTC     func(tc *v1alpha1.TidbCluster)
+		expectScript string
+	}
+
+	cases := []testcase{
+		{
+			name:     "basic",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --globoff -o /dev/null http://127.0.0.1:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "enable tls",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key --globoff -o /dev/null https://127.0.0.1:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "prefer ipv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --globoff -o /dev/null http://[::1]:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "prefer ipv6 and enable tls",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key --globoff -o /dev/null https://[::1]:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "set ipv4 status listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "10.0.0.1"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --globoff -o /dev/null http://10.0.0.1:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "set ipv6 status listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "fd00::1"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --globoff -o /dev/null http://[fd00::1]:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+		{
+			name: "set ipv6 status listen host with zone",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "fe80::1%eth0"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+# globoff is required to access IPv6 addresses enclosed in brackets
+if ! curl -s --fail --globoff -o /dev/null http://[fe80::1%25eth0]:20180/status; then
+    echo "store is not up yet" >&2
+    exit 1
+fi
+echo "store is up"
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "readiness-script-test"
+		tc.Namespace = "readiness-script-test-ns"
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		script, err := RenderTiKVReadinessScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestTiKVReadinessScriptStatusAddr(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	statusAddrRegexp := regexp.MustCompile(`--status-addr=(\S+):(\d+) `)
+	statusURLRegexp := regexp.MustCompile(`https?://(\S+):(\d+)/status`)
+	loopback := map[string]string{"0.0.0.0": "127.0.0.1", "[::]": "[::1]"}
+
+	modifies := []func(tc *v1alpha1.TidbCluster){
+		func(tc *v1alpha1.TidbCluster) {},
+		func(tc *v1alpha1.TidbCluster) { tc.Spec.PreferIPv6 = true },
+		func(tc *v1alpha1.TidbCluster) {
+			tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+		},
+		func(tc *v1alpha1.TidbCluster) { tc.Spec.TiKV.StatusListenHost = "10.0.0.1" },
+		func(tc *v1alpha1.TidbCluster) { tc.Spec.TiKV.StatusListenHost = "fd00::1" },
+	}
+	for _, modify := range modifies {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "readiness-script-test"
+		tc.Namespace = "readiness-script-test-ns"
+		modify(tc)
+
+		startScript, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		readinessScript, err := RenderTiKVReadinessScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+
+		statusAddr := statusAddrRegexp.FindStringSubmatch(startScript)
+		g.Expect(statusAddr).Should(gomega.HaveLen(3))
+		statusURL := statusURLRegexp.FindStringSubmatch(readinessScript)
+		g.Expect(statusURL).Should(gomega.HaveLen(3))
+
+		expectHost := statusAddr[1]
+		if host, ok := loopback[expectHost]; ok {
+			expectHost = host
+		}
+		g.Expect(statusURL[1]).Should(gomega.Equal(expectHost))
+		g.Expect(statusURL[2]).Should(gomega.Equal(statusAddr[2]))
+	}
+}
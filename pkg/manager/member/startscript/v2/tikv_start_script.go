@@ -16,145 +16,1357 @@ package v2
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/apis/util/config"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	"github.com/pingcap/tidb-operator/pkg/manager/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // TiKVStartScriptModel contain fields for rendering TiKV start script
 type TiKVStartScriptModel struct {
-	PDAddr         string
-	Addr           string
-	StatusAddr     string
-	AdvertiseHost  string
-	AdvertiseAddr  string
-	DataDir        string
-	Capacity       string
-	ExtraArgs      string
-	KVStartTimeout int
+	PDAddr           string
+	Addr             string
+	StatusListenHost string
+	StatusAddr       string
+	AdvertiseHost    string
+	AdvertisePort    int32
+	AdvertiseAddr    string
+	DataDir          string
+	WalDir           string
+	TitanDir         string
+	RaftDir          string
+	Capacity         string
+	LogLevel         string
+	NsLookupCmd      string
 
-	AcrossK8s *AcrossK8sScriptModel
+	// DnsWaitThreshold is the threshold of waiting for the DNS record of TiKV to match the Pod IP,
+	// it is counted in attempts or seconds according to the DnsWaitThresholdUnit of TidbCluster.
+	DnsWaitThreshold int
+	// DnsWaitInterval is the seconds to sleep between the attempts of resolving the domain, 0 means 1 second.
+	DnsWaitInterval int
+	// DnsWaitResolver is the IP address of the DNS server queried by NsLookupCmd, the resolver of the host
+	// is used if it is empty.
+	DnsWaitResolver string
+
+	// ConfigPath is the config file passed to TiKV, it is generated at runtime from the mounted
+	// one if some settings are only known in the Pod, e.g. the CPU limit.
+	ConfigPath string
+	// InlineConfig is the content of the config file written to the path of the mounted one by a heredoc
+	// before it is used, so that the ConfigMap of the config file needs not be mounted.
+	// The config file is expected to be mounted if it is empty.
+	InlineConfig string
+	// CpuQuota refers to the CPU limit of the container in millicores injected by the downward API,
+	// it is empty unless the TiKVCPUQuota feature flag is set and the CPU limit is configured.
+	CpuQuota string
+	// SizeThreadPools indicates whether to size the unified read pool and the scheduler worker pool
+	// of TiKV by CpuQuota in the config file, TiKV has no flags for them.
+	SizeThreadPools bool
+	// ApiVersion is the storage.api-version set in the config file, it is recorded in DataDir on the first start
+	// and TiKV is not started if it differs from the recorded one, 0 means using the one in the config file.
+	ApiVersion int
+	// ReserveSpacePercent is the percentage of Capacity set as storage.reserve-space in the config file at runtime,
+	// the size of the file system of DataDir is used if Capacity is 0, 0 means using the one in the config file.
+	ReserveSpacePercent int
+	// GCNumThreads is the gc.num-threads set in the config file at runtime, 0 means using the one in the config file.
+	GCNumThreads int
+	// RegionSplitSize and RegionMaxSize (in MiB) are the coprocessor.region-split-size and region-max-size set in
+	// the config file at runtime, 0 means using the one in the config file.
+	RegionSplitSize int64
+	RegionMaxSize   int64
+	// MemoryLimit refers to the memory limit of the container in bytes injected by the downward API, it is only
+	// set if BlockCacheMemoryPercent is set.
+	MemoryLimit string
+	// BlockCacheMemoryPercent is the percentage of MemoryLimit set as storage.block-cache.capacity in the config
+	// file at runtime, 0 means using the one in the config file.
+	BlockCacheMemoryPercent int
+
+	// LogFile is the file TiKV logs to, TiKV logs to stdout if it is empty.
+	LogFile string
+	// LogMaxSize (in MB) and LogMaxBackups are the rotation settings of LogFile, they are appended to
+	// the config file at runtime as TiKV has no flags for them, 0 means using the default of TiKV.
+	LogMaxSize    int32
+	LogMaxBackups int32
+
+	// Prologue is the script from the spec of TidbCluster inserted verbatim before composing ARGS,
+	// e.g. for the vendor-specific setup of the node, it is not rendered as a template.
+	Prologue string
+
+	// StartupDelaySeconds is the seconds to sleep before any network operation, no sleep if it is not positive
+	StartupDelaySeconds int
+
+	// ExtraArgs are the flags generated by the operator, they are trusted and rendered into ARGS as they are.
+	ExtraArgs string
+	// UserArgs are the flags got from the spec of TidbCluster, they are not trusted and quoted by shellQuote,
+	// so each of them is passed to TiKV as one argument without any expansion by shell.
+	UserArgs string
+
+	// DisableStatusServer indicates that the status server of TiKV is disabled, StatusAddr is empty in this case
+	DisableStatusServer bool
+
+	// FixDataDirPermissions indicates whether to fix the owner and mode of DataDir before starting TiKV
+	FixDataDirPermissions bool
+	// CheckDataDirFsync indicates whether to write and fsync a file in DataDir before starting TiKV
+	CheckDataDirFsync bool
+	// MinFreeSpace is the minimum free bytes of the file system of DataDir to start TiKV, no check if it is 0
+	MinFreeSpace int64
+
+	// StoreLabels are static labels of the store, they are rendered in key order
+	// to keep the start script stable.
+	StoreLabels map[string]string
+	// TopologyLabels maps the store label keys to the well-known topology keys of Pod labels, e.g. zone to
+	// topology.kubernetes.io/zone, they are read from PodLabelsFile at runtime. The start script exits if none of
+	// them is set, as they are only set on the Pod if they are copied from the Node.
+	TopologyLabels map[string]string
+	// PodLabelKeys are the keys of Pod labels read from PodLabelsFile as store labels at runtime,
+	// the store labels which are already set take precedence over them.
+	PodLabelKeys  []string
+	PodLabelsFile string
+
+	// BinaryPath is the path of tikv-server binary, it defaults to /tikv-server
+	BinaryPath string
+	// ArchBinaryPaths are the paths of tikv-server binary keyed by the arches, e.g. amd64 and arm64, the path is
+	// selected by the arch reported by `uname -m` at runtime, BinaryPath is used for the other arches.
+	ArchBinaryPaths map[string]string
+	// ArgsPerLine indicates whether to print the arguments of tikv-server one per line instead of in one line
+	ArgsPerLine bool
+
+	// TextfileDir is the textfile dir of node-exporter where the start script writes ModelChecksum as a metric,
+	// nothing is written if it is empty or the dir is not mounted.
+	TextfileDir  string
+	TextfileName string
+	// ModelChecksum is the checksum of the other fields of the model, it is only set if TextfileDir is set.
+	ModelChecksum string `json:"-"`
+
+	// ExecAttempts is the number of attempts to exec tikv-server, the start script exits
+	// on the first failure of exec if it is 1.
+	ExecAttempts int
+
+	// NumaNode is the NUMA node which TiKV server is bound to by numactl,
+	// it is empty if TiKV server is not bound to any NUMA node.
+	NumaNode string
+
+	// RecoverMode indicates that TiKV is started with a fresh data dir after its disk is replaced,
+	// a banner is printed in this mode, tikv-server is started with the same flags.
+	RecoverMode bool
+
+	// PDLeaderWait is set if TiKV waits until the PD cluster has a leader before starting
+	PDLeaderWait *TiKVPDLeaderWait
+
+	// EvictLeaderCleanup is set if the evict leader scheduler created by the preStop script is removed before starting
+	EvictLeaderCleanup *TiKVEvictLeaderCleanup
+
+	// ReadinessFile is set if a watcher is started in background to touch a file once TiKV is up
+	ReadinessFile *TiKVReadinessFile
+
+	// MetricsPush is set if a sender is started in background to push the metrics of TiKV to a pushgateway
+	MetricsPush *TiKVMetricsPush
+
+	// InMemoryEngine is set if the data dir is on a memory-backed mount and the in-memory engine is enabled
+	InMemoryEngine *TiKVInMemoryEngine
+
+	// PDSRV is set if PDAddr is resolved from SRV records in the start script
+	PDSRV *TiKVPDSRV
+
+	AcrossK8s       *AcrossK8sScriptModel
+	ResolvedSummary *ResolvedSummary
+}
+
+// Validate checks the fields required by TiKV start script
+func (m *TiKVStartScriptModel) Validate() error {
+	var statusAddrErr, textfileDirErr, logFileErr error
+	if !m.DisableStatusServer {
+		statusAddrErr = validateAddr("StatusAddr", m.StatusAddr)
+	}
+	if m.TextfileDir != "" {
+		textfileDirErr = validateAbsPath("TextfileDir", m.TextfileDir)
+	}
+	if m.LogFile != "" {
+		logFileErr = validateAbsPath("LogFile", m.LogFile)
+	} else if m.LogMaxSize != 0 || m.LogMaxBackups != 0 {
+		logFileErr = fmt.Errorf("LogMaxSize and LogMaxBackups require LogFile")
+	}
+	var apiVersionErr error
+	if m.ApiVersion != 0 && m.ApiVersion != 1 && m.ApiVersion != 2 {
+		apiVersionErr = fmt.Errorf("ApiVersion %d must be 1 or 2", m.ApiVersion)
+	}
+	var reserveSpaceErr error
+	if m.ReserveSpacePercent < 0 || m.ReserveSpacePercent >= 100 {
+		reserveSpaceErr = fmt.Errorf("ReserveSpacePercent %d must be in [0, 100)", m.ReserveSpacePercent)
+	}
+	var gcNumThreadsErr error
+	if m.GCNumThreads < 0 || m.GCNumThreads > tikvMaxGCNumThreads {
+		gcNumThreadsErr = fmt.Errorf("GCNumThreads %d must be in [1, %d]", m.GCNumThreads, tikvMaxGCNumThreads)
+	}
+	var regionSizeErr error
+	if m.RegionSplitSize < 0 || m.RegionMaxSize < 0 {
+		regionSizeErr = fmt.Errorf("RegionSplitSize %d and RegionMaxSize %d must not be negative", m.RegionSplitSize, m.RegionMaxSize)
+	} else if m.RegionSplitSize != 0 && m.RegionMaxSize != 0 && m.RegionMaxSize < m.RegionSplitSize {
+		regionSizeErr = fmt.Errorf("RegionMaxSize %d must not be less than RegionSplitSize %d", m.RegionMaxSize, m.RegionSplitSize)
+	}
+	var blockCacheErr error
+	if m.BlockCacheMemoryPercent < 0 || m.BlockCacheMemoryPercent > tikvMaxBlockCacheMemoryPercent {
+		blockCacheErr = fmt.Errorf("BlockCacheMemoryPercent %d must be in [1, %d]", m.BlockCacheMemoryPercent, tikvMaxBlockCacheMemoryPercent)
+	} else if m.BlockCacheMemoryPercent != 0 && m.MemoryLimit == "" {
+		blockCacheErr = fmt.Errorf("BlockCacheMemoryPercent requires MemoryLimit")
+	}
+	var minFreeSpaceErr error
+	if m.MinFreeSpace < 0 {
+		minFreeSpaceErr = fmt.Errorf("MinFreeSpace %d must not be negative", m.MinFreeSpace)
+	}
+	var threadPoolsErr error
+	if m.SizeThreadPools && m.CpuQuota == "" {
+		threadPoolsErr = fmt.Errorf("SizeThreadPools requires CpuQuota")
+	}
+	var prologueErr error
+	if m.Prologue != "" && strings.TrimSpace(m.Prologue) == "" {
+		prologueErr = fmt.Errorf("Prologue must not only contain whitespace")
+	}
+	var inlineConfigErr error
+	if m.InlineConfig != "" {
+		inlineConfigErr = validateInlineConfig(m.InlineConfig)
+	}
+	return validateModel("TiKV start",
+		validateURLs("PDAddr", m.PDAddr),
+		validateAddr("Addr", m.Addr),
+		statusAddrErr,
+		validateRequired("AdvertiseHost", m.AdvertiseHost),
+		validatePort("AdvertisePort", m.AdvertisePort),
+		validateAddr("AdvertiseAddr", m.AdvertiseAddr),
+		validateRequired("DataDir", m.DataDir),
+		validateRequired("Capacity", m.Capacity),
+		validateResolver("DnsWaitResolver", m.DnsWaitResolver),
+		validateRequired("ConfigPath", m.ConfigPath),
+		validateAbsPath("BinaryPath", m.BinaryPath),
+		validateArchBinaryPaths(m.ArchBinaryPaths),
+		validatePositive("ExecAttempts", m.ExecAttempts),
+		validateLabelKeys("PodLabelKeys", m.PodLabelKeys),
+		textfileDirErr,
+		logFileErr,
+		prologueErr,
+		inlineConfigErr,
+		threadPoolsErr,
+		apiVersionErr,
+		reserveSpaceErr,
+		gcNumThreadsErr,
+		regionSizeErr,
+		blockCacheErr,
+		minFreeSpaceErr,
+		m.PDLeaderWait.Validate(),
+		m.EvictLeaderCleanup.Validate(),
+		m.ReadinessFile.Validate(),
+		m.MetricsPush.Validate(),
+		m.InMemoryEngine.Validate(),
+		m.PDSRV.Validate(),
+		m.AcrossK8s.Validate(),
+		m.ResolvedSummary.Validate(),
+	)
+}
+
+// validateInlineConfig checks that the inline config is valid TOML and does not terminate its heredoc early.
+func validateInlineConfig(inlineConfig string) error {
+	if err := config.New(nil).UnmarshalTOML([]byte(inlineConfig)); err != nil {
+		return fmt.Errorf("InlineConfig is not valid TOML: %v", err)
+	}
+	for _, line := range strings.Split(inlineConfig, "\n") {
+		if line == tikvInlineConfigDelimiter {
+			return fmt.Errorf("InlineConfig must not contain the line %q, which is the delimiter of its heredoc", tikvInlineConfigDelimiter)
+		}
+	}
+	return nil
+}
+
+// archRegexp matches the arches of ArchBinaryPaths, which are rendered as the patterns of case without quoting.
+var archRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// validateArchBinaryPaths checks that the arches are valid and the paths are clean absolute paths.
+func validateArchBinaryPaths(paths map[string]string) error {
+	arches := make([]string, 0, len(paths))
+	for arch := range paths {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	for _, arch := range arches {
+		if !archRegexp.MatchString(arch) {
+			return fmt.Errorf("arch %q of ArchBinaryPaths must only contain lowercase letters, digits and '_'", arch)
+		}
+		if err := validateAbsPath(fmt.Sprintf("ArchBinaryPaths[%s]", arch), paths[arch]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseArchBinaryPaths parses the paths of binary per arch in the form of "amd64=/amd64/tikv-server,arm64=/arm64/tikv-server".
+func parseArchBinaryPaths(v string) (map[string]string, error) {
+	paths := map[string]string{}
+	for _, item := range strings.Split(v, ",") {
+		arch, path, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || arch == "" || path == "" {
+			return nil, fmt.Errorf("%q is not in the form of arch=path", item)
+		}
+		if _, ok := paths[arch]; ok {
+			return nil, fmt.Errorf("arch %q is duplicated", arch)
+		}
+		paths[arch] = path
+	}
+	return paths, nil
+}
+
+// TiKVPDLeaderWait contains fields for waiting for the PD leader, the PD leader is got from
+// the first address of PDAddr, so that it is resolved in the same way as the one passed to TiKV.
+type TiKVPDLeaderWait struct {
+	PDScheme string
+	CurlArgs string
+	// Timeout is the seconds to wait for the PD leader, TiKV is started anyway after it.
+	Timeout int
+}
+
+// Validate checks the fields required by waiting for the PD leader, nil is valid as the wait is optional
+func (w *TiKVPDLeaderWait) Validate() error {
+	if w == nil {
+		return nil
+	}
+	return validateModel("PD leader wait",
+		validateRequired("PDScheme", w.PDScheme),
+		validatePositive("Timeout", w.Timeout),
+	)
+}
+
+// TiKVEvictLeaderCleanup contains fields for removing the evict leader scheduler recorded in File by the preStop
+// script, the scheduler is kept while the store is stopped so that its leaders are not moved back until it restarts.
+type TiKVEvictLeaderCleanup struct {
+	File     string
+	PDScheme string
+	CurlArgs string
+}
+
+// Validate checks the fields required by removing the evict leader scheduler, nil is valid as the cleanup is optional
+func (c *TiKVEvictLeaderCleanup) Validate() error {
+	if c == nil {
+		return nil
+	}
+	return validateModel("evict leader cleanup",
+		validateAbsPath("File", c.File),
+		validateRequired("PDScheme", c.PDScheme),
+	)
+}
+
+// TiKVReadinessFile contains fields for touching File once the status server of TiKV is up, the status server
+// is started after the store is registered to PD, so that the file tells that TiKV has joined the cluster.
+type TiKVReadinessFile struct {
+	File      string
+	StatusURL string
+	CurlArgs  string
+}
+
+// Validate checks the fields required by the readiness file, nil is valid as the file is optional
+func (f *TiKVReadinessFile) Validate() error {
+	if f == nil {
+		return nil
+	}
+	return validateModel("readiness file",
+		validateAbsPath("File", f.File),
+		validateURL("StatusURL", f.StatusURL),
+	)
+}
+
+// TiKVMetricsPush contains fields for pushing the metrics scraped from MetricsURL to the pushgateway at GatewayURL
+// every Interval seconds, they are grouped by GroupingKey in the gateway. Each request times out after Interval seconds, and the sender stops after MaxFailures
+// consecutive failed pushes so that a misconfigured gateway does not keep it running for the lifetime of TiKV.
+type TiKVMetricsPush struct {
+	MetricsURL  string
+	GatewayURL  string
+	GroupingKey string
+	CurlArgs    string
+	Interval    int
+	MaxFailures int
+}
+
+// Validate checks the fields required by pushing the metrics, nil is valid as the push is optional
+func (p *TiKVMetricsPush) Validate() error {
+	if p == nil {
+		return nil
+	}
+	var gatewayURLErr error
+	if strings.ContainsAny(p.GatewayURL, " \t\n'\"`$\\;&|<>()") {
+		gatewayURLErr = fmt.Errorf("GatewayURL %q must not contain whitespace or shell metacharacters", p.GatewayURL)
+	} else {
+		gatewayURLErr = validateURL("GatewayURL", p.GatewayURL)
+	}
+	return validateModel("metrics push",
+		validateURL("MetricsURL", p.MetricsURL),
+		gatewayURLErr,
+		validateRequired("GroupingKey", p.GroupingKey),
+		validatePositive("Interval", p.Interval),
+		validatePositive("MaxFailures", p.MaxFailures),
+	)
+}
+
+// TiKVInMemoryEngine contains fields for the experimental in-memory engine, the data dir is MountPath
+// which is checked to be memory-backed before TiKV is started.
+type TiKVInMemoryEngine struct {
+	MountPath string
+}
+
+// Validate checks the fields required by the in-memory engine, nil is valid as the engine is optional
+func (e *TiKVInMemoryEngine) Validate() error {
+	if e == nil {
+		return nil
+	}
+	return validateModel("in-memory engine",
+		validateAbsPath("MountPath", e.MountPath),
+	)
+}
+
+// TiKVPDSRV contains fields for resolving the PD addresses from the SRV records of Name,
+// FallbackAddr is used if no record is resolved.
+type TiKVPDSRV struct {
+	Name         string
+	FallbackAddr string
+}
+
+// Validate checks the fields required by resolving PD by SRV records, nil is valid as it is optional
+func (s *TiKVPDSRV) Validate() error {
+	if s == nil {
+		return nil
+	}
+	var nameErr error
+	if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(s.Name, tikvPDSRVPrefix)); len(errs) > 0 {
+		nameErr = fmt.Errorf("Name %q is invalid: %s", s.Name, strings.Join(errs, ", "))
+	}
+	return validateModel("PD SRV",
+		nameErr,
+		validateURLs("FallbackAddr", s.FallbackAddr),
+	)
 }
 
 // RenderTiKVStartScript renders TiKV start script from TidbCluster
 func RenderTiKVStartScript(tc *v1alpha1.TidbCluster) (string, error) {
+	return renderTiKVStartScript(tc, "")
+}
+
+// RenderTiKVStartScriptWithInlineConfig renders TiKV start script like RenderTiKVStartScript, but the start script
+// writes tikvConfig, which is the effective config file of TiKV, e.g. the "config-file" in the ConfigMap of TiKV,
+// to /etc/tikv/tikv.toml by a heredoc before using it, for the environments in which mounting the ConfigMap is awkward.
+func RenderTiKVStartScriptWithInlineConfig(tc *v1alpha1.TidbCluster, tikvConfig string) (string, error) {
+	if strings.TrimSpace(tikvConfig) == "" {
+		return "", fmt.Errorf("the inline config of TiKV must not be empty")
+	}
+	return renderTiKVStartScript(tc, tikvConfig)
+}
+
+func renderTiKVStartScript(tc *v1alpha1.TidbCluster, inlineConfig string) (string, error) {
 	m := &TiKVStartScriptModel{}
-	tcName := tc.Name
-	tcNS := tc.Namespace
-	peerServiceName := controller.TiKVPeerMemberName(tcName)
 
-	m.PDAddr = fmt.Sprintf("%s:%d", controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort)
-	if tc.AcrossK8s() {
-		m.AcrossK8s = &AcrossK8sScriptModel{
-			PDAddr:        fmt.Sprintf("%s:%d", controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort),
-			DiscoveryAddr: fmt.Sprintf("%s-discovery.%s:10261", tcName, tcNS),
+	m.PDAddr, m.AcrossK8s = tikvPDAddr(tc)
+	if domain, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVPDSRVDomain]; ok {
+		if m.AcrossK8s != nil {
+			return "", fmt.Errorf("annotation %s can not be used when the cluster is deployed across k8s", label.AnnTiKVPDSRVDomain)
 		}
-		m.PDAddr = "${result}" // get pd addr in subscript
-	} else if tc.Heterogeneous() && tc.WithoutLocalPD() {
-		m.PDAddr = fmt.Sprintf("%s:%d", controller.PDMemberName(tc.Spec.Cluster.Name), v1alpha1.DefaultPDClientPort) // use pd of reference cluster
+		m.PDSRV = &TiKVPDSRV{
+			Name:         tikvPDSRVPrefix + domain,
+			FallbackAddr: m.PDAddr,
+		}
+		m.PDAddr = "${pd_srv_addr}" // get pd addr from SRV records
 	}
 
-	listenHost := "0.0.0.0"
-	if tc.Spec.PreferIPv6 {
-		listenHost = "[::]"
+	m.Addr = formatListenAddr("", v1alpha1.DefaultTiKVServerPort, listenOnIPv6(tc, tc.Spec.PreferIPv6))
+	m.DisableStatusServer = tc.Spec.TiKV.DisableStatusServer
+	if !m.DisableStatusServer {
+		m.StatusListenHost = tikvStatusListenHost(tc)
+		m.StatusAddr = formatListenAddr(m.StatusListenHost, v1alpha1.DefaultTiKVStatusPort, false)
 	}
-	m.Addr = fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiKVServerPort)
-	m.StatusAddr = fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiKVStatusPort)
 
-	advertiseHost := fmt.Sprintf("${TIKV_POD_NAME}.%s.%s.svc", peerServiceName, tcNS)
-	if tc.Spec.ClusterDomain != "" {
-		advertiseHost = advertiseHost + "." + tc.Spec.ClusterDomain
+	m.AdvertiseHost = tikvAdvertiseHost(tc)
+	m.AdvertisePort = tikvAdvertisePort(tc)
+	m.AdvertiseAddr = TiKVAdvertiseAddr(tc)
+
+	dirs, err := newTiKVDirs(tc)
+	if err != nil {
+		return "", err
+	}
+	m.DataDir = dirs.DataDir
+	m.WalDir = dirs.WalDir
+	m.TitanDir = dirs.TitanDir
+	m.RaftDir = dirs.RaftDir
+	if mountPath, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVInMemoryEngine]; ok {
+		// a persistent WAL or raft dir can not be used with the data dir which is lost on restart
+		if m.WalDir != "" || m.RaftDir != "" {
+			return "", fmt.Errorf("the WAL and raft volumes of TiKV can not be used with annotation %s", label.AnnTiKVInMemoryEngine)
+		}
+		m.InMemoryEngine = &TiKVInMemoryEngine{MountPath: mountPath}
+		m.DataDir = mountPath
+	}
+	m.FixDataDirPermissions = tc.Spec.TiKV.FixDataDirPermissions
+	m.CheckDataDirFsync = tc.Spec.TiKV.CheckDataDirFsync
+	if q := tc.Spec.TiKV.MinFreeSpace; q != nil {
+		m.MinFreeSpace = q.Value()
+	}
+	// the trailing newlines are trimmed to keep the layout of the start script
+	m.Prologue = strings.TrimRight(tc.Spec.TiKV.StartScriptPrologue, "\n")
+	if m.AcrossK8s != nil && slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagCachePDAddr) {
+		m.AcrossK8s.PDAddrCache = &AcrossK8sPDAddrCache{
+			File:     filepath.Join(m.DataDir, tikvPDAddrCacheFile),
+			PDScheme: tc.Scheme(),
+			CurlArgs: tikvCurlArgs(tc),
+		}
+	}
+
+	m.Capacity = fmt.Sprintf("${%s}", tikvCapacityEnv)
+	if tc.Spec.TiKV.PreComputeCapacity {
+		m.Capacity = tikvCapacityFromStorageRequest(tc.Spec.TiKV.Requests)
+	}
+
+	m.ConfigPath = tikvConfigPath
+	if inlineConfig != "" {
+		// the heredoc is terminated by its delimiter on the line following the config
+		m.InlineConfig = strings.TrimRight(inlineConfig, "\n")
+	}
+	if tc.TiKVCPULimitUsed() {
+		// TiKV limits the CPU time of foreground requests by the quota, so that it does not exceed the limit of cgroup
+		m.CpuQuota = fmt.Sprintf("${%s}", constants.TiKVCPULimitEnv)
+		m.ConfigPath = tikvRuntimeConfigPath
+		m.SizeThreadPools = tc.Spec.TiKV.SizeThreadPoolsByCPULimit
+	}
+	if v := tc.Spec.TiKV.StorageAPIVersion; v != 0 {
+		m.ApiVersion = int(v)
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if m.InMemoryEngine != nil {
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if v := tc.Spec.TiKV.ReserveSpacePercent; v != 0 {
+		m.ReserveSpacePercent = int(v)
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if v := tc.Spec.TiKV.GCNumThreads; v != 0 {
+		m.GCNumThreads = int(v)
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if q := tc.Spec.TiKV.RegionSplitSize; q != nil {
+		m.RegionSplitSize = q.Value() >> 20
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if q := tc.Spec.TiKV.RegionMaxSize; q != nil {
+		m.RegionMaxSize = q.Value() >> 20
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+	if tc.Spec.TiKV.BlockCacheSizedByMemoryLimit() {
+		m.MemoryLimit = fmt.Sprintf("${%s}", constants.TiKVMemoryLimitEnv)
+		m.BlockCacheMemoryPercent = int(tc.Spec.TiKV.BlockCacheMemoryPercent)
+		m.ConfigPath = tikvRuntimeConfigPath
+	}
+
+	m.DnsWaitThreshold = tc.TiKVStartTimeout()
+	m.DnsWaitInterval = int(tc.Spec.DnsWaitIntervalSeconds)
+	m.StartupDelaySeconds = tc.Spec.TiKV.StartupDelaySeconds
+	m.DnsWaitResolver = tc.Spec.DnsWaitResolver
+	m.NsLookupCmd = nsLookupCmd(tc, m.DnsWaitResolver)
+
+	m.LogLevel = tc.Spec.TiKV.LogLevel
+	if lf := tc.Spec.TiKV.LogFile; lf != nil {
+		m.LogFile = lf.Path
+		m.LogMaxSize = lf.MaxSize
+		m.LogMaxBackups = lf.MaxBackups
+		if m.LogMaxSize != 0 || m.LogMaxBackups != 0 {
+			m.ConfigPath = tikvRuntimeConfigPath
+		}
+	}
+
+	m.StoreLabels = staticStoreLabels(tc.Spec.TiKV.StoreLabels)
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagTopologyStoreLabels) {
+		m.TopologyLabels = tikvTopologyStoreLabels
+		m.PodLabelsFile = filepath.Join(constants.PodInfoMountPath, constants.PodLabelsFileName)
+	}
+	if keys := tc.Spec.TiKV.PodLabelsAsStoreLabels; len(keys) > 0 {
+		m.PodLabelKeys = keys
+		m.PodLabelsFile = filepath.Join(constants.PodInfoMountPath, constants.PodLabelsFileName)
+	}
+
+	m.BinaryPath = tikvBinaryPath
+	if path, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVBinaryPath]; ok {
+		m.BinaryPath = path
+	}
+	if v, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVArchBinaryPaths]; ok {
+		paths, err := parseArchBinaryPaths(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid arch binary paths %q in annotation %s: %v", v, label.AnnTiKVArchBinaryPaths, err)
+		}
+		m.ArchBinaryPaths = paths
+	}
+
+	m.ArgsPerLine = slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagArgsPerLine)
+
+	m.ExecAttempts = 1
+	if v, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVExecAttempts]; ok {
+		attempts, err := strconv.Atoi(v)
+		if err != nil || attempts <= 0 {
+			return "", fmt.Errorf("invalid exec attempts %q in annotation %s, it must be a positive integer", v, label.AnnTiKVExecAttempts)
+		}
+		m.ExecAttempts = attempts
+	}
+
+	if node, ok := tc.BaseTiKVSpec().Annotations()[label.AnnNumaNode]; ok {
+		if _, err := strconv.ParseUint(node, 10, 32); err != nil {
+			return "", fmt.Errorf("invalid NUMA node %q in annotation %s: %v", node, label.AnnNumaNode, err)
+		}
+		m.NumaNode = node
+	}
+
+	if v, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVRecoverMode]; ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid recover mode %q in annotation %s: %v", v, label.AnnTiKVRecoverMode, err)
+		}
+		m.RecoverMode = enabled
 	}
-	m.AdvertiseHost = advertiseHost
-	m.AdvertiseAddr = fmt.Sprintf("%s:%d", advertiseHost, v1alpha1.DefaultTiKVServerPort)
 
-	m.DataDir = filepath.Join(constants.TiKVDataVolumeMountPath, tc.Spec.TiKV.DataSubDir)
+	if file, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVReadinessFile]; ok {
+		if m.DisableStatusServer {
+			return "", fmt.Errorf("the status server of TiKV can not be disabled with annotation %s", label.AnnTiKVReadinessFile)
+		}
+		if file == "" {
+			file = tikvDefaultReadinessFile
+		}
+		m.ReadinessFile = &TiKVReadinessFile{
+			File: file,
+			StatusURL: fmt.Sprintf("%s://%s:%d/status", tc.Scheme(),
+				urlHost(tikvStatusProbeHost(m.StatusListenHost)), v1alpha1.DefaultTiKVStatusPort),
+			CurlArgs: tikvCurlArgs(tc),
+		}
+	}
+
+	if gateway, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVMetricsPushGateway]; ok {
+		if m.DisableStatusServer {
+			return "", fmt.Errorf("the status server of TiKV can not be disabled with annotation %s", label.AnnTiKVMetricsPushGateway)
+		}
+		m.MetricsPush = &TiKVMetricsPush{
+			MetricsURL: fmt.Sprintf("%s://%s:%d/metrics", tc.Scheme(),
+				urlHost(tikvStatusProbeHost(m.StatusListenHost)), v1alpha1.DefaultTiKVStatusPort),
+			GatewayURL:  strings.TrimSuffix(gateway, "/"),
+			GroupingKey: fmt.Sprintf("job/tikv/namespace/%s/instance/${TIKV_POD_NAME}", tc.Namespace),
+			CurlArgs:    tikvCurlArgs(tc),
+			Interval:    tikvDefaultMetricsPushInterval,
+			MaxFailures: tikvMetricsPushMaxFailures,
+		}
+		if v, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVMetricsPushInterval]; ok {
+			interval, err := strconv.Atoi(v)
+			if err != nil || interval <= 0 {
+				return "", fmt.Errorf("invalid metrics push interval %q in annotation %s, it must be a positive integer", v, label.AnnTiKVMetricsPushInterval)
+			}
+			m.MetricsPush.Interval = interval
+		}
+	}
 
-	m.Capacity = "${CAPACITY}"
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagWaitForPDLeader) {
+		m.PDLeaderWait = &TiKVPDLeaderWait{
+			PDScheme: tc.Scheme(),
+			CurlArgs: tikvCurlArgs(tc),
+			Timeout:  tc.TiKVStartTimeout(),
+		}
+	}
 
-	m.KVStartTimeout = tc.PDStartTimeout()
+	if tc.Spec.TiKV.PreStop != nil {
+		m.EvictLeaderCleanup = &TiKVEvictLeaderCleanup{
+			File:     tikvEvictLeaderSchedulerFile,
+			PDScheme: tc.Scheme(),
+			CurlArgs: fmt.Sprintf("%s --max-time %d", tikvCurlArgs(tc), tikvPreStopRequestTimeout),
+		}
+	}
 
 	extraArgs := []string{}
 	if tc.Spec.EnableDynamicConfiguration != nil && *tc.Spec.EnableDynamicConfiguration {
-		advertiseStatusAddr := fmt.Sprintf("${TIKV_POD_NAME}.%s.%s.svc", peerServiceName, tcNS)
-		if tc.Spec.ClusterDomain != "" {
-			advertiseStatusAddr = advertiseStatusAddr + "." + tc.Spec.ClusterDomain
+		if m.DisableStatusServer {
+			return "", fmt.Errorf("the status server of TiKV can not be disabled with dynamic configuration")
+		}
+		if tikvSupportsFlag(tc, tikvAdvertiseStatusAddrFlag) {
+			extraArgs = append(extraArgs, fmt.Sprintf("%s=%s:%d", tikvAdvertiseStatusAddrFlag, m.AdvertiseHost, v1alpha1.DefaultTiKVStatusPort))
 		}
-		extraArgs = append(extraArgs, fmt.Sprintf("--advertise-status-addr=%s:%d", advertiseStatusAddr, v1alpha1.DefaultTiKVStatusPort))
 	}
 	if len(extraArgs) > 0 {
 		m.ExtraArgs = strings.Join(extraArgs, " ")
 	}
+	// keep the order of the additional flags, and the duplicated ones are passed to TiKV as they are
+	if flags := tc.Spec.TiKV.AdditionalStartupFlags; len(flags) > 0 {
+		m.UserArgs = shellQuoteArgs(flags)
+	}
 
 	waitForDnsNameIpMatchOnStartup := slices.Contains(
 		tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch)
+	skipDnsWaitOnStartup := skipDnsWait(tc)
 
-	var tikvStartScriptTpl = template.Must(
-		template.Must(
-			template.New("tikv-start-script").Parse(tikvStartSubScript),
-		).Parse(
-			componentCommonScript +
-				replaceTikvStartScriptDnsAwaitPart(tikvStartScript, waitForDnsNameIpMatchOnStartup)),
-	)
+	ports := []ResolvedSummaryPort{{Name: "server", Port: v1alpha1.DefaultTiKVServerPort}}
+	if !m.DisableStatusServer {
+		ports = append(ports, ResolvedSummaryPort{Name: "status", Port: v1alpha1.DefaultTiKVStatusPort})
+	}
+	m.ResolvedSummary = newResolvedSummary(tc, ResolvedSummary{
+		Component:     v1alpha1.TiKVMemberType.String(),
+		PDAddr:        m.PDAddr,
+		AdvertiseAddr: m.AdvertiseAddr,
+		DataDir:       m.DataDir,
+		Ports:         ports,
+	})
 
-	return renderTemplateFunc(tikvStartScriptTpl, m)
+	if dir, ok := tc.BaseTiKVSpec().Annotations()[label.AnnTiKVTextfileDir]; ok {
+		m.TextfileDir = dir
+		m.TextfileName = fmt.Sprintf("tikv_start_script_%s_${TIKV_POD_NAME}.prom", tc.Namespace)
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	if m.TextfileDir != "" {
+		// it is the same as the checksum in the header comment as ModelChecksum itself is not marshaled
+		sum, err := utils.Sha256Sum(m)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the checksum of script model: %v", err)
+		}
+		m.ModelChecksum = sum
+	}
+
+	tikvStartScriptTpl, err := parseStartScriptTemplate(tc, "tikv-start-script", tikvStartSubScript,
+		commonScript(tc, "/etc/tikv")+
+			replaceDnsLookupForStrictMode(tc, replaceDnsWaitThresholdUnit(tc, replaceDnsWaitSleep(tc, replaceTikvStartScriptDnsAwaitPart(tikvStartScript, waitForDnsNameIpMatchOnStartup, skipDnsWaitOnStartup)))))
+	if err != nil {
+		return "", err
+	}
+
+	script, err := renderTemplateFunc(tikvStartScriptTpl, m)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagModelChecksum) {
+		return withModelChecksum(script, m)
+	}
+	return script, nil
 }
 
 const (
+	// tikvConfigPath is the path of the config file mounted from the ConfigMap
+	tikvConfigPath = "/etc/tikv/tikv.toml"
+	// tikvRuntimeConfigPath is the path of the config file generated at runtime, it is in the data volume
+	// as the root filesystem may be read-only.
+	tikvRuntimeConfigPath = constants.TiKVDataVolumeMountPath + "/runtime-tikv.toml"
+	// tikvInlineConfigDelimiter is the delimiter of the heredoc of the inline config, it is quoted in the
+	// start script so that the config is written as it is without any expansion.
+	tikvInlineConfigDelimiter = "TIKV_CONFIG_EOF"
+	// tikvMaxBlockCacheMemoryPercent is the max percentage of the memory limit used by the block cache, the rest
+	// is left to the other memory usages of TiKV, e.g. the memtables and the raft entries.
+	tikvMaxBlockCacheMemoryPercent = 80
+	// tikvMaxGCNumThreads is the max of gc.num-threads set from the spec, more workers only add the load of TiKV.
+	tikvMaxGCNumThreads = 32
+
+	// tikvBinaryPath is the default path of tikv-server binary in the image.
+	tikvBinaryPath = "/tikv-server"
+
+	// tikvDefaultReadinessFile is the default file touched by the start script once TiKV is up.
+	tikvDefaultReadinessFile = "/tmp/tikv-ready"
+
+	// tikvDefaultMetricsPushInterval is the default seconds between two pushes of the metrics of TiKV.
+	tikvDefaultMetricsPushInterval = 15
+	// tikvMetricsPushMaxFailures is the consecutive failed pushes after which the metrics sender stops.
+	tikvMetricsPushMaxFailures = 10
+
+	// tikvPDSRVPrefix is the prefix of the SRV records publishing the PD endpoints
+	tikvPDSRVPrefix = "_pd._tcp."
+
+	// tikvPDAddrCacheFile is the file in the data dir caching the PD addr verified by discovery.
+	tikvPDAddrCacheFile = ".pd-addr-cache"
+
+	// tikvEvictLeaderSchedulerFile is the file in the data volume recording the store whose evict leader scheduler
+	// is created by the preStop script, the scheduler is removed by the start script after the store restarts.
+	tikvEvictLeaderSchedulerFile = constants.TiKVDataVolumeMountPath + "/.evict-leader-scheduler"
+
+	tikvAdvertiseStatusAddrFlag = "--advertise-status-addr"
+
 	// tikvStartSubScript contains optional subscripts used in start script.
 	tikvStartSubScript = `
 {{ define "AcrossK8sSubscript" }}
 pd_url={{ .AcrossK8s.PDAddr }}
-encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
-discovery_url={{ .AcrossK8s.DiscoveryAddr }}
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
+discovery_url={{ .AcrossK8s.DiscoveryAddr }}` + acrossK8sPDAddrCacheReadSubScript + `
+until result=$(` + acrossK8sVerifySubScript + acrossK8sStripPDSchemeSubScript + `); do` + acrossK8sMaxRetriesSubScript + acrossK8sDeadlineSubScript + `
     echo "waiting for the verification of PD endpoints ..."
-    sleep $((RANDOM % 5))
-done
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sPDAddrCacheWriteSubScript + acrossK8sVerboseSubScript + `
+{{- end }}
+
+{{ define "TiKVBinaryPath" -}}
+{{ if .ArchBinaryPaths }}${TIKV_BINARY_PATH}{{ else }}{{ .BinaryPath }}{{ end }}
+{{- end }}
+
+{{ define "TiKVExec" -}}
+exec {{ if .NumaNode }}numactl --cpunodebind={{ .NumaNode }} --membind={{ .NumaNode }} {{ end }}{{ template "TiKVBinaryPath" . }} ${ARGS}{{ if .UserArgs }} "$@"{{ end }}
 {{- end }}
 `
 
 	tikvWaitForDnsIpMatchSubScript = `
 componentDomain={{ .AdvertiseHost }}
-waitThreshold={{ .KVStartTimeout }}
-nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+waitThreshold={{ .DnsWaitThreshold }}
+nsLookupCmd="{{ .NsLookupCmd }}"
 ` + componentCommonWaitForDnsIpMatchScript
 
 	tikvWaitForDnsOnlySubScript = "" // it is empty for backward compatibility
 
 	// tikvStartScript is the template of start script.
 	tikvStartScript = `
-TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}` +
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+{{- if gt .StartupDelaySeconds 0 }}
+
+echo "sleeping {{ .StartupDelaySeconds }}s before starting tikv-server ..."
+sleep {{ .StartupDelaySeconds }}
+{{- end }}` +
 		dnsAwaitPart + `
 {{- if .AcrossK8s -}} {{ template "AcrossK8sSubscript" . }} {{- end }}
+{{- if .PDSRV }}
+
+# the targets of the SRV records are ordered by priority and weight, a failed lookup falls back to the PD service
+pd_srv_addr=$(dig {{ .PDSRV.Name }} SRV +short 2>/dev/null | sort -k1,1n -k2,2nr | awk 'NF == 4 { sub(/\.$/, "", $4); printf "%s%s:%s", sep, $4, $3; sep = "," }') || true
+if [[ -n "${pd_srv_addr}" ]]; then
+    echo "resolved PD addresses ${pd_srv_addr} from the SRV records of {{ .PDSRV.Name }}"
+else
+    pd_srv_addr={{ .PDSRV.FallbackAddr }}
+    echo "no SRV record of {{ .PDSRV.Name }} is resolved, using PD addresses ${pd_srv_addr}"
+fi
+{{- end }}
+{{- if .PDLeaderWait }}
+
+pd_addr={{ .PDAddr }}
+pd_leader_url={{ .PDLeaderWait.PDScheme }}://${pd_addr%%,*}/pd/api/v1/leader
+waitStartTime=$(date +%s)
+until curl {{ .PDLeaderWait.CurlArgs }} ${pd_leader_url} 2>/dev/null | grep -q '"name"'; do
+    if [[ $(( $(date +%s) - waitStartTime )) -ge {{ .PDLeaderWait.Timeout }} ]]; then
+        echo "PD leader is not elected in {{ .PDLeaderWait.Timeout }}s, starting tikv-server anyway"
+        break
+    fi
+    echo "waiting for PD leader from ${pd_leader_url} ..."
+    sleep 1
+done
+{{- end }}
+{{- if .EvictLeaderCleanup }}
+
+# the evict leader scheduler created by the preStop script is kept until the store restarts, so that its leaders
+# are not moved back while it is stopped
+if [ -s {{ .EvictLeaderCleanup.File }} ]; then
+    evict_leader_store_id=$(cat {{ .EvictLeaderCleanup.File }})
+    pd_addr={{ .PDAddr }}
+    if curl {{ .EvictLeaderCleanup.CurlArgs }} -X DELETE {{ .EvictLeaderCleanup.PDScheme }}://${pd_addr%%,*}/pd/api/v1/schedulers/evict-leader-scheduler-${evict_leader_store_id}; then
+        echo "removed the evict leader scheduler of store ${evict_leader_store_id}"
+        rm -f {{ .EvictLeaderCleanup.File }}
+    else
+        echo "failed to remove the evict leader scheduler of store ${evict_leader_store_id}, it is retried on the next start" >&2
+    fi
+fi
+{{- end }}
+{{- if .TitanDir }}
+
+mkdir -p {{ .TitanDir }}
+{{- end }}
+{{- if .RaftDir }}
+
+mkdir -p {{ .RaftDir }}
+{{- end }}
+{{- if .FixDataDirPermissions }}
+
+umask 0022
+mkdir -p {{ .DataDir }}
+if ! chown "$(id -u):$(id -g)" {{ .DataDir }} || ! chmod go-w {{ .DataDir }}; then
+    echo "failed to fix the permissions of data dir {{ .DataDir }}, exiting."
+    exit {{ exitCode "StorageCheckFailed" }}
+fi
+{{- end }}
+{{- if .CheckDataDirFsync }}
+
+mkdir -p {{ .DataDir }}
+fsync_check_file={{ .DataDir }}/.fsync-check.$$
+if ! dd if=/dev/zero of=${fsync_check_file} bs=4096 count=1 conv=fsync 2>/dev/null; then
+    rm -f ${fsync_check_file}
+    echo "failed to write and fsync ${fsync_check_file}, the data volume may be broken, exiting."
+    exit {{ exitCode "StorageCheckFailed" }}
+fi
+rm -f ${fsync_check_file}
+{{- end }}
+{{- if .MinFreeSpace }}
+
+mkdir -p {{ .DataDir }}
+free_space=$(( $(df -Pk {{ .DataDir }} | awk 'NR == 2 { print $4 }') * 1024 ))
+if [[ ${free_space} -lt {{ .MinFreeSpace }} ]]; then
+    echo "only ${free_space} bytes are free in data dir {{ .DataDir }}, at least {{ .MinFreeSpace }} bytes are required, exiting."
+    exit {{ exitCode "StorageCheckFailed" }}
+fi
+{{- end }}
+{{- if .InlineConfig }}
+
+mkdir -p $(dirname ` + tikvConfigPath + `)
+cat > ` + tikvConfigPath + ` <<'` + tikvInlineConfigDelimiter + `'
+{{ .InlineConfig }}
+` + tikvInlineConfigDelimiter + `
+{{- end }}
+{{- if .CpuQuota }}
+
+if grep -q '^\[quota\]' ` + tikvConfigPath + `; then
+    echo "quota is set in the config file, the CPU limit {{ .CpuQuota }}m of the container is not applied"
+    cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+else
+    echo "limiting the CPU time of foreground requests to {{ .CpuQuota }}m"
+    { cat ` + tikvConfigPath + `; printf '\n[quota]\nforeground-cpu-time = %s\n' "{{ .CpuQuota }}"; } > {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if .SizeThreadPools }}
+
+# size the pools in the same way as TiKV does by the number of CPU cores, while the cores are got from the CPU limit
+cpu_cores=$(( ({{ .CpuQuota }} + 999) / 1000 ))
+if grep -q '^\[readpool\.unified\]' {{ .ConfigPath }}; then
+    echo "readpool.unified is set in the config file, it is not sized by the CPU limit"
+else
+    unified_pool_size=$(( cpu_cores * 8 / 10 ))
+    if [[ ${unified_pool_size} -lt 4 ]]; then
+        unified_pool_size=4
+    fi
+    printf '\n[readpool.unified]\nmax-thread-count = %d\n' "${unified_pool_size}" >> {{ .ConfigPath }}
+fi
+if grep -q '^\[storage\]' {{ .ConfigPath }}; then
+    echo "storage is set in the config file, the scheduler worker pool is not sized by the CPU limit"
+else
+    scheduler_pool_size=8
+    if [[ ${cpu_cores} -lt 16 ]]; then
+        scheduler_pool_size=$(( cpu_cores < 4 ? cpu_cores : 4 ))
+    fi
+    printf '\n[storage]\nscheduler-worker-pool-size = %d\n' "${scheduler_pool_size}" >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if .ApiVersion }}
+
+api_version_file={{ .DataDir }}/.api-version
+if [[ -f ${api_version_file} ]]; then
+    if [[ "$(cat ${api_version_file})" != "{{ .ApiVersion }}" ]]; then
+        echo "the api-version of data dir {{ .DataDir }} is $(cat ${api_version_file}), it can not be changed to {{ .ApiVersion }}, exiting."
+        exit {{ exitCode "StorageCheckFailed" }}
+    fi
+{{- if ne .ApiVersion 1 }}
+elif [[ -d {{ .DataDir }}/db ]]; then
+    echo "api-version {{ .ApiVersion }} can only be set on bootstrap, data dir {{ .DataDir }} already has data without the api-version recorded, exiting."
+    exit {{ exitCode "StorageCheckFailed" }}
+{{- end }}
+fi
+mkdir -p {{ .DataDir }} && echo {{ .ApiVersion }} > ${api_version_file}
+{{- if not .CpuQuota }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if grep -q '^api-version *=' {{ .ConfigPath }}; then
+    echo "api-version is set in the config file, the one of the spec is not applied"
+elif grep -q '^\[storage\]' {{ .ConfigPath }}; then
+    sed -i 's/^\[storage\]$/&\napi-version = {{ .ApiVersion }}/' {{ .ConfigPath }}
+else
+    printf '\n[storage]\napi-version = {{ .ApiVersion }}\n' >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if .InMemoryEngine }}
+
+if ! awk -v dir={{ .InMemoryEngine.MountPath }} '$2 == dir && $3 == "tmpfs" { found = 1 } END { exit !found }' /proc/mounts; then
+    echo "{{ .InMemoryEngine.MountPath }} is not a memory-backed mount, the in-memory engine can not be enabled, exiting."
+    exit {{ exitCode "StorageCheckFailed" }}
+fi
+{{- if not (or .CpuQuota .ApiVersion) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if grep -q '^\[in-memory-engine\]' {{ .ConfigPath }}; then
+    echo "in-memory-engine is set in the config file, it is not enabled by the annotation"
+else
+    printf '\n[in-memory-engine]\nenable = true\n' >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if .ReserveSpacePercent }}
+
+capacity={{ .Capacity }}
+case "${capacity}" in
+*GB) capacity_bytes=$(( ${capacity%GB} * 1024 * 1024 * 1024 )) ;;
+*MB) capacity_bytes=$(( ${capacity%MB} * 1024 * 1024 )) ;;
+0) mkdir -p {{ .DataDir }} && capacity_bytes=$(( $(df -Pk {{ .DataDir }} | awk 'NR == 2 { print $2 }') * 1024 )) ;;
+*) capacity_bytes=${capacity} ;;
+esac
+reserve_space=$(( capacity_bytes / 100 * {{ .ReserveSpacePercent }} + capacity_bytes % 100 * {{ .ReserveSpacePercent }} / 100 ))
+{{- if not (or .CpuQuota .ApiVersion .InMemoryEngine) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if grep -q '^reserve-space *=' {{ .ConfigPath }}; then
+    echo "reserve-space is set in the config file, the one of the spec is not applied"
+else
+    echo "reserving ${reserve_space} bytes ({{ .ReserveSpacePercent }}% of the capacity ${capacity}) for TiKV"
+    if grep -q '^\[storage\]' {{ .ConfigPath }}; then
+        sed -i "s/^\[storage\]$/&\nreserve-space = \"${reserve_space}B\"/" {{ .ConfigPath }}
+    else
+        printf '\n[storage]\nreserve-space = "%sB"\n' "${reserve_space}" >> {{ .ConfigPath }}
+    fi
+fi
+{{- end }}
+{{- if .GCNumThreads }}
+
+# num-threads is also a key of other sections, e.g. backup, so only the one in the gc section is checked
+{{- if not (or .CpuQuota .ApiVersion .InMemoryEngine .ReserveSpacePercent) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if awk '/^\[/ { in_gc = ($0 == "[gc]") } in_gc && /^num-threads *=/ { found = 1 } END { exit !found }' {{ .ConfigPath }}; then
+    echo "gc.num-threads is set in the config file, the one of the spec is not applied"
+elif grep -q '^\[gc\]$' {{ .ConfigPath }}; then
+    sed -i 's/^\[gc\]$/&\nnum-threads = {{ .GCNumThreads }}/' {{ .ConfigPath }}
+else
+    printf '\n[gc]\nnum-threads = {{ .GCNumThreads }}\n' >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if or .RegionSplitSize .RegionMaxSize }}
+
+# the sizes are applied together, so that region-max-size is never less than region-split-size
+{{- if not (or .CpuQuota .ApiVersion .InMemoryEngine .ReserveSpacePercent .GCNumThreads) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if awk '/^\[/ { in_coprocessor = ($0 == "[coprocessor]") } in_coprocessor && /^region-(split|max)-size *=/ { found = 1 } END { exit !found }' {{ .ConfigPath }}; then
+    echo "coprocessor.region-split-size or region-max-size is set in the config file, the ones of the spec are not applied"
+elif grep -q '^\[coprocessor\]$' {{ .ConfigPath }}; then
+    sed -i 's/^\[coprocessor\]$/&{{ if .RegionSplitSize }}\nregion-split-size = "{{ .RegionSplitSize }}MiB"{{ end }}{{ if .RegionMaxSize }}\nregion-max-size = "{{ .RegionMaxSize }}MiB"{{ end }}/' {{ .ConfigPath }}
+else
+    printf '\n[coprocessor]\n{{ if .RegionSplitSize }}region-split-size = "{{ .RegionSplitSize }}MiB"\n{{ end }}{{ if .RegionMaxSize }}region-max-size = "{{ .RegionMaxSize }}MiB"\n{{ end }}' >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- if .BlockCacheMemoryPercent }}
+
+{{- if not (or .CpuQuota .ApiVersion .InMemoryEngine .ReserveSpacePercent .GCNumThreads .RegionSplitSize .RegionMaxSize) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if awk '/^\[/ { in_block_cache = ($0 == "[storage.block-cache]") } in_block_cache && /^capacity *=/ { found = 1 } END { exit !found }' {{ .ConfigPath }}; then
+    echo "storage.block-cache.capacity is set in the config file, the memory limit {{ .MemoryLimit }} of the container is not applied"
+else
+    block_cache_capacity=$(( {{ .MemoryLimit }} * {{ .BlockCacheMemoryPercent }} / 100 / 1048576 ))
+    echo "setting the capacity of block cache to ${block_cache_capacity}MiB, {{ .BlockCacheMemoryPercent }}% of the memory limit"
+    if grep -q '^\[storage\.block-cache\]$' {{ .ConfigPath }}; then
+        sed -i "s/^\[storage\.block-cache\]$/&\ncapacity = \"${block_cache_capacity}MiB\"/" {{ .ConfigPath }}
+    else
+        printf '\n[storage.block-cache]\ncapacity = "%sMiB"\n' "${block_cache_capacity}" >> {{ .ConfigPath }}
+    fi
+fi
+{{- end }}
+{{- if .LogFile }}
+
+mkdir -p $(dirname {{ .LogFile }})
+{{- if or .LogMaxSize .LogMaxBackups }}
+{{- if not (or .CpuQuota .ApiVersion .InMemoryEngine .ReserveSpacePercent .GCNumThreads .RegionSplitSize .RegionMaxSize .BlockCacheMemoryPercent) }}
+cp ` + tikvConfigPath + ` {{ .ConfigPath }}
+{{- end }}
+if grep -q '^\[log\.file\]' {{ .ConfigPath }}; then
+    echo "log.file is set in the config file, the log rotation of the spec is not applied"
+else
+    printf '\n[log.file]\n{{ if .LogMaxSize }}max-size = {{ .LogMaxSize }}\n{{ end }}{{ if .LogMaxBackups }}max-backups = {{ .LogMaxBackups }}\n{{ end }}' >> {{ .ConfigPath }}
+fi
+{{- end }}
+{{- end }}
+{{- if .Prologue }}
+
+{{ .Prologue }}
+{{- end }}
 
 ARGS="--pd={{ .PDAddr }} \
 --advertise-addr={{ .AdvertiseAddr }} \
 --addr={{ .Addr }} \
---status-addr={{ .StatusAddr }} \
---data-dir={{ .DataDir }} \
+{{ if .StatusAddr }}--status-addr={{ .StatusAddr }} \
+{{ end }}--data-dir={{ .DataDir }} \
 --capacity={{ .Capacity }} \
---config=/etc/tikv/tikv.toml"
+--config={{ .ConfigPath }}"
+{{- if .LogLevel }}
+ARGS="${ARGS} --log-level={{ .LogLevel }}"
+{{- end }}
+{{- if .LogFile }}
+ARGS="${ARGS} --log-file={{ .LogFile }}"
+{{- end }}
 {{- if .ExtraArgs }}
 ARGS="${ARGS} {{ .ExtraArgs }}"
 {{- end }}
+{{- if .StoreLabels }}
+{{ $first := true -}}
+STORE_LABELS="{{ range $k, $v := .StoreLabels }}{{ if not $first }},{{ end }}{{ $first = false }}{{ $k }}={{ $v }}{{ end }}${STORE_LABELS:+,${STORE_LABELS}}"
+{{- end }}
+{{- if .TopologyLabels }}
+
+# the well-known topology labels of the pod are passed as the store labels without the prefix, k8s sets them on
+# the nodes, so they must be copied to the pod, e.g. by the PodTopologyLabelsAdmission feature gate of k8s
+topology_labels_found=false
+for label in{{ range $k, $v := .TopologyLabels }} {{ $k }}={{ $v }}{{ end }}
+do
+    key=${label%%=*}
+    pod_label_key=${label#*=}
+    case ",${STORE_LABELS:-}," in
+    *",${key}="*)
+        topology_labels_found=true
+        continue
+        ;;
+    esac
+    value=$(awk -v key="${pod_label_key}" 'index($0, key "=") == 1 { v = substr($0, length(key) + 2); gsub(/^"|"$/, "", v); print v }' {{ .PodLabelsFile }} 2>/dev/null)
+    if [[ -n "${value}" ]]; then
+        topology_labels_found=true
+        STORE_LABELS="${STORE_LABELS:+${STORE_LABELS},}${key}=${value}"
+    fi
+done
+if [[ ${topology_labels_found} == false ]]; then
+    echo "none of the topology labels{{ range $k, $v := .TopologyLabels }} {{ $v }}{{ end }} is set on the pod, they must be copied from the node to the pod" >&2
+    exit {{ exitCode "Generic" }}
+fi
+{{- end }}
+{{- if .PodLabelKeys }}
+
+# the pod labels are skipped if the store labels with the same keys are already set
+for key in{{ range .PodLabelKeys }} {{ . }}{{ end }}
+do
+    case ",${STORE_LABELS:-}," in
+    *",${key}="*)
+        continue
+        ;;
+    esac
+    value=$(awk -v key="${key}" 'index($0, key "=") == 1 { v = substr($0, length(key) + 2); gsub(/^"|"$/, "", v); print v }' {{ .PodLabelsFile }} 2>/dev/null)
+    if [[ -n "${value}" ]]; then
+        STORE_LABELS="${STORE_LABELS:+${STORE_LABELS},}${key}=${value}"
+    fi
+done
+{{- end }}
 
 if [ ! -z "${STORE_LABELS:-}" ]; then
   LABELS="--labels ${STORE_LABELS} "
   ARGS="${ARGS}${LABELS}"
 fi
+{{- if .UserArgs }}
+
+# the flags from the spec of TidbCluster are passed as positional parameters to avoid shell expansion
+set -- {{ .UserArgs }}
+{{- end }}
+
+{{- if .TextfileDir }}
+
+if [[ -d {{ .TextfileDir }} ]]; then
+    textfile={{ .TextfileDir }}/{{ .TextfileName }}
+    {
+        echo "# HELP tidb_operator_start_script_model_info The checksum of the model of the start script."
+        echo "# TYPE tidb_operator_start_script_model_info gauge"
+        echo "tidb_operator_start_script_model_info{component=\"tikv\",pod=\"${TIKV_POD_NAME}\",checksum=\"{{ .ModelChecksum }}\"} 1"
+    } > ${textfile}.$$ && mv ${textfile}.$$ ${textfile} || echo "failed to write the model checksum to ${textfile}"
+fi
+{{- end }}
+{{- if .ReadinessFile }}
+
+# the watcher is inherited by tikv-server after exec, it touches the file once the status server is up
+rm -f {{ .ReadinessFile.File }}
+(
+    until curl {{ .ReadinessFile.CurlArgs }} --globoff -o /dev/null {{ .ReadinessFile.StatusURL }} 2>/dev/null; do
+        sleep 1
+    done
+    mkdir -p $(dirname {{ .ReadinessFile.File }}) && touch {{ .ReadinessFile.File }}
+) &
+{{- end }}
+{{- if .MetricsPush }}
+
+# the sender is inherited by tikv-server after exec, it pushes the metrics of the status server to the gateway
+(
+    failures=0
+    while [[ ${failures} -lt {{ .MetricsPush.MaxFailures }} ]]; do
+        sleep {{ .MetricsPush.Interval }}
+        if curl {{ .MetricsPush.CurlArgs }} --globoff -m {{ .MetricsPush.Interval }} {{ .MetricsPush.MetricsURL }} 2>/dev/null |
+            curl -s --fail -m {{ .MetricsPush.Interval }} --data-binary @- {{ .MetricsPush.GatewayURL }}/metrics/{{ .MetricsPush.GroupingKey }} >/dev/null 2>&1; then
+            failures=0
+        else
+            failures=$(( failures+1 ))
+        fi
+    done
+    echo "failed to push the metrics of tikv-server to {{ .MetricsPush.GatewayURL }} {{ .MetricsPush.MaxFailures }} times in a row, stop pushing" >&2
+) &
+{{- end }}
+{{- if .InMemoryEngine }}
+
+echo "################################################################"
+echo "WARNING: the data dir {{ .DataDir }} of tikv-server is in memory,"
+echo "the data is EPHEMERAL and lost once the pod is restarted."
+echo "The in-memory engine is experimental, do not use it in production."
+echo "################################################################"
+{{- end }}
+{{- if .RecoverMode }}
+
+echo "################################################################"
+echo "WARNING: tikv-server is started in recover mode, the store is"
+echo "expected to rejoin the cluster with a fresh data dir."
+echo "################################################################"
+{{- end }}
+{{- if .ArchBinaryPaths }}
 
+# the arches reported by uname are mapped to the ones of Go and Kubernetes
+TIKV_BINARY_PATH={{ .BinaryPath }}
+arch=$(uname -m)
+case ${arch} in
+x86_64) arch=amd64 ;;
+aarch64) arch=arm64 ;;
+esac
+case ${arch} in
+{{- range $arch, $path := .ArchBinaryPaths }}
+{{ $arch }}) TIKV_BINARY_PATH={{ $path }} ;;
+{{- end }}
+*) echo "no binary path of tikv-server for arch ${arch}, use {{ .BinaryPath }}" ;;
+esac
+{{- end }}
+` + resolvedSummarySubScript + `
 echo "starting tikv-server ..."
-echo "/tikv-server ${ARGS}"
-exec /tikv-server ${ARGS}
+{{- if .ArgsPerLine }}
+echo "{{ if .NumaNode }}numactl --cpunodebind={{ .NumaNode }} --membind={{ .NumaNode }} {{ end }}{{ template "TiKVBinaryPath" . }}"
+# the arguments are split in the same way as the ones passed to tikv-server
+for arg in ${ARGS}{{ if .UserArgs }} "$@"{{ end }}
+do
+    echo "    ${arg}"
+done
+{{- else if .NumaNode }}
+echo "numactl --cpunodebind={{ .NumaNode }} --membind={{ .NumaNode }} {{ template "TiKVBinaryPath" . }} ${ARGS}{{ if .UserArgs }} $*{{ end }}"
+{{- else }}
+echo "{{ template "TiKVBinaryPath" . }} ${ARGS}{{ if .UserArgs }} $*{{ end }}"
+{{- end }}
+{{- if gt .ExecAttempts 1 }}
+
+# the shell does not exit if exec fails with execfail, so that a transient failure is retried
+shopt -s execfail
+for attempt in $(seq 1 {{ .ExecAttempts }})
+do
+    echo "executing tikv-server, attempt ${attempt}/{{ .ExecAttempts }}"
+    {{ template "TiKVExec" . }}
+    echo "failed to exec tikv-server, attempt ${attempt}/{{ .ExecAttempts }}" >&2
+    sleep ${attempt}
+done
+echo "failed to exec tikv-server after {{ .ExecAttempts }} attempts, exiting." >&2
+exit {{ exitCode "ExecFailed" }}
+{{- else }}
+{{ template "TiKVExec" . }}
+{{- end }}
 `
 )
 
-func replaceTikvStartScriptDnsAwaitPart(startScript string, withLocalIpMatch bool) string {
+// tikvTopologyStoreLabels are the store label keys of the well-known topology keys of Pod labels.
+var tikvTopologyStoreLabels = map[string]string{
+	"region": corev1.LabelTopologyRegion,
+	"zone":   corev1.LabelTopologyZone,
+}
+
+// tikvFlagMinVersions are the min versions of TiKV which accept the flags rendered by the start script,
+// the flags are not rendered for older TiKV as it exits on unknown flags.
+var tikvFlagMinVersions = map[string]string{
+	tikvAdvertiseStatusAddrFlag: "v4.0.0",
+}
+
+// tikvSupportsFlag returns whether the flag is accepted by the version of TiKV in TidbCluster.
+func tikvSupportsFlag(tc *v1alpha1.TidbCluster, flag string) bool {
+	minVersion, ok := tikvFlagMinVersions[flag]
+	return !ok || versionAtLeast(tc.TiKVVersion(), minVersion)
+}
+
+// tikvAdvertiseHost returns the host advertised by TiKV, it refers to ${TIKV_POD_NAME} of the script.
+func tikvAdvertiseHost(tc *v1alpha1.TidbCluster) string {
+	if useLocalhost(tc) {
+		return localhostHost
+	}
+	if suffix := tc.Spec.TiKV.AdvertiseHostSuffix; suffix != "" {
+		return fmt.Sprintf("${TIKV_POD_NAME}.%s", suffix)
+	}
+	peerServiceName := controller.TiKVPeerMemberName(tc.Name)
+	if name := tc.Spec.TiKV.PeerServiceName; name != "" {
+		peerServiceName = name
+	}
+	advertiseHost := fmt.Sprintf("${TIKV_POD_NAME}.%s.%s.svc", peerServiceName, tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		advertiseHost = advertiseHost + "." + tc.Spec.ClusterDomain
+	}
+	return advertiseHost
+}
+
+// tikvCapacityFromStorageRequest returns the capacity in bytes from the storage request,
+// both binary SI (e.g. 10Gi) and decimal SI (e.g. 10G) quantities are converted to bytes.
+func tikvCapacityFromStorageRequest(requests corev1.ResourceList) string {
+	q, ok := requests[corev1.ResourceStorage]
+	if !ok {
+		return "0"
+	}
+	return strconv.FormatInt(q.Value(), 10)
+}
+
+// tikvStatusListenHost returns the host which the status server of TiKV listens on,
+// IPv6 addresses are enclosed in brackets.
+func tikvStatusListenHost(tc *v1alpha1.TidbCluster) string {
+	host := tc.Spec.TiKV.StatusListenHost
+	if host == "" {
+		return listenHost(tc, tc.Spec.PreferIPv6)
+	}
+	return formatHost(host)
+}
+
+// tikvPDAddr returns the PD address used by TiKV, the across-k8s model is returned
+// if the PD address is got from the across-k8s subscript at runtime.
+func tikvPDAddr(tc *v1alpha1.TidbCluster) (string, *AcrossK8sScriptModel) {
+	tcName := tc.Name
+	if useLocalhost(tc) {
+		return fmt.Sprintf("%s:%d", localhostHost, v1alpha1.DefaultPDClientPort), nil
+	}
+	if tc.AcrossK8s() {
+		acrossK8s := newAcrossK8sScriptModel(tc)
+		return "${result}", acrossK8s // get pd addr in subscript
+	}
+	if tc.Heterogeneous() && tc.WithoutLocalPD() {
+		return referencePDAddr(tc), nil // use pd of reference cluster
+	}
+	if tc.Spec.PD != nil && tc.Spec.PD.Replicas > 1 &&
+		slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses) {
+		return strings.Join(pdMemberAddrs(tc), ","), nil
+	}
+	return fmt.Sprintf("%s:%d", controller.PDMemberName(tcName), v1alpha1.DefaultPDClientPort), nil
+}
+
+// pdMemberAddrs returns the client addresses of all desired PD members in ordinal order,
+// so that TiKV can still bootstrap when some of the PD members are unavailable.
+func pdMemberAddrs(tc *v1alpha1.TidbCluster) []string {
+	tcName := tc.Name
+	pdDomainSuffix := fmt.Sprintf("%s.%s.svc", controller.PDPeerMemberName(tcName), tc.Namespace)
+	if tc.Spec.ClusterDomain != "" {
+		pdDomainSuffix = pdDomainSuffix + "." + tc.Spec.ClusterDomain
+	}
+
+	ordinals := tc.PDStsDesiredOrdinals(true).List()
+	addrs := make([]string, 0, len(ordinals))
+	for _, ordinal := range ordinals {
+		addrs = append(addrs, fmt.Sprintf("%s-%d.%s:%d", controller.PDMemberName(tcName), ordinal, pdDomainSuffix, v1alpha1.DefaultPDClientPort))
+	}
+	return addrs
+}
+
+// staticStoreLabels returns the store labels in the form of `key=value`,
+// plain keys are node labels which are set by the operator through PD API.
+func staticStoreLabels(storeLabels []string) map[string]string {
+	var labels map[string]string
+	for _, l := range storeLabels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+func replaceTikvStartScriptDnsAwaitPart(startScript string, withLocalIpMatch, skipDnsWait bool) string {
+	if skipDnsWait {
+		return strings.ReplaceAll(startScript, dnsAwaitPart, "")
+	}
 	if withLocalIpMatch {
 		return strings.ReplaceAll(startScript, dnsAwaitPart, tikvWaitForDnsIpMatchSubScript)
 	} else {
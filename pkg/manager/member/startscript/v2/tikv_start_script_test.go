@@ -14,12 +14,26 @@
 package v2
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 func TestRenderTiKVStartScript(t *testing.T) {
@@ -283,10 +297,10 @@ then
 fi
 
 TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -335,10 +349,10 @@ then
 fi
 
 TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -450,11 +464,5648 @@ echo "/tikv-server ${ARGS}"
 exec /tikv-server ${ARGS}
 `,
 		},
+		{
+			name: "set static store labels",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreLabels = []string{"zone", "rack=r1", "host", "env=prod"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+STORE_LABELS="env=prod,rack=r1${STORE_LABELS:+,${STORE_LABELS}}"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set topology store labels",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreLabels = []string{"rack=r1"}
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTopologyStoreLabels}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+STORE_LABELS="rack=r1${STORE_LABELS:+,${STORE_LABELS}}"
+
+# the well-known topology labels of the pod are passed as the store labels without the prefix, k8s sets them on
+# the nodes, so they must be copied to the pod, e.g. by the PodTopologyLabelsAdmission feature gate of k8s
+topology_labels_found=false
+for label in region=topology.kubernetes.io/region zone=topology.kubernetes.io/zone
+do
+    key=${label%%=*}
+    pod_label_key=${label#*=}
+    case ",${STORE_LABELS:-}," in
+    *",${key}="*)
+        topology_labels_found=true
+        continue
+        ;;
+    esac
+    value=$(awk -v key="${pod_label_key}" 'index($0, key "=") == 1 { v = substr($0, length(key) + 2); gsub(/^"|"$/, "", v); print v }' /etc/podinfo/labels 2>/dev/null)
+    if [[ -n "${value}" ]]; then
+        topology_labels_found=true
+        STORE_LABELS="${STORE_LABELS:+${STORE_LABELS},}${key}=${value}"
+    fi
+done
+if [[ ${topology_labels_found} == false ]]; then
+    echo "none of the topology labels topology.kubernetes.io/region topology.kubernetes.io/zone is set on the pod, they must be copied from the node to the pod" >&2
+    exit 1
+fi
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set store labels without static labels",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreLabels = []string{"zone", "host"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set IPv4 status listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "10.0.0.1"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=10.0.0.1:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set IPv6 status listen host",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "fd00::1"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=[fd00::1]:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set IPv6 status listen host with prefer IPv6",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PreferIPv6 = true
+				tc.Spec.TiKV.StatusListenHost = "[fd00::1]"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=[::]:20160 \
+--status-addr=[fd00::1]:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set wal volume of storage volumes",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "10Gi", MountPath: "/var/lib/wal"}}
+				tc.Spec.TiKV.WALVolumeName = "wal"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set wal volume of additional volumes",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdditionalVolumeMounts = []corev1.VolumeMount{{Name: "wal", MountPath: "/mnt/wal"}}
+				tc.Spec.TiKV.WALVolumeName = "wal"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set wal volume without mount path",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "10Gi"}}
+				tc.Spec.TiKV.WALVolumeName = "wal"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "multiple pd addresses with 1 pd replicas",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 1}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "multiple pd addresses with 3 pd replicas",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 3}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-2.start-script-test-pd-peer.start-script-test-ns.svc:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "multiple pd addresses with 5 pd replicas",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 5}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-2.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-3.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-4.start-script-test-pd-peer.start-script-test-ns.svc:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "multiple pd addresses with cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 3}
+				tc.Spec.ClusterDomain = "cluster.local"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc.cluster.local:2379,start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc.cluster.local:2379,start-script-test-pd-2.start-script-test-pd-peer.start-script-test-ns.svc.cluster.local:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc.cluster.local:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pd service address without multiple pd addresses feature flag",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 3}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "skip dns wait",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagSkipDnsWait}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with custom discovery port",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.ClusterDomain = "cluster.local"
+				tc.Spec.AcrossK8s = true
+				tc.Spec.Discovery.Port = pointer.Int32(10262)
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10262
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc.cluster.local:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "advertise host suffix",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdvertiseHostSuffix = "tikv.example.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.tikv.example.com:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "advertise host suffix with dynamic configuration",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdvertiseHostSuffix = "tikv.example.com"
+				tc.Spec.ClusterDomain = "cluster-1.com"
+				tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.tikv.example.com:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --advertise-status-addr=${TIKV_POD_NAME}.tikv.example.com:20180"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "bind numa node",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/numa-node": "1"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS}"
+exec numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pre-compute capacity from 10Gi storage request",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreComputeCapacity = true
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=10737418240 \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pre-compute capacity from 10G storage request",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreComputeCapacity = true
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10G")}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=10000000000 \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pre-compute capacity from 1.5Gi storage request",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreComputeCapacity = true
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1.5Gi")}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=1610612736 \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pre-compute capacity without storage request",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreComputeCapacity = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=0 \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "dual stack",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagDualStack}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=[::]:20160 \
+--status-addr=[::]:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level trace",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "trace"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=trace"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level debug",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "debug"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=debug"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level info",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "info"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=info"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level warn",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "warn"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=warn"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level error",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "error"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=error"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log level off",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogLevel = "off"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-level=off"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "additional startup flags",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+				tc.Spec.TiKV.AdditionalStartupFlags = []string{"--security-redact-info-log", "--log-file=/var/log/tikv.log", "--security-redact-info-log"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --advertise-status-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20180"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+# the flags from the spec of TidbCluster are passed as positional parameters to avoid shell expansion
+set -- '--security-redact-info-log' '--log-file=/var/log/tikv.log' '--security-redact-info-log'
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS} $*"
+exec /tikv-server ${ARGS} "$@"
+`,
+		},
+		{
+			name: "across k8s with verification bounds",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{Timeout: 10, MaxRetries: 30, MaxBackoff: 8}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 10 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    retries=$(( ${retries:-0}+1 ))
+    if [ ${retries} -gt 30 ]; then
+        echo "failed to verify PD endpoints after 30 retries" >&2
+        exit 11
+    fi
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 8))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "nested data sub dir",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "data/tikv"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv/data/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with tls enabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=https://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pod name fallback",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagPodNameFallback}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+POD_NAME=${POD_NAME:-${HOSTNAME:-}}
+if [[ -z "${POD_NAME}" && -f /etc/hostname ]]
+then
+    POD_NAME=$(cat /etc/hostname)
+fi
+if [[ -z "${POD_NAME}" ]]
+then
+    echo "failed to determine the pod name from POD_NAME, HOSTNAME and /etc/hostname, exiting." >&2
+    exit 1
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd in the same cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.ClusterDomain = "cluster.local"
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster", Namespace: "target-ns", ClusterDomain: "cluster.local"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=target-cluster-pd.target-ns.svc.cluster.local:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc.cluster.local:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd in another cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.ClusterDomain = "cluster-2.com"
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster", ClusterDomain: "cluster-1.com"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=target-cluster-pd.start-script-test-ns.svc.cluster-1.com:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc.cluster-2.com:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "fix data dir permissions",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DataSubDir = "data"
+				tc.Spec.TiKV.FixDataDirPermissions = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+umask 0022
+mkdir -p /var/lib/tikv/data
+if ! chown "$(id -u):$(id -g)" /var/lib/tikv/data || ! chmod go-w /var/lib/tikv/data; then
+    echo "failed to fix the permissions of data dir /var/lib/tikv/data, exiting."
+    exit 13
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv/data \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "additional startup flags with shell metacharacters",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdditionalStartupFlags = []string{"--log-file=/var/log/tikv log.log", `--labels=zone="z1"`, "--config-check='$(hostname)'", "--data-dir=${HOME}/\\tikv"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+# the flags from the spec of TidbCluster are passed as positional parameters to avoid shell expansion
+set -- '--log-file=/var/log/tikv log.log' '--labels=zone="z1"' '--config-check='\''$(hostname)'\''' '--data-dir=${HOME}/\tikv'
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS} $*"
+exec /tikv-server ${ARGS} "$@"
+`,
+		},
+		{
+			name: "recover mode",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-recover-mode": "true"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "################################################################"
+echo "WARNING: tikv-server is started in recover mode, the store is"
+echo "expected to rejoin the cluster with a fresh data dir."
+echo "################################################################"
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "recover mode disabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-recover-mode": "false"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "titan volume",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+				tc.Spec.TiKV.Config.Set("rocksdb.titan.enabled", true)
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}}
+				tc.Spec.TiKV.TitanVolumeName = "titan"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p /var/lib/titan
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "titan volume when titan is disabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+				tc.Spec.TiKV.Config.Set("rocksdb.titan.enabled", false)
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}}
+				tc.Spec.TiKV.TitanVolumeName = "titan"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "source extra env file",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagSourceExtraEnvFile}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+EXTRA_ENV_FILE="/etc/tikv/extra.env"
+if [[ -f "${EXTRA_ENV_FILE}" ]]
+then
+    echo "sourcing ${EXTRA_ENV_FILE}"
+    set -a
+    source ${EXTRA_ENV_FILE}
+    set +a
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "startup delay",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StartupDelaySeconds = 5
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+echo "sleeping 5s before starting tikv-server ..."
+sleep 5
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "custom binary path",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-binary-path": "/usr/local/bin/tikv-server"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/usr/local/bin/tikv-server ${ARGS}"
+exec /usr/local/bin/tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "custom binary path with numa node",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-binary-path": "/usr/local/bin/tikv-server", "tidb.pingcap.com/numa-node": "1"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "numactl --cpunodebind=1 --membind=1 /usr/local/bin/tikv-server ${ARGS}"
+exec numactl --cpunodebind=1 --membind=1 /usr/local/bin/tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "cpu limit without cpu quota feature flag",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "cpu quota",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+if grep -q '^\[quota\]' /etc/tikv/tikv.toml; then
+    echo "quota is set in the config file, the CPU limit ${TIKV_CPU_LIMIT}m of the container is not applied"
+    cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+else
+    echo "limiting the CPU time of foreground requests to ${TIKV_CPU_LIMIT}m"
+    { cat /etc/tikv/tikv.toml; printf '\n[quota]\nforeground-cpu-time = %s\n' "${TIKV_CPU_LIMIT}"; } > /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "cpu request without limit",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "peer service name",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PeerServiceName = "legacy-tikv-peer"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.legacy-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "peer service name with cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PeerServiceName = "legacy-tikv-peer"
+				tc.Spec.ClusterDomain = "cluster.local"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.legacy-tikv-peer.start-script-test-ns.svc.cluster.local:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "disable status server",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.DisableStatusServer = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "raft volume",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"}}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p /var/lib/raft
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "raft volume with sub dir",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"}}
+				tc.Spec.TiKV.RaftEngineSubDir = "raft-engine"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p /var/lib/raft/raft-engine
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "exec attempts",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-exec-attempts": "3"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+
+# the shell does not exit if exec fails with execfail, so that a transient failure is retried
+shopt -s execfail
+for attempt in $(seq 1 3)
+do
+    echo "executing tikv-server, attempt ${attempt}/3"
+    exec /tikv-server ${ARGS}
+    echo "failed to exec tikv-server, attempt ${attempt}/3" >&2
+    sleep ${attempt}
+done
+echo "failed to exec tikv-server after 3 attempts, exiting." >&2
+exit 14
+`,
+		},
+		{
+			name: "exec attempts with numa node",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-exec-attempts": "3", "tidb.pingcap.com/numa-node": "1"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS}"
+
+# the shell does not exit if exec fails with execfail, so that a transient failure is retried
+shopt -s execfail
+for attempt in $(seq 1 3)
+do
+    echo "executing tikv-server, attempt ${attempt}/3"
+    exec numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS}
+    echo "failed to exec tikv-server, attempt ${attempt}/3" >&2
+    sleep ${attempt}
+done
+echo "failed to exec tikv-server after 3 attempts, exiting." >&2
+exit 14
+`,
+		},
+		{
+			name: "pod labels as store labels",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreLabels = []string{"zone=z1"}
+				tc.Spec.TiKV.PodLabelsAsStoreLabels = []string{"rack", "example.com/host-group"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+STORE_LABELS="zone=z1${STORE_LABELS:+,${STORE_LABELS}}"
+
+# the pod labels are skipped if the store labels with the same keys are already set
+for key in rack example.com/host-group
+do
+    case ",${STORE_LABELS:-}," in
+    *",${key}="*)
+        continue
+        ;;
+    esac
+    value=$(awk -v key="${key}" 'index($0, key "=") == 1 { v = substr($0, length(key) + 2); gsub(/^"|"$/, "", v); print v }' /etc/podinfo/labels 2>/dev/null)
+    if [[ -n "${value}" ]]; then
+        STORE_LABELS="${STORE_LABELS:+${STORE_LABELS},}${key}=${value}"
+    fi
+done
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "advertise port",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.AdvertisePort = 30160
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:30160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "wait for PD leader",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForPDLeader}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+pd_addr=start-script-test-pd:2379
+pd_leader_url=http://${pd_addr%%,*}/pd/api/v1/leader
+waitStartTime=$(date +%s)
+until curl -s --fail ${pd_leader_url} 2>/dev/null | grep -q '"name"'; do
+    if [[ $(( $(date +%s) - waitStartTime )) -ge 30 ]]; then
+        echo "PD leader is not elected in 30s, starting tikv-server anyway"
+        break
+    fi
+    echo "waiting for PD leader from ${pd_leader_url} ..."
+    sleep 1
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "wait for PD leader across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForPDLeader}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+pd_addr=${result}
+pd_leader_url=http://${pd_addr%%,*}/pd/api/v1/leader
+waitStartTime=$(date +%s)
+until curl -s --fail ${pd_leader_url} 2>/dev/null | grep -q '"name"'; do
+    if [[ $(( $(date +%s) - waitStartTime )) -ge 30 ]]; then
+        echo "PD leader is not elected in 30s, starting tikv-server anyway"
+        break
+    fi
+    echo "waiting for PD leader from ${pd_leader_url} ..."
+    sleep 1
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "remove evict leader scheduler of preStop",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.PreStop = &v1alpha1.TiKVPreStopSpec{}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+# the evict leader scheduler created by the preStop script is kept until the store restarts, so that its leaders
+# are not moved back while it is stopped
+if [ -s /var/lib/tikv/.evict-leader-scheduler ]; then
+    evict_leader_store_id=$(cat /var/lib/tikv/.evict-leader-scheduler)
+    pd_addr=start-script-test-pd:2379
+    if curl -s --fail --max-time 3 -X DELETE http://${pd_addr%%,*}/pd/api/v1/schedulers/evict-leader-scheduler-${evict_leader_store_id}; then
+        echo "removed the evict leader scheduler of store ${evict_leader_store_id}"
+        rm -f /var/lib/tikv/.evict-leader-scheduler
+    else
+        echo "failed to remove the evict leader scheduler of store ${evict_leader_store_id}, it is retried on the next start" >&2
+    fi
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with discovery CA bundle",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.AcrossK8sVerification = &v1alpha1.AcrossK8sVerificationSpec{CABundlePath: "/var/lib/discovery-ca/ca.crt"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 --ca-certificate=/var/lib/discovery-ca/ca.crt https://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "check data dir fsync",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.CheckDataDirFsync = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p /var/lib/tikv
+fsync_check_file=/var/lib/tikv/.fsync-check.$$
+if ! dd if=/dev/zero of=${fsync_check_file} bs=4096 count=1 conv=fsync 2>/dev/null; then
+    rm -f ${fsync_check_file}
+    echo "failed to write and fsync ${fsync_check_file}, the data volume may be broken, exiting."
+    exit 13
+fi
+rm -f ${fsync_check_file}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with PD addr cache",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+pd_addr_cache=/var/lib/tikv/.pd-addr-cache
+result=""
+if [ -s ${pd_addr_cache} ]; then
+    cached_pd_addr=$(cat ${pd_addr_cache})
+    if curl -s --fail -m 3 http://${cached_pd_addr%%,*}/pd/api/v1/health >/dev/null 2>&1; then
+        result=${cached_pd_addr}
+        echo "using the cached PD endpoints ${result}"
+    else
+        echo "PD is unreachable by the cached endpoints ${cached_pd_addr}, verifying by discovery"
+    fi
+fi
+if [ -z "${result}" ]; then
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+mkdir -p $(dirname ${pd_addr_cache})
+echo "${result}" > ${pd_addr_cache}
+fi
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "across k8s with PD addr cache and tls enabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=https://start-script-test-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=start-script-test-discovery.start-script-test-ns:10261
+pd_addr_cache=/var/lib/tikv/.pd-addr-cache
+result=""
+if [ -s ${pd_addr_cache} ]; then
+    cached_pd_addr=$(cat ${pd_addr_cache})
+    if curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key -m 3 https://${cached_pd_addr%%,*}/pd/api/v1/health >/dev/null 2>&1; then
+        result=${cached_pd_addr}
+        echo "using the cached PD endpoints ${result}"
+    else
+        echo "PD is unreachable by the cached endpoints ${cached_pd_addr}, verifying by discovery"
+    fi
+fi
+if [ -z "${result}" ]; then
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+mkdir -p $(dirname ${pd_addr_cache})
+echo "${result}" > ${pd_addr_cache}
+fi
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "PD addr cache without across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log file",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p $(dirname /var/lib/tikv/log/tikv.log)
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+ARGS="${ARGS} --log-file=/var/lib/tikv/log/tikv.log"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log file with rotation",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log", MaxSize: 300, MaxBackups: 10}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p $(dirname /var/lib/tikv/log/tikv.log)
+cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+if grep -q '^\[log\.file\]' /var/lib/tikv/runtime-tikv.toml; then
+    echo "log.file is set in the config file, the log rotation of the spec is not applied"
+else
+    printf '\n[log.file]\nmax-size = 300\nmax-backups = 10\n' >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+ARGS="${ARGS} --log-file=/var/lib/tikv/log/tikv.log"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "log file with rotation and cpu quota",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log", MaxBackups: 10}
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+if grep -q '^\[quota\]' /etc/tikv/tikv.toml; then
+    echo "quota is set in the config file, the CPU limit ${TIKV_CPU_LIMIT}m of the container is not applied"
+    cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+else
+    echo "limiting the CPU time of foreground requests to ${TIKV_CPU_LIMIT}m"
+    { cat /etc/tikv/tikv.toml; printf '\n[quota]\nforeground-cpu-time = %s\n' "${TIKV_CPU_LIMIT}"; } > /var/lib/tikv/runtime-tikv.toml
+fi
+
+mkdir -p $(dirname /var/lib/tikv/log/tikv.log)
+if grep -q '^\[log\.file\]' /var/lib/tikv/runtime-tikv.toml; then
+    echo "log.file is set in the config file, the log rotation of the spec is not applied"
+else
+    printf '\n[log.file]\nmax-backups = 10\n' >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+ARGS="${ARGS} --log-file=/var/lib/tikv/log/tikv.log"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "start script prologue",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StartScriptPrologue = "ulimit -n 1000000\nexport MALLOC_CONF=prof:true\n"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ulimit -n 1000000
+export MALLOC_CONF=prof:true
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "size thread pools by cpu limit",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+				tc.Spec.TiKV.SizeThreadPoolsByCPULimit = true
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+if grep -q '^\[quota\]' /etc/tikv/tikv.toml; then
+    echo "quota is set in the config file, the CPU limit ${TIKV_CPU_LIMIT}m of the container is not applied"
+    cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+else
+    echo "limiting the CPU time of foreground requests to ${TIKV_CPU_LIMIT}m"
+    { cat /etc/tikv/tikv.toml; printf '\n[quota]\nforeground-cpu-time = %s\n' "${TIKV_CPU_LIMIT}"; } > /var/lib/tikv/runtime-tikv.toml
+fi
+
+# size the pools in the same way as TiKV does by the number of CPU cores, while the cores are got from the CPU limit
+cpu_cores=$(( (${TIKV_CPU_LIMIT} + 999) / 1000 ))
+if grep -q '^\[readpool\.unified\]' /var/lib/tikv/runtime-tikv.toml; then
+    echo "readpool.unified is set in the config file, it is not sized by the CPU limit"
+else
+    unified_pool_size=$(( cpu_cores * 8 / 10 ))
+    if [[ ${unified_pool_size} -lt 4 ]]; then
+        unified_pool_size=4
+    fi
+    printf '\n[readpool.unified]\nmax-thread-count = %d\n' "${unified_pool_size}" >> /var/lib/tikv/runtime-tikv.toml
+fi
+if grep -q '^\[storage\]' /var/lib/tikv/runtime-tikv.toml; then
+    echo "storage is set in the config file, the scheduler worker pool is not sized by the CPU limit"
+else
+    scheduler_pool_size=8
+    if [[ ${cpu_cores} -lt 16 ]]; then
+        scheduler_pool_size=$(( cpu_cores < 4 ? cpu_cores : 4 ))
+    fi
+    printf '\n[storage]\nscheduler-worker-pool-size = %d\n' "${scheduler_pool_size}" >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd across k8s",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster"}
+				tc.Spec.AcrossK8s = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://target-cluster-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=target-cluster-discovery.start-script-test-ns:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "heterogeneous without local pd across k8s in another cluster domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = nil
+				tc.Spec.Cluster = &v1alpha1.TidbClusterRef{Name: "target-cluster", Namespace: "target-ns", ClusterDomain: "cluster-1.com"}
+				tc.Spec.AcrossK8s = true
+				tc.Spec.ClusterDomain = "cluster-2.com"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+pd_url=http://target-cluster-pd:2379
+encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
+discovery_url=target-cluster-discovery.target-ns.svc.cluster-1.com:10261
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % 5))
+done
+
+ARGS="--pd=${result} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc.cluster-2.com:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "readiness file",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-readiness-file": ""}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+# the watcher is inherited by tikv-server after exec, it touches the file once the status server is up
+rm -f /tmp/tikv-ready
+(
+    until curl -s --fail --globoff -o /dev/null http://127.0.0.1:20180/status 2>/dev/null; do
+        sleep 1
+    done
+    mkdir -p $(dirname /tmp/tikv-ready) && touch /tmp/tikv-ready
+) &
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "readiness file with tls enabled",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-readiness-file": "/var/run/tikv/ready"}
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+				tc.Spec.PreferIPv6 = true
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=[::]:20160 \
+--status-addr=[::]:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+# the watcher is inherited by tikv-server after exec, it touches the file once the status server is up
+rm -f /var/run/tikv/ready
+(
+    until curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key --globoff -o /dev/null https://[::1]:20180/status 2>/dev/null; do
+        sleep 1
+    done
+    mkdir -p $(dirname /var/run/tikv/ready) && touch /var/run/tikv/ready
+) &
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "status listen host with ipv6 zone",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StatusListenHost = "fe80::1%eth0"
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=[fe80::1%eth0]:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "args per line",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagArgsPerLine}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server"
+# the arguments are split in the same way as the ones passed to tikv-server
+for arg in ${ARGS}
+do
+    echo "    ${arg}"
+done
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "storage api version 1",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageAPIVersion = 1
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+api_version_file=/var/lib/tikv/.api-version
+if [[ -f ${api_version_file} ]]; then
+    if [[ "$(cat ${api_version_file})" != "1" ]]; then
+        echo "the api-version of data dir /var/lib/tikv is $(cat ${api_version_file}), it can not be changed to 1, exiting."
+        exit 13
+    fi
+fi
+mkdir -p /var/lib/tikv && echo 1 > ${api_version_file}
+cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+if grep -q '^api-version *=' /var/lib/tikv/runtime-tikv.toml; then
+    echo "api-version is set in the config file, the one of the spec is not applied"
+elif grep -q '^\[storage\]' /var/lib/tikv/runtime-tikv.toml; then
+    sed -i 's/^\[storage\]$/&\napi-version = 1/' /var/lib/tikv/runtime-tikv.toml
+else
+    printf '\n[storage]\napi-version = 1\n' >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "storage api version 2",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StorageAPIVersion = 2
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+api_version_file=/var/lib/tikv/.api-version
+if [[ -f ${api_version_file} ]]; then
+    if [[ "$(cat ${api_version_file})" != "2" ]]; then
+        echo "the api-version of data dir /var/lib/tikv is $(cat ${api_version_file}), it can not be changed to 2, exiting."
+        exit 13
+    fi
+elif [[ -d /var/lib/tikv/db ]]; then
+    echo "api-version 2 can only be set on bootstrap, data dir /var/lib/tikv already has data without the api-version recorded, exiting."
+    exit 13
+fi
+mkdir -p /var/lib/tikv && echo 2 > ${api_version_file}
+cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+if grep -q '^api-version *=' /var/lib/tikv/runtime-tikv.toml; then
+    echo "api-version is set in the config file, the one of the spec is not applied"
+elif grep -q '^\[storage\]' /var/lib/tikv/runtime-tikv.toml; then
+    sed -i 's/^\[storage\]$/&\napi-version = 2/' /var/lib/tikv/runtime-tikv.toml
+else
+    printf '\n[storage]\napi-version = 2\n' >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "in-memory engine",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-in-memory-engine": "/var/lib/tikv-memory"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+if ! awk -v dir=/var/lib/tikv-memory '$2 == dir && $3 == "tmpfs" { found = 1 } END { exit !found }' /proc/mounts; then
+    echo "/var/lib/tikv-memory is not a memory-backed mount, the in-memory engine can not be enabled, exiting."
+    exit 13
+fi
+cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+if grep -q '^\[in-memory-engine\]' /var/lib/tikv/runtime-tikv.toml; then
+    echo "in-memory-engine is set in the config file, it is not enabled by the annotation"
+else
+    printf '\n[in-memory-engine]\nenable = true\n' >> /var/lib/tikv/runtime-tikv.toml
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv-memory \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "################################################################"
+echo "WARNING: the data dir /var/lib/tikv-memory of tikv-server is in memory,"
+echo "the data is EPHEMERAL and lost once the pod is restarted."
+echo "The in-memory engine is experimental, do not use it in production."
+echo "################################################################"
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "pd srv domain",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-pd-srv-domain": "pd.example.com"}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+# the targets of the SRV records are ordered by priority and weight, a failed lookup falls back to the PD service
+pd_srv_addr=$(dig _pd._tcp.pd.example.com SRV +short 2>/dev/null | sort -k1,1n -k2,2nr | awk 'NF == 4 { sub(/\.$/, "", $4); printf "%s%s:%s", sep, $4, $3; sep = "," }') || true
+if [[ -n "${pd_srv_addr}" ]]; then
+    echo "resolved PD addresses ${pd_srv_addr} from the SRV records of _pd._tcp.pd.example.com"
+else
+    pd_srv_addr=start-script-test-pd:2379
+    echo "no SRV record of _pd._tcp.pd.example.com is resolved, using PD addresses ${pd_srv_addr}"
+fi
+
+ARGS="--pd=${pd_srv_addr} \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "reserve space percent",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.ReserveSpacePercent = 10
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+capacity=${CAPACITY}
+case "${capacity}" in
+*GB) capacity_bytes=$(( ${capacity%GB} * 1024 * 1024 * 1024 )) ;;
+*MB) capacity_bytes=$(( ${capacity%MB} * 1024 * 1024 )) ;;
+0) mkdir -p /var/lib/tikv && capacity_bytes=$(( $(df -Pk /var/lib/tikv | awk 'NR == 2 { print $2 }') * 1024 )) ;;
+*) capacity_bytes=${capacity} ;;
+esac
+reserve_space=$(( capacity_bytes / 100 * 10 + capacity_bytes % 100 * 10 / 100 ))
+cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml
+if grep -q '^reserve-space *=' /var/lib/tikv/runtime-tikv.toml; then
+    echo "reserve-space is set in the config file, the one of the spec is not applied"
+else
+    echo "reserving ${reserve_space} bytes (10% of the capacity ${capacity}) for TiKV"
+    if grep -q '^\[storage\]' /var/lib/tikv/runtime-tikv.toml; then
+        sed -i "s/^\[storage\]$/&\nreserve-space = \"${reserve_space}B\"/" /var/lib/tikv/runtime-tikv.toml
+    else
+        printf '\n[storage]\nreserve-space = "%sB"\n' "${reserve_space}" >> /var/lib/tikv/runtime-tikv.toml
+    fi
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/var/lib/tikv/runtime-tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "min free space",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				q := resource.MustParse("10Gi")
+				tc.Spec.TiKV.MinFreeSpace = &q
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+mkdir -p /var/lib/tikv
+free_space=$(( $(df -Pk /var/lib/tikv | awk 'NR == 2 { print $4 }') * 1024 ))
+if [[ ${free_space} -lt 10737418240 ]]; then
+    echo "only ${free_space} bytes are free in data dir /var/lib/tikv, at least 10737418240 bytes are required, exiting."
+    exit 13
+fi
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Logf("test case: %s", c.name)
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.AcrossK8s = false
+		tc.Spec.ClusterDomain = ""
+		tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: false}
+
+		if c.modifyTC != nil {
+			c.modifyTC(tc)
+		}
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if diff := cmp.Diff(c.expectScript, script); diff != "" {
+			t.Errorf("unexpected (-want, +got): %s", diff)
+		}
+		g.Expect(validateScript(script)).Should(gomega.Succeed())
+	}
+}
+
+func TestRenderTiKVStartScriptWithInvalidNumaNode(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, node := range []string{"", "-1", "node0", "0,1"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/numa-node": node}
+
+		_, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "numa node %q", node)
+	}
+}
+
+func TestRenderTiKVStartScriptWithInvalidAdvertisePort(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, port := range []int32{-1, 65536} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.AdvertisePort = port
+
+		_, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "advertise port %d", port)
+	}
+}
+
+func TestRenderTiKVStartScriptWithInvalidRecoverMode(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, mode := range []string{"", "yes", "enabled"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-recover-mode": mode}
+
+		_, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "recover mode %q", mode)
+	}
+}
+
+func TestRenderTiKVStartScriptWithInvalidBinaryPath(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, path := range []string{"", "tikv-server", "/usr/local/bin/tikv server", "/tikv-server && id"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-binary-path": path}
+
+		_, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "binary path %q", path)
+	}
+}
+
+func TestRenderTiKVStartScriptWithArchBinaryPaths(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = annotations
+		return tc
+	}
+
+	script, err := RenderTiKVStartScript(newTC(map[string]string{
+		label.AnnTiKVArchBinaryPaths: "arm64=/arm64/tikv-server, amd64=/amd64/tikv-server",
+		label.AnnTiKVBinaryPath:      "/usr/local/bin/tikv-server",
+	}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	archBlock := `
+# the arches reported by uname are mapped to the ones of Go and Kubernetes
+TIKV_BINARY_PATH=/usr/local/bin/tikv-server
+arch=$(uname -m)
+case ${arch} in
+x86_64) arch=amd64 ;;
+aarch64) arch=arm64 ;;
+esac
+case ${arch} in
+amd64) TIKV_BINARY_PATH=/amd64/tikv-server ;;
+arm64) TIKV_BINARY_PATH=/arm64/tikv-server ;;
+*) echo "no binary path of tikv-server for arch ${arch}, use /usr/local/bin/tikv-server" ;;
+esac
+`
+	g.Expect(script).Should(gomega.ContainSubstring(archBlock))
+	g.Expect(script).Should(gomega.HaveSuffix("echo \"${TIKV_BINARY_PATH} ${ARGS}\"\nexec ${TIKV_BINARY_PATH} ${ARGS}\n"))
+
+	// the path is selected by the arch reported by uname at runtime
+	cases := map[string]string{
+		"x86_64":  "/amd64/tikv-server",
+		"aarch64": "/arm64/tikv-server",
+		"arm64":   "/arm64/tikv-server",
+		"riscv64": "/usr/local/bin/tikv-server",
+	}
+	for machine, expect := range cases {
+		fragment := fmt.Sprintf("uname() { echo %s; }\n%s\necho -n ${TIKV_BINARY_PATH} >&2\n", machine, archBlock)
+		file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+		g.Expect(err).Should(gomega.Succeed())
+		var stderr bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, &stderr))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "machine %s", machine)
+		g.Expect(stderr.String()).Should(gomega.Equal(expect), "machine %s", machine)
+	}
+
+	// the arch paths are used by all the ways of executing tikv-server
+	script, err = RenderTiKVStartScript(newTC(map[string]string{
+		label.AnnTiKVArchBinaryPaths: "amd64=/amd64/tikv-server",
+		label.AnnTiKVExecAttempts:    "3",
+		"tidb.pingcap.com/numa-node": "1",
+	}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("\n    exec numactl --cpunodebind=1 --membind=1 ${TIKV_BINARY_PATH} ${ARGS}\n"))
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("/tikv-server ${ARGS}"))
+
+	// the binary path is used as it is without the annotation
+	script, err = RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("uname -m"))
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("TIKV_BINARY_PATH"))
+	g.Expect(script).Should(gomega.HaveSuffix("exec /tikv-server ${ARGS}\n"))
+
+	for _, paths := range []string{"", "amd64", "amd64=", "=/tikv-server", "amd64=/a/tikv-server,amd64=/b/tikv-server", "x86-64=/tikv-server", "amd64=tikv-server", "amd64=/tikv-server;id"} {
+		_, err := RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVArchBinaryPaths: paths}))
+		g.Expect(err).Should(gomega.HaveOccurred(), "arch binary paths %q", paths)
+	}
+}
+
+func TestRenderTiKVStartScriptWithExecAttempts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(attempts string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		if attempts != "" {
+			tc.Spec.TiKV.Annotations = map[string]string{"tidb.pingcap.com/tikv-exec-attempts": attempts}
+		}
+		return tc
+	}
+
+	// the retry loop is only rendered if there are more than one attempt
+	for _, attempts := range []string{"", "1"} {
+		script, err := RenderTiKVStartScript(newTC(attempts))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("execfail"), "exec attempts %q", attempts)
+		g.Expect(strings.Count(script, "exec /tikv-server")).Should(gomega.Equal(1), "exec attempts %q", attempts)
+	}
+
+	script, err := RenderTiKVStartScript(newTC("5"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("shopt -s execfail"))
+	g.Expect(script).Should(gomega.ContainSubstring("for attempt in $(seq 1 5)"))
+	g.Expect(script).Should(gomega.HaveSuffix("exit 14\n"))
+
+	for _, attempts := range []string{"0", "-1", "three"} {
+		_, err := RenderTiKVStartScript(newTC(attempts))
+		g.Expect(err).Should(gomega.HaveOccurred(), "exec attempts %q", attempts)
+	}
+}
+
+func TestRenderTiKVStartScriptWithDisableStatusServer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{
+				DisableStatusServer: true,
+			},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("--status-addr"))
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("--advertise-status-addr"))
+
+	_, err = RenderTiKVReadinessScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+
+	tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
+
+func TestRenderTiKVStartScriptWithInvalidDataSubDir(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, subDir := range []string{"/data", "..", "../data", "data/../../tikv"} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.DataSubDir = subDir
+
+		_, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.HaveOccurred(), "data sub dir %q", subDir)
+	}
+}
+
+func TestRenderTiKVStartScriptWithRaftEngineSubDir(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(subDir string, volumes ...v1alpha1.StorageVolume) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					StorageVolumes:   volumes,
+					RaftEngineSubDir: subDir,
+				},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+	raftVolume := v1alpha1.StorageVolume{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"}
+
+	for _, subDir := range []string{"/raft", "..", "../raft", "raft/../../tikv"} {
+		_, err := RenderTiKVStartScript(newTC(subDir, raftVolume))
+		g.Expect(err).Should(gomega.HaveOccurred(), "raft engine sub dir %q", subDir)
+	}
+
+	// the sub dir is ignored if the raft volume is not mounted
+	script, err := RenderTiKVStartScript(newTC("raft-engine", v1alpha1.StorageVolume{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("raft-engine"))
+
+	script, err = RenderTiKVStartScript(newTC("raft/engine", raftVolume))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("mkdir -p /var/lib/raft/raft/engine\n"))
+}
+
+func TestRenderTiKVStartScriptWithPodLabelsAsStoreLabels(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	labelsFile := filepath.Join(t.TempDir(), "labels")
+	g.Expect(os.WriteFile(labelsFile, []byte(`app.kubernetes.io/component="tikv"
+example.com/host-group="g1"
+rack="r1"
+zone="z2"`), 0644)).Should(gomega.Succeed())
+
+	cases := []struct {
+		name         string
+		storeLabels  []string
+		podLabelKeys []string
+		env          []string
+		expect       string
+	}{
+		{
+			name:         "pod labels only",
+			podLabelKeys: []string{"rack", "example.com/host-group"},
+			expect:       "rack=r1,example.com/host-group=g1",
+		},
+		{
+			name:         "static labels take precedence",
+			storeLabels:  []string{"zone=z1"},
+			podLabelKeys: []string{"zone", "rack"},
+			expect:       "zone=z1,rack=r1",
+		},
+		{
+			name:         "env labels take precedence",
+			storeLabels:  []string{"zone=z1"},
+			podLabelKeys: []string{"rack", "zone"},
+			env:          []string{"STORE_LABELS=rack=r0"},
+			expect:       "zone=z1,rack=r0",
+		},
+		{
+			name:         "missing pod labels are skipped",
+			podLabelKeys: []string{"host", "rack", "component"},
+			expect:       "rack=r1",
+		},
+	}
+	for _, c := range cases {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					StoreLabels:            c.storeLabels,
+					PodLabelsAsStoreLabels: c.podLabelKeys,
+				},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+
+		// run the part computing the store labels against the labels file
+		begin := strings.Index(script, "\nSTORE_LABELS=")
+		if begin < 0 {
+			begin = strings.Index(script, "\n# the pod labels are skipped")
+		}
+		end := strings.Index(script, `if [ ! -z "${STORE_LABELS:-}" ]`)
+		fragment := strings.ReplaceAll(script[begin:end], "/etc/podinfo/labels", labelsFile)
+		file, err := syntax.NewParser().Parse(strings.NewReader(fragment+`printf "%s" "${STORE_LABELS:-}" >&2`), "")
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+		var stderr bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron(append(c.env, "PATH="+os.Getenv("PATH"))...)), interp.StdIO(nil, io.Discard, &stderr))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(stderr.String()).Should(gomega.Equal(c.expect), "case %s", c.name)
+	}
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{PodLabelsAsStoreLabels: []string{"rack;id"}},
+		},
+	}
+	_, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
+
+func TestRenderTiKVStartScriptWithTopologyStoreLabels(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		name        string
+		storeLabels []string
+		podLabels   string
+		expect      string
+		wantExit    bool
+	}{
+		{
+			name:      "topology labels copied to the pod",
+			podLabels: "topology.kubernetes.io/region=\"r1\"\ntopology.kubernetes.io/zone=\"z1\"",
+			expect:    "region=r1,zone=z1",
+		},
+		{
+			name:      "missing region is skipped",
+			podLabels: "app.kubernetes.io/component=\"tikv\"\ntopology.kubernetes.io/zone=\"z1\"",
+			expect:    "zone=z1",
+		},
+		{
+			name:        "static labels take precedence",
+			storeLabels: []string{"zone=z0"},
+			podLabels:   "topology.kubernetes.io/zone=\"z1\"",
+			expect:      "zone=z0",
+		},
+		{
+			name:        "static labels without topology labels on the pod",
+			storeLabels: []string{"zone=z0"},
+			podLabels:   "app.kubernetes.io/component=\"tikv\"",
+			expect:      "zone=z0",
+		},
+		{
+			name:      "topology labels not copied to the pod",
+			podLabels: "app.kubernetes.io/component=\"tikv\"",
+			wantExit:  true,
+		},
+	}
+	for _, c := range cases {
+		labelsFile := filepath.Join(t.TempDir(), "labels")
+		g.Expect(os.WriteFile(labelsFile, []byte(c.podLabels), 0644)).Should(gomega.Succeed())
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV:                      &v1alpha1.TiKVSpec{StoreLabels: c.storeLabels},
+				StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTopologyStoreLabels},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+
+		// run the part computing the store labels against the labels file
+		begin := strings.Index(script, "\nSTORE_LABELS=")
+		if begin < 0 {
+			begin = strings.Index(script, "\n# the well-known topology labels")
+		}
+		end := strings.Index(script, `if [ ! -z "${STORE_LABELS:-}" ]`)
+		fragment := strings.ReplaceAll(script[begin:end], "/etc/podinfo/labels", labelsFile)
+		file, err := syntax.NewParser().Parse(strings.NewReader(fragment+`printf "%s" "${STORE_LABELS:-}"`), "")
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+		var stdout, stderr bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, &stderr))
+		g.Expect(err).Should(gomega.Succeed())
+		err = runner.Run(context.Background(), file)
+		if c.wantExit {
+			g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeGeneric)), "case %s", c.name)
+			g.Expect(stderr.String()).Should(gomega.ContainSubstring("must be copied from the node to the pod"), "case %s", c.name)
+			continue
+		}
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(stdout.String()).Should(gomega.Equal(c.expect), "case %s", c.name)
+	}
+}
+
+func TestRenderTiKVStartScriptWithFixDataDirPermissions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, fix := range []bool{false, true} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.DataSubDir = "data"
+		tc.Spec.TiKV.FixDataDirPermissions = fix
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if !fix {
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("chmod"))
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("chown"))
+			continue
+		}
+		g.Expect(script).Should(gomega.ContainSubstring(`chown "$(id -u):$(id -g)" /var/lib/tikv/data `))
+		g.Expect(script).Should(gomega.ContainSubstring("chmod go-w /var/lib/tikv/data;"))
+		// the mount path of data volume is not touched
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("/var/lib/tikv "))
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("/var/lib/tikv;"))
+	}
+}
+
+func TestRenderTiKVStartScriptWithStartupDelay(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, delay := range []int{-1, 0, 3} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.AcrossK8s = true
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch}
+		tc.Spec.TiKV.StartupDelaySeconds = delay
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if delay <= 0 {
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("sleep %d", delay), "delay %d", delay)
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("before starting tikv-server"), "delay %d", delay)
+			continue
+		}
+		// the delay is before waiting for DNS and verifying PD endpoints
+		sleepIndex := strings.Index(script, "\nsleep 3\n")
+		g.Expect(sleepIndex).Should(gomega.BeNumerically(">", 0))
+		g.Expect(sleepIndex).Should(gomega.BeNumerically("<", strings.Index(script, "componentDomain=")))
+		g.Expect(sleepIndex).Should(gomega.BeNumerically("<", strings.Index(script, "discovery_url=")))
+	}
+}
+
+func TestRenderTiKVStartScriptWithTextfileDir(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = annotations
+		return tc
+	}
+
+	// nothing is written without the textfile dir
+	script, err := RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("textfile"))
+
+	tc := newTC(map[string]string{"tidb.pingcap.com/tikv-textfile-dir": "/var/lib/node-exporter/textfile"})
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagModelChecksum}
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("if [[ -d /var/lib/node-exporter/textfile ]]; then\n"))
+	g.Expect(script).Should(gomega.ContainSubstring("textfile=/var/lib/node-exporter/textfile/tikv_start_script_start-script-test-ns_${TIKV_POD_NAME}.prom\n"))
+	// the metric carries the same checksum as the header comment
+	checksum := ScriptModelChecksum(script)
+	g.Expect(checksum).Should(gomega.HaveLen(64))
+	g.Expect(script).Should(gomega.ContainSubstring(`tidb_operator_start_script_model_info{component=\"tikv\",pod=\"${TIKV_POD_NAME}\",checksum=\"` + checksum + `\"} 1`))
+
+	for _, dir := range []string{"textfile", "/var/lib/node-exporter/../textfile", "/var/lib/$(id)"} {
+		_, err := RenderTiKVStartScript(newTC(map[string]string{"tidb.pingcap.com/tikv-textfile-dir": dir}))
+		g.Expect(err).Should(gomega.HaveOccurred(), "textfile dir %q", dir)
+	}
+}
+
+func TestRenderTiKVStartScriptWithModelChecksum(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func() *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					StoreLabels: []string{"zone=z1", "host=h1", "rack=r1"},
+				},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagModelChecksum}
+		return tc
+	}
+
+	script, err := RenderTiKVStartScript(newTC())
+	g.Expect(err).Should(gomega.Succeed())
+	checksum := ScriptModelChecksum(script)
+	g.Expect(checksum).Should(gomega.HaveLen(64))
+	g.Expect(strings.SplitN(script, "\n", 3)[:2]).Should(gomega.Equal([]string{"#!/bin/sh", "# model-checksum: " + checksum}))
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+
+	// identical specs have identical checksums, the map of store labels does not affect the order
+	for i := 0; i < 10; i++ {
+		script, err := RenderTiKVStartScript(newTC())
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(ScriptModelChecksum(script)).Should(gomega.Equal(checksum))
+	}
+
+	tc := newTC()
+	tc.Spec.TiKV.LogLevel = "debug"
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(ScriptModelChecksum(script)).ShouldNot(gomega.Equal(checksum))
+
+	// the checksum is not rendered without the feature flag
+	tc = newTC()
+	tc.Spec.StartScriptV2FeatureFlags = nil
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(ScriptModelChecksum(script)).Should(gomega.BeEmpty())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("model-checksum"))
+}
+
+func TestRenderTiKVStartScriptWithWaitForPDLeader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := map[string]struct {
+		modify  func(tc *v1alpha1.TidbCluster)
+		pdAddr  string
+		url     string
+		curl    string
+		timeout int
+	}{
+		"basic": {
+			modify:  func(tc *v1alpha1.TidbCluster) {},
+			pdAddr:  "pd_addr=start-script-test-pd:2379\n",
+			url:     "pd_leader_url=http://${pd_addr%%,*}/pd/api/v1/leader\n",
+			curl:    "curl -s --fail ${pd_leader_url}",
+			timeout: 30,
+		},
+		"tls and start timeout": {
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+				tc.Spec.TiKV.StartTimeout = 60
+			},
+			pdAddr:  "pd_addr=start-script-test-pd:2379\n",
+			url:     "pd_leader_url=https://${pd_addr%%,*}/pd/api/v1/leader\n",
+			curl:    "curl -s --fail --cacert /var/lib/tikv-tls/ca.crt --cert /var/lib/tikv-tls/tls.crt --key /var/lib/tikv-tls/tls.key ${pd_leader_url}",
+			timeout: 60,
+		},
+		"multiple pd addresses": {
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 2}
+				tc.Spec.StartScriptV2FeatureFlags = append(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses)
+			},
+			pdAddr:  "pd_addr=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379,start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc:2379\n",
+			url:     "pd_leader_url=http://${pd_addr%%,*}/pd/api/v1/leader\n",
+			curl:    "curl -s --fail ${pd_leader_url}",
+			timeout: 30,
+		},
+		"across k8s": {
+			modify: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.AcrossK8s = true
+			},
+			pdAddr:  "pd_addr=${result}\n",
+			url:     "pd_leader_url=http://${pd_addr%%,*}/pd/api/v1/leader\n",
+			curl:    "curl -s --fail ${pd_leader_url}",
+			timeout: 30,
+		},
+	}
+
+	for name, test := range tests {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForPDLeader}
+		test.modify(tc)
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "case %s", name)
+		g.Expect(script).Should(gomega.ContainSubstring(test.pdAddr), "case %s", name)
+		g.Expect(script).Should(gomega.ContainSubstring(test.url), "case %s", name)
+		g.Expect(script).Should(gomega.ContainSubstring(test.curl), "case %s", name)
+		g.Expect(script).Should(gomega.ContainSubstring("-ge %d ]]", test.timeout), "case %s", name)
+		// the PD address is resolved before the wait when the cluster is deployed across k8s
+		waitIndex := strings.Index(script, "pd_leader_url=")
+		g.Expect(waitIndex).Should(gomega.BeNumerically("<", strings.Index(script, "exec /tikv-server")), "case %s", name)
+		if tc.Spec.AcrossK8s {
+			g.Expect(strings.Index(script, "until result=")).Should(gomega.BeNumerically("<", waitIndex), "case %s", name)
+		}
+	}
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("pd_leader_url"))
+}
+
+func TestRenderTiKVStartScriptWithEvictLeaderCleanup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		name string
+		// storeID is the store recorded by the preStop script, nothing is recorded if it is empty
+		storeID    string
+		pdFailed   bool
+		expect     string
+		keepRecord bool
+	}{
+		{name: "scheduler recorded", storeID: "4", expect: "DELETE http://start-script-test-pd:2379/pd/api/v1/schedulers/evict-leader-scheduler-4\nremoved the evict leader scheduler of store 4\n"},
+		{name: "nothing recorded", expect: ""},
+		{name: "pd unavailable", storeID: "4", pdFailed: true, expect: "DELETE http://start-script-test-pd:2379/pd/api/v1/schedulers/evict-leader-scheduler-4\n", keepRecord: true},
+	}
+	for _, c := range cases {
+		schedulerFile := filepath.Join(t.TempDir(), "evict-leader-scheduler")
+		if c.storeID != "" {
+			g.Expect(os.WriteFile(schedulerFile, []byte(c.storeID+"\n"), 0644)).Should(gomega.Succeed())
+		}
+
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{PreStop: &v1alpha1.TiKVPreStopSpec{}},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+
+		// run the part removing the scheduler with curl replaced by a function
+		begin := strings.Index(script, "\n# the evict leader scheduler")
+		end := strings.Index(script, "\nARGS=")
+		fragment := strings.ReplaceAll(script[begin:end], tikvEvictLeaderSchedulerFile, schedulerFile)
+		fakes := `curl() {
+    echo "DELETE ${@: -1}"
+    [ "${PD_FAILED}" != true ]
+}
+`
+		file, err := syntax.NewParser().Parse(strings.NewReader(fakes+fragment), "")
+		g.Expect(err).Should(gomega.Succeed(), "case %s", c.name)
+		var stdout, stderr bytes.Buffer
+		runner, err := interp.New(interp.Env(expand.ListEnviron(fmt.Sprintf("PD_FAILED=%t", c.pdFailed), "PATH="+os.Getenv("PATH"))), interp.StdIO(nil, &stdout, &stderr))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed(), "case %s", c.name)
+		g.Expect(stdout.String()).Should(gomega.Equal(c.expect), "case %s", c.name)
+		if c.keepRecord {
+			// the scheduler is removed on the next start
+			g.Expect(stderr.String()).Should(gomega.ContainSubstring("failed to remove the evict leader scheduler of store 4"), "case %s", c.name)
+			g.Expect(schedulerFile).Should(gomega.BeAnExistingFile(), "case %s", c.name)
+		} else {
+			g.Expect(schedulerFile).ShouldNot(gomega.BeAnExistingFile(), "case %s", c.name)
+		}
+	}
+
+	// the scheduler is only removed if the preStop script is used
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("evict-leader-scheduler"))
+}
+
+func TestRenderTiKVStartScriptWithVersion(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cases := []struct {
+		version string
+		expect  bool
+	}{
+		{version: "v3.0.20", expect: false},
+		{version: "v3.1.2", expect: false},
+		{version: "v4.0.0-rc.1", expect: true},
+		{version: "v4.0.0", expect: true},
+		{version: "v8.5.0", expect: true},
+		{version: "latest", expect: true},
+		{version: "nightly", expect: true},
+		// custom tags are regarded as new versions
+		{version: "my-custom-build", expect: true},
+	}
+	for _, c := range cases {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.BaseImage = "pingcap/tikv"
+		tc.Spec.Version = c.version
+		tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "version %s", c.version)
+		flag := "--advertise-status-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20180"
+		if c.expect {
+			g.Expect(script).Should(gomega.ContainSubstring(flag), "version %s", c.version)
+		} else {
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("--advertise-status-addr"), "version %s", c.version)
+		}
+
+		// the version of TiKV overrides the one of the cluster
+		tc.Spec.TiKV.Version = pointer.StringPtr("v3.1.2")
+		script, err = RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed(), "version %s", c.version)
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("--advertise-status-addr"), "version %s", c.version)
+	}
+}
+
+func TestRenderTiKVStartScriptWithCheckDataDirFsync(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, check := range []bool{false, true} {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.DataSubDir = "data"
+		tc.Spec.TiKV.CheckDataDirFsync = check
+
+		script, err := RenderTiKVStartScript(tc)
+		g.Expect(err).Should(gomega.Succeed())
+		if !check {
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("fsync"))
+			continue
+		}
+		g.Expect(script).Should(gomega.ContainSubstring("fsync_check_file=/var/lib/tikv/data/.fsync-check.$$\n"))
+		// the check is done before starting TiKV
+		g.Expect(strings.Index(script, "conv=fsync")).Should(gomega.BeNumerically("<", strings.Index(script, "exec /tikv-server")))
+
+		// run the check against a writable dir and a dir which can not be created
+		begin := strings.Index(script, "\nmkdir -p /var/lib/tikv/data\n")
+		end := strings.Index(script, "\nARGS=")
+		tmp := t.TempDir()
+		g.Expect(os.WriteFile(filepath.Join(tmp, "file"), nil, 0644)).Should(gomega.Succeed())
+		for dir, succeed := range map[string]bool{filepath.Join(tmp, "data"): true, filepath.Join(tmp, "file", "data"): false} {
+			fragment := strings.ReplaceAll(script[begin:end], "/var/lib/tikv/data", dir)
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if succeed {
+				g.Expect(err).Should(gomega.Succeed(), "dir %s", dir)
+				entries, err := os.ReadDir(dir)
+				g.Expect(err).Should(gomega.Succeed())
+				g.Expect(entries).Should(gomega.BeEmpty(), "the check file is removed")
+			} else {
+				g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeStorageCheckFailed)), "dir %s", dir)
+			}
+		}
+	}
+}
+
+func TestRenderTiKVStartScriptWithPDAddrCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{},
+		},
+	}
+	tc.Name = "start-script-test"
+	tc.Namespace = "start-script-test-ns"
+	tc.Spec.AcrossK8s = true
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagCachePDAddr}
+
+	script, err := RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+
+	begin := strings.Index(script, "\npd_url=")
+	end := strings.Index(script, "\nARGS=")
+	cases := []struct {
+		name           string
+		cached         string
+		pdReachable    bool
+		expectedResult string
+		verified       bool
+	}{
+		{
+			name:           "no cache",
+			pdReachable:    true,
+			expectedResult: "pd-from-discovery:2379",
+			verified:       true,
+		},
+		{
+			name:           "cached PD is reachable",
+			cached:         "pd-from-cache:2379",
+			pdReachable:    true,
+			expectedResult: "pd-from-cache:2379",
+		},
+		{
+			name:           "cached PD is unreachable",
+			cached:         "pd-from-cache:2379",
+			expectedResult: "pd-from-discovery:2379",
+			verified:       true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+			tmp := t.TempDir()
+			cacheFile := filepath.Join(tmp, "tikv", ".pd-addr-cache")
+			verifiedFile := filepath.Join(tmp, "verified")
+			if c.cached != "" {
+				g.Expect(os.MkdirAll(filepath.Dir(cacheFile), 0755)).Should(gomega.Succeed())
+				g.Expect(os.WriteFile(cacheFile, []byte(c.cached+"\n"), 0644)).Should(gomega.Succeed())
+			}
+			curlStatus := 1
+			if c.pdReachable {
+				curlStatus = 0
+			}
+			// curl and wget are replaced by functions, the verification through discovery is recorded in a file
+			// as it is run in a subshell
+			stubs := fmt.Sprintf("curl() { return %d; }\nwget() { touch %s; echo http://pd-from-discovery:2379; }\n", curlStatus, verifiedFile)
+			fragment := stubs + strings.ReplaceAll(script[begin:end], "/var/lib/tikv/.pd-addr-cache", cacheFile)
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			g.Expect(runner.Vars["result"].String()).Should(gomega.Equal(c.expectedResult))
+			_, err = os.Stat(verifiedFile)
+			g.Expect(err == nil).Should(gomega.Equal(c.verified))
+			cached, err := os.ReadFile(cacheFile)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(strings.TrimSpace(string(cached))).Should(gomega.Equal(c.expectedResult))
+		})
+	}
+}
+
+func TestRenderTiKVStartScriptWithLogFile(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func() *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	// TiKV logs to stdout by default
+	script, err := RenderTiKVStartScript(newTC())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("--log-file"))
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("[log.file]"))
+
+	tc := newTC()
+	tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log", MaxSize: 300, MaxBackups: 10}
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring(`ARGS="${ARGS} --log-file=/var/lib/tikv/log/tikv.log"`))
+
+	// run the rotation block against the config files with and without log.file
+	begin := strings.Index(script, "\nmkdir -p $(dirname /var/lib/tikv/log/tikv.log)\n")
+	end := strings.Index(script, "\nARGS=")
+	for config, expected := range map[string]string{
+		"log-level = \"info\"\n":       "log-level = \"info\"\n\n[log.file]\nmax-size = 300\nmax-backups = 10\n",
+		"[log.file]\nmax-size = 100\n": "[log.file]\nmax-size = 100\n",
+	} {
+		tmp := t.TempDir()
+		configFile := filepath.Join(tmp, "tikv.toml")
+		g.Expect(os.WriteFile(configFile, []byte(config), 0644)).Should(gomega.Succeed())
+		fragment := strings.NewReplacer(
+			"/etc/tikv/tikv.toml", configFile,
+			"/var/lib/tikv", tmp,
+		).Replace(script[begin:end])
+		file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+		g.Expect(err).Should(gomega.Succeed())
+		runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+		runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(string(runtimeConfig)).Should(gomega.Equal(expected))
+		g.Expect(filepath.Join(tmp, "log")).Should(gomega.BeADirectory())
+	}
+
+	tc = newTC()
+	tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "log/tikv.log"}
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
+
+func TestRenderTiKVStartScriptWithPrologue(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func() *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	const prologue = "ulimit -n 1000000"
+
+	script, err := RenderTiKVStartScript(newTC())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring(prologue))
+
+	// the prologue is after the common script and before composing ARGS, even with the optional blocks
+	tc := newTC()
+	tc.Spec.TiKV.StartScriptPrologue = prologue + "\n"
+	tc.Spec.TiKV.LogFile = &v1alpha1.TiKVLogFileSpec{Path: "/var/lib/tikv/log/tikv.log"}
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.Count(script, prologue)).Should(gomega.Equal(1))
+	idx := strings.Index(script, "\n"+prologue+"\n\nARGS=")
+	g.Expect(idx).Should(gomega.BeNumerically(">", strings.Index(script, "runmode=")))
+	g.Expect(idx).Should(gomega.BeNumerically(">", strings.Index(script, "mkdir -p $(dirname /var/lib/tikv/log/tikv.log)")))
+
+	// the prologue is not rendered as a template
+	tc = newTC()
+	tc.Spec.TiKV.StartScriptPrologue = `echo "{{ .DataDir }}"`
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring(`echo "{{ .DataDir }}"`))
+
+	tc = newTC()
+	tc.Spec.TiKV.StartScriptPrologue = " \n\t\n"
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+}
+
+func TestRenderTiKVStartScriptWithThreadPoolsSizedByCPULimit(t *testing.T) {
+	newTC := func(sizeThreadPools bool) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					SizeThreadPoolsByCPULimit: sizeThreadPools,
+				},
+				StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota},
+			},
+		}
+		tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	// TiKV sizes the pools by itself by default
+	script, err := RenderTiKVStartScript(newTC(false))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("max-thread-count"))
+	tc := newTC(true)
+	tc.Spec.TiKV.Limits = nil
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("max-thread-count"))
+
+	script, err = RenderTiKVStartScript(newTC(true))
+	g.Expect(err).Should(gomega.Succeed())
+	begin := strings.Index(script, "\nif grep -q '^\\[quota\\]'")
+	end := strings.Index(script, "\nARGS=")
+
+	cases := []struct {
+		name     string
+		cpuLimit string
+		config   string
+		expected string
+	}{
+		{
+			name:     "less than one core",
+			cpuLimit: "500",
+			expected: "\n[readpool.unified]\nmax-thread-count = 4\n\n[storage]\nscheduler-worker-pool-size = 1\n",
+		},
+		{
+			name:     "4 cores",
+			cpuLimit: "4000",
+			expected: "\n[readpool.unified]\nmax-thread-count = 4\n\n[storage]\nscheduler-worker-pool-size = 4\n",
+		},
+		{
+			name:     "10.5 cores",
+			cpuLimit: "10500",
+			expected: "\n[readpool.unified]\nmax-thread-count = 8\n\n[storage]\nscheduler-worker-pool-size = 4\n",
+		},
+		{
+			name:     "32 cores",
+			cpuLimit: "32000",
+			expected: "\n[readpool.unified]\nmax-thread-count = 25\n\n[storage]\nscheduler-worker-pool-size = 8\n",
+		},
+		{
+			name:     "pools are configured",
+			cpuLimit: "32000",
+			config:   "[readpool.unified]\nmax-thread-count = 2\n\n[storage]\nreserve-space = \"0MB\"\n",
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv", tmp,
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			env := expand.ListEnviron("PATH="+os.Getenv("PATH"), "TIKV_CPU_LIMIT="+c.cpuLimit)
+			runner, err := interp.New(interp.Env(env), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.config + "\n[quota]\nforeground-cpu-time = " + c.cpuLimit + "\n" + c.expected))
+		})
+	}
+}
+
+func TestRenderTiKVStartScriptWithReadinessFile(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Spec.TiKV.Annotations = annotations
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	// no watcher is started by default
+	script, err := RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("/status"))
+
+	tc := newTC(map[string]string{label.AnnTiKVReadinessFile: "ready"})
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+
+	tc = newTC(map[string]string{label.AnnTiKVReadinessFile: ""})
+	tc.Spec.TiKV.DisableStatusServer = true
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+
+	// the file is touched after the status server is up
+	file := filepath.Join(t.TempDir(), "ready", "tikv-ready")
+	script, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVReadinessFile: file}))
+	g.Expect(err).Should(gomega.Succeed())
+	begin := strings.Index(script, "\nrm -f "+file)
+	end := strings.Index(script, "\necho \"starting tikv-server ...\"")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+	attempts := filepath.Join(t.TempDir(), "attempts")
+	stubs := fmt.Sprintf(`
+sleep() { :; }
+curl() {
+    echo >> %s
+    [[ $(wc -l < %s) -ge 3 ]]
+}
+`, attempts, attempts)
+	fragment := stubs + script[begin:end] + "\nwait\n"
+	parsed, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+	g.Expect(err).Should(gomega.Succeed())
+	runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(runner.Run(context.Background(), parsed)).Should(gomega.Succeed())
+	g.Expect(file).Should(gomega.BeARegularFile())
+	content, err := os.ReadFile(attempts)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.Count(string(content), "\n")).Should(gomega.Equal(3))
+}
+
+func TestRenderTiKVStartScriptWithMetricsPush(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Spec.TiKV.Annotations = annotations
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	// no sender is started by default
+	script, err := RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("/metrics"))
+
+	for _, annotations := range []map[string]string{
+		{label.AnnTiKVMetricsPushGateway: ""},
+		{label.AnnTiKVMetricsPushGateway: "http://pushgateway"},
+		{label.AnnTiKVMetricsPushGateway: "http://pushgateway:9091; rm -rf /"},
+		{label.AnnTiKVMetricsPushGateway: "http://pushgateway:9091", label.AnnTiKVMetricsPushInterval: "0"},
+		{label.AnnTiKVMetricsPushGateway: "http://pushgateway:9091", label.AnnTiKVMetricsPushInterval: "1m"},
+	} {
+		_, err = RenderTiKVStartScript(newTC(annotations))
+		g.Expect(err).Should(gomega.HaveOccurred(), "annotations %v", annotations)
+	}
+
+	tc := newTC(map[string]string{label.AnnTiKVMetricsPushGateway: "http://pushgateway:9091"})
+	tc.Spec.TiKV.DisableStatusServer = true
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+
+	// the interval defaults to 15 seconds, and the trailing slash of the gateway is trimmed
+	script, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVMetricsPushGateway: "http://pushgateway:9091/"}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring(`
+# the sender is inherited by tikv-server after exec, it pushes the metrics of the status server to the gateway
+(
+    failures=0
+    while [[ ${failures} -lt 10 ]]; do
+        sleep 15
+        if curl -s --fail --globoff -m 15 http://127.0.0.1:20180/metrics 2>/dev/null |
+            curl -s --fail -m 15 --data-binary @- http://pushgateway:9091/metrics/job/tikv/namespace/start-script-test-ns/instance/${TIKV_POD_NAME} >/dev/null 2>&1; then
+            failures=0
+        else
+            failures=$(( failures+1 ))
+        fi
+    done
+    echo "failed to push the metrics of tikv-server to http://pushgateway:9091 10 times in a row, stop pushing" >&2
+) &
+`))
+
+	// the sender stops after the max consecutive failures, and a successful push resets the failures
+	script, err = RenderTiKVStartScript(newTC(map[string]string{
+		label.AnnTiKVMetricsPushGateway:  "http://pushgateway:9091",
+		label.AnnTiKVMetricsPushInterval: "30",
+	}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("        sleep 30\n"))
+	g.Expect(script).Should(gomega.ContainSubstring("curl -s --fail -m 30 --data-binary @- http://pushgateway:9091/metrics/"))
+	begin := strings.Index(script, "\n# the sender is inherited")
+	end := strings.Index(script, "\necho \"starting tikv-server ...\"")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+	pushes := filepath.Join(t.TempDir(), "pushes")
+	stubs := fmt.Sprintf(`
+set -o pipefail
+sleep() { :; }
+curl() {
+    [[ " $* " == *" --data-binary "* ]] || { echo metrics; return 0; }
+    cat > /dev/null
+    echo >> %s
+    [[ $(wc -l < %s) -eq 3 ]]
+}
+`, pushes, pushes)
+	fragment := stubs + script[begin:end] + "\nwait\n"
+	parsed, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+	g.Expect(err).Should(gomega.Succeed())
+	var stderr bytes.Buffer
+	runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"), "TIKV_POD_NAME=tikv-0")), interp.StdIO(nil, io.Discard, &stderr))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(runner.Run(context.Background(), parsed)).Should(gomega.Succeed())
+	content, err := os.ReadFile(pushes)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.Count(string(content), "\n")).Should(gomega.Equal(13))
+	g.Expect(stderr.String()).Should(gomega.ContainSubstring("stop pushing"))
+}
+
+func TestRenderTiKVStartScriptWithArgsPerLine(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(argsPerLine bool) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					AdditionalStartupFlags: []string{"--log-file=/var/log/tikv log.log"},
+				},
+			},
+		}
+		tc.Spec.TiKV.Annotations = map[string]string{label.AnnNumaNode: "1"}
+		if argsPerLine {
+			tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagArgsPerLine}
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	script, err := RenderTiKVStartScript(newTC(false))
+	g.Expect(err).Should(gomega.Succeed())
+	scriptWithArgsPerLine, err := RenderTiKVStartScript(newTC(true))
+	g.Expect(err).Should(gomega.Succeed())
+
+	// only the printing of the arguments is changed
+	const execLine = "\nexec numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS} \"$@\"\n"
+	g.Expect(script).Should(gomega.HaveSuffix(execLine))
+	g.Expect(scriptWithArgsPerLine).Should(gomega.HaveSuffix(execLine))
+	printing := "echo \"numactl --cpunodebind=1 --membind=1 /tikv-server ${ARGS} $*\"\n"
+	g.Expect(script).Should(gomega.ContainSubstring(printing))
+	g.Expect(scriptWithArgsPerLine).ShouldNot(gomega.ContainSubstring(printing))
+
+	begin := strings.Index(scriptWithArgsPerLine, "\necho \"starting tikv-server ...\"")
+	end := strings.Index(scriptWithArgsPerLine, execLine)
+	fragment := `ARGS="--pd=pd:2379 --labels zone=z1"
+set -- '--log-file=/var/log/tikv log.log'` + scriptWithArgsPerLine[begin:end]
+	file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+	g.Expect(err).Should(gomega.Succeed())
+	stdout := new(bytes.Buffer)
+	runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, stdout, io.Discard))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+	g.Expect(stdout.String()).Should(gomega.Equal(`starting tikv-server ...
+numactl --cpunodebind=1 --membind=1 /tikv-server
+    --pd=pd:2379
+    --labels
+    zone=z1
+    --log-file=/var/log/tikv log.log
+`))
+}
+
+func TestRenderTiKVStartScriptWithStorageAPIVersion(t *testing.T) {
+	render := func(apiVersion int32) string {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{StorageAPIVersion: apiVersion},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		script, err := RenderTiKVStartScript(tc)
+		gomega.NewGomegaWithT(t).Expect(err).Should(gomega.Succeed())
+		return script
+	}
+
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(render(0)).ShouldNot(gomega.ContainSubstring("api-version"))
+
+	cases := []struct {
+		name       string
+		apiVersion int32
+		// prepare creates the files in the data dir before the start script runs
+		prepare func(dataDir string) error
+		config  string
+		fail    bool
+		// expected is the runtime config file passed to TiKV
+		expected string
+	}{
+		{
+			name:       "api v1 on bootstrap",
+			apiVersion: 1,
+			expected:   "\n[storage]\napi-version = 1\n",
+		},
+		{
+			name:       "api v2 on bootstrap",
+			apiVersion: 2,
+			config:     "[storage]\nreserve-space = \"0MB\"\n",
+			expected:   "[storage]\napi-version = 2\nreserve-space = \"0MB\"\n",
+		},
+		{
+			name:       "api v2 is set in the config file",
+			apiVersion: 2,
+			config:     "[storage]\napi-version = 1\n",
+			expected:   "[storage]\napi-version = 1\n",
+		},
+		{
+			name:       "api v1 on existing data",
+			apiVersion: 1,
+			prepare: func(dataDir string) error {
+				return os.Mkdir(filepath.Join(dataDir, "db"), 0755)
+			},
+			expected: "\n[storage]\napi-version = 1\n",
+		},
+		{
+			name:       "api v2 on existing data",
+			apiVersion: 2,
+			prepare: func(dataDir string) error {
+				return os.Mkdir(filepath.Join(dataDir, "db"), 0755)
+			},
+			fail: true,
+		},
+		{
+			name:       "api v2 on existing data of api v2",
+			apiVersion: 2,
+			prepare: func(dataDir string) error {
+				if err := os.Mkdir(filepath.Join(dataDir, "db"), 0755); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dataDir, ".api-version"), []byte("2\n"), 0644)
+			},
+			expected: "\n[storage]\napi-version = 2\n",
+		},
+		{
+			name:       "flip api v1 to v2",
+			apiVersion: 2,
+			prepare: func(dataDir string) error {
+				return os.WriteFile(filepath.Join(dataDir, ".api-version"), []byte("1\n"), 0644)
+			},
+			fail: true,
+		},
+		{
+			name:       "flip api v2 to v1",
+			apiVersion: 1,
+			prepare: func(dataDir string) error {
+				return os.WriteFile(filepath.Join(dataDir, ".api-version"), []byte("2\n"), 0644)
+			},
+			fail: true,
+		},
 	}
 
 	for _, c := range cases {
-		t.Logf("test case: %s", c.name)
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tmp := t.TempDir()
+			dataDir := filepath.Join(tmp, "data")
+			g.Expect(os.Mkdir(dataDir, 0755)).Should(gomega.Succeed())
+			if c.prepare != nil {
+				g.Expect(c.prepare(dataDir)).Should(gomega.Succeed())
+			}
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
 
+			script := render(c.apiVersion)
+			begin := strings.Index(script, "\napi_version_file=")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+				"/var/lib/tikv", dataDir,
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if c.fail {
+				g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeStorageCheckFailed)))
+				g.Expect(filepath.Join(tmp, "runtime-tikv.toml")).ShouldNot(gomega.BeAnExistingFile())
+				return
+			}
+			g.Expect(err).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+			recorded, err := os.ReadFile(filepath.Join(dataDir, ".api-version"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(recorded)).Should(gomega.Equal(fmt.Sprintf("%d\n", c.apiVersion)))
+		})
+	}
+}
+
+func TestRenderTiKVStartScriptWithInMemoryEngine(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
 		tc := &v1alpha1.TidbCluster{
 			Spec: v1alpha1.TidbClusterSpec{
 				TiKV: &v1alpha1.TiKVSpec{},
@@ -462,19 +6113,734 @@ exec /tikv-server ${ARGS}
 		}
 		tc.Name = "start-script-test"
 		tc.Namespace = "start-script-test-ns"
-		tc.Spec.AcrossK8s = false
-		tc.Spec.ClusterDomain = ""
-		tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: false}
+		tc.Spec.TiKV.Annotations = annotations
+		return tc
+	}
 
-		if c.modifyTC != nil {
-			c.modifyTC(tc)
+	script, err := RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("in-memory-engine"))
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("EPHEMERAL"))
+	g.Expect(script).Should(gomega.ContainSubstring("--data-dir=/var/lib/tikv \\"))
+
+	_, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVInMemoryEngine: "memory"}))
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("MountPath"))
+
+	tc := newTC(map[string]string{label.AnnTiKVInMemoryEngine: "/var/lib/tikv-memory"})
+	tc.Spec.TiKV.WALVolumeName = "wal"
+	tc.Spec.TiKV.StorageVolumes = []v1alpha1.StorageVolume{{Name: "wal", MountPath: "/var/lib/wal"}}
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring(label.AnnTiKVInMemoryEngine))
+
+	script, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVInMemoryEngine: "/var/lib/tikv-memory"}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("--data-dir=/var/lib/tikv-memory \\"))
+	g.Expect(script).Should(gomega.ContainSubstring("--config=/var/lib/tikv/runtime-tikv.toml"))
+	g.Expect(script).Should(gomega.ContainSubstring("the data is EPHEMERAL"))
+
+	begin := strings.Index(script, "\nif ! awk")
+	end := strings.Index(script, "\nARGS=")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+
+	cases := []struct {
+		name   string
+		mounts string
+		config string
+		fail   bool
+		// expected is the runtime config file passed to TiKV
+		expected string
+	}{
+		{
+			name:     "memory-backed mount",
+			mounts:   "tmpfs /var/lib/tikv-memory tmpfs rw,relatime 0 0\n",
+			expected: "\n[in-memory-engine]\nenable = true\n",
+		},
+		{
+			name:     "in-memory engine is configured",
+			mounts:   "tmpfs /var/lib/tikv-memory tmpfs rw,relatime 0 0\n",
+			config:   "[in-memory-engine]\nenable = false\n",
+			expected: "[in-memory-engine]\nenable = false\n",
+		},
+		{
+			name:   "disk-backed mount",
+			mounts: "/dev/sdb /var/lib/tikv-memory ext4 rw,relatime 0 0\ntmpfs /dev/shm tmpfs rw 0 0\n",
+			fail:   true,
+		},
+		{
+			name:   "not mounted",
+			mounts: "tmpfs /var/lib/tikv-memory/data tmpfs rw 0 0\n",
+			fail:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tmp := t.TempDir()
+			mountsFile := filepath.Join(tmp, "mounts")
+			g.Expect(os.WriteFile(mountsFile, []byte(c.mounts), 0644)).Should(gomega.Succeed())
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			runtimeConfigFile := filepath.Join(tmp, "runtime-tikv.toml")
+			fragment := strings.NewReplacer(
+				"/proc/mounts", mountsFile,
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", runtimeConfigFile,
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if c.fail {
+				g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeStorageCheckFailed)))
+				g.Expect(runtimeConfigFile).ShouldNot(gomega.BeAnExistingFile())
+				return
+			}
+			g.Expect(err).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(runtimeConfigFile)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+		})
+	}
+}
+
+func TestRenderTiKVStartScriptWithPDSRVDomain(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func(annotations map[string]string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.TiKV.Annotations = annotations
+		return tc
+	}
+
+	script, err := RenderTiKVStartScript(newTC(nil))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("SRV"))
+	g.Expect(script).Should(gomega.ContainSubstring(`ARGS="--pd=start-script-test-pd:2379 \`))
+
+	for _, domain := range []string{"", "_pd.example.com", "pd.example.com."} {
+		_, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVPDSRVDomain: domain}))
+		g.Expect(err).Should(gomega.HaveOccurred(), "domain %q", domain)
+		g.Expect(err.Error()).Should(gomega.ContainSubstring("invalid PD SRV script model"), "domain %q", domain)
+	}
+
+	tc := newTC(map[string]string{label.AnnTiKVPDSRVDomain: "pd.example.com"})
+	tc.Spec.AcrossK8s = true
+	_, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.HaveOccurred())
+	g.Expect(err.Error()).Should(gomega.ContainSubstring("across k8s"))
+
+	// the fallback is the PD addresses without the option
+	tc = newTC(map[string]string{label.AnnTiKVPDSRVDomain: "pd.example.com"})
+	tc.Spec.PD = &v1alpha1.PDSpec{Replicas: 2}
+	tc.Spec.StartScriptV2FeatureFlags = []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagMultiplePDAddresses}
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring("\n    pd_srv_addr=start-script-test-pd-0.start-script-test-pd-peer.start-script-test-ns.svc:2379," +
+		"start-script-test-pd-1.start-script-test-pd-peer.start-script-test-ns.svc:2379\n"))
+
+	script, err = RenderTiKVStartScript(newTC(map[string]string{label.AnnTiKVPDSRVDomain: "pd.example.com"}))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring(`ARGS="--pd=${pd_srv_addr} \`))
+	begin := strings.Index(script, "\npd_srv_addr=")
+	end := strings.Index(script, "\nARGS=")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+
+	cases := []struct {
+		name string
+		// dig is the stub of dig
+		dig      string
+		expected string
+	}{
+		{
+			name: "records",
+			dig: `dig() {
+    [[ "$1 $2 $3" == "_pd._tcp.pd.example.com SRV +short" ]] || return 1
+    echo "10 10 2379 pd-2.example.com."
+    echo "0 5 2379 pd-1.example.com."
+    echo "0 50 2379 pd-0.example.com."
+}`,
+			expected: "pd-0.example.com:2379,pd-1.example.com:2379,pd-2.example.com:2379",
+		},
+		{
+			name:     "no record",
+			dig:      `dig() { :; }`,
+			expected: "start-script-test-pd:2379",
+		},
+		{
+			name:     "failed lookup",
+			dig:      `dig() { echo ";; connection timed out; no servers could be reached"; return 9; }`,
+			expected: "start-script-test-pd:2379",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			src := "set -euo pipefail\n" + c.dig + script[begin:end] + "\necho \"pd=${pd_srv_addr}\" >&2"
+			file, err := syntax.NewParser().Parse(strings.NewReader(src), "")
+			g.Expect(err).Should(gomega.Succeed())
+			var stderr bytes.Buffer
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, &stderr))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+			g.Expect(stderr.String()).Should(gomega.Equal("pd=" + c.expected + "\n"))
+		})
+	}
+}
+
+func TestRenderTiKVStartScriptWithReserveSpacePercent(t *testing.T) {
+	cases := []struct {
+		name    string
+		percent int32
+		// capacity is the CAPACITY env, the capacity is pre-computed from storage if it is set
+		capacity string
+		storage  string
+		config   string
+		// expected is the runtime config file passed to TiKV, it is matched as a regexp
+		expected string
+	}{
+		{
+			name:     "capacity in GB",
+			percent:  10,
+			capacity: "100GB",
+			expected: "\n\\[storage\\]\nreserve-space = \"10737418240B\"\n",
+		},
+		{
+			name:     "capacity in MB",
+			percent:  5,
+			capacity: "1000MB",
+			expected: "\n\\[storage\\]\nreserve-space = \"52428800B\"\n",
+		},
+		{
+			name:     "pre-computed capacity is rounded down",
+			percent:  3,
+			storage:  "1001",
+			expected: "\n\\[storage\\]\nreserve-space = \"30B\"\n",
+		},
+		{
+			name:     "pre-computed capacity less than 100 bytes",
+			percent:  50,
+			storage:  "99",
+			expected: "\n\\[storage\\]\nreserve-space = \"49B\"\n",
+		},
+		{
+			name:     "pre-computed capacity near the max of int64",
+			percent:  50,
+			storage:  "9223372036854775807",
+			expected: "\n\\[storage\\]\nreserve-space = \"4611686018427387903B\"\n",
+		},
+		{
+			name:     "unlimited capacity uses the size of the file system",
+			percent:  1,
+			capacity: "0",
+			expected: "\n\\[storage\\]\nreserve-space = \"[1-9][0-9]*B\"\n",
+		},
+		{
+			name:     "storage section in the config file",
+			percent:  20,
+			capacity: "10GB",
+			config:   "[storage]\napi-version = 1\n",
+			expected: "\\[storage\\]\nreserve-space = \"2147483648B\"\napi-version = 1\n",
+		},
+		{
+			name:     "reserve-space is set in the config file",
+			percent:  20,
+			capacity: "10GB",
+			config:   "[storage]\nreserve-space = \"5GB\"\n",
+			expected: "\\[storage\\]\nreserve-space = \"5GB\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tc := &v1alpha1.TidbCluster{
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{ReserveSpacePercent: c.percent},
+				},
+			}
+			tc.Name = "start-script-test"
+			tc.Namespace = "start-script-test-ns"
+			if c.storage != "" {
+				tc.Spec.TiKV.PreComputeCapacity = true
+				tc.Spec.TiKV.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(c.storage)}
+			}
+			script, err := RenderTiKVStartScript(tc)
+			g.Expect(err).Should(gomega.Succeed())
+
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			begin := strings.Index(script, "\ncapacity=")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+				"/var/lib/tikv", filepath.Join(tmp, "data"),
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(
+				interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"), "CAPACITY="+c.capacity)),
+				interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.MatchRegexp("^" + c.expected + "$"))
+		})
+	}
+
+	tc := &v1alpha1.TidbCluster{
+		Spec: v1alpha1.TidbClusterSpec{
+			TiKV: &v1alpha1.TiKVSpec{ReserveSpacePercent: 100},
+		},
+	}
+	_, err := RenderTiKVStartScript(tc)
+	gomega.NewGomegaWithT(t).Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("ReserveSpacePercent 100 must be in [0, 100)")))
+}
+
+func TestRenderTiKVStartScriptWithGCNumThreads(t *testing.T) {
+	newTC := func(threads int32) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{GCNumThreads: threads},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	cases := []struct {
+		name   string
+		config string
+		// expected is the runtime config file passed to TiKV
+		expected string
+	}{
+		{
+			name:     "no gc section in the config file",
+			config:   "[backup]\nnum-threads = 2\n",
+			expected: "[backup]\nnum-threads = 2\n\n[gc]\nnum-threads = 4\n",
+		},
+		{
+			name:     "gc section in the config file",
+			config:   "[gc]\nbatch-keys = 512\n",
+			expected: "[gc]\nnum-threads = 4\nbatch-keys = 512\n",
+		},
+		{
+			name:     "gc.num-threads is set in the config file",
+			config:   "[gc]\nnum-threads = 2\n",
+			expected: "[gc]\nnum-threads = 2\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			script, err := RenderTiKVStartScript(newTC(4))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.ContainSubstring("--config=/var/lib/tikv/runtime-tikv.toml"))
+
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			begin := strings.Index(script, "\n# num-threads is also a key")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+		})
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	// the config file is used as it is if the threads are not set
+	script, err := RenderTiKVStartScript(newTC(0))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("[gc]"))
+	g.Expect(script).Should(gomega.ContainSubstring("--config=/etc/tikv/tikv.toml"))
+
+	// the config file is copied once with the other settings applied at runtime
+	tc := newTC(4)
+	tc.Spec.TiKV.ReserveSpacePercent = 10
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.Count(script, "cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml")).Should(gomega.Equal(1))
+
+	for _, threads := range []int32{-1, 33} {
+		_, err = RenderTiKVStartScript(newTC(threads))
+		g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring(fmt.Sprintf("GCNumThreads %d must be in [1, 32]", threads))))
+	}
+}
+
+func TestRenderTiKVStartScriptWithRegionSize(t *testing.T) {
+	newTC := func(splitSize, maxSize string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
+		}
+		if splitSize != "" {
+			q := resource.MustParse(splitSize)
+			tc.Spec.TiKV.RegionSplitSize = &q
+		}
+		if maxSize != "" {
+			q := resource.MustParse(maxSize)
+			tc.Spec.TiKV.RegionMaxSize = &q
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	cases := []struct {
+		name      string
+		splitSize string
+		maxSize   string
+		config    string
+		// expected is the runtime config file passed to TiKV
+		expected string
+	}{
+		{
+			name:      "no coprocessor section in the config file",
+			splitSize: "256Mi",
+			maxSize:   "384Mi",
+			config:    "[gc]\nbatch-keys = 512\n",
+			expected:  "[gc]\nbatch-keys = 512\n\n[coprocessor]\nregion-split-size = \"256MiB\"\nregion-max-size = \"384MiB\"\n",
+		},
+		{
+			name:      "coprocessor section in the config file",
+			splitSize: "1Gi",
+			config:    "[coprocessor]\nsplit-region-on-table = false\n",
+			expected:  "[coprocessor]\nregion-split-size = \"1024MiB\"\nsplit-region-on-table = false\n",
+		},
+		{
+			name:     "only region max size",
+			maxSize:  "144Mi",
+			config:   "[coprocessor]\n",
+			expected: "[coprocessor]\nregion-max-size = \"144MiB\"\n",
+		},
+		{
+			name:      "region size is set in the config file",
+			splitSize: "256Mi",
+			maxSize:   "384Mi",
+			config:    "[coprocessor]\nregion-max-size = \"144MiB\"\n",
+			expected:  "[coprocessor]\nregion-max-size = \"144MiB\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			script, err := RenderTiKVStartScript(newTC(c.splitSize, c.maxSize))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.ContainSubstring("--config=/var/lib/tikv/runtime-tikv.toml"))
+
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			begin := strings.Index(script, "\n# the sizes are applied together")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+		})
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	// the config file is used as it is if the sizes are not set
+	script, err := RenderTiKVStartScript(newTC("", ""))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("[coprocessor]"))
+	g.Expect(script).Should(gomega.ContainSubstring("--config=/etc/tikv/tikv.toml"))
+
+	// the config file is copied once with the other settings applied at runtime
+	tc := newTC("256Mi", "")
+	tc.Spec.TiKV.GCNumThreads = 4
+	script, err = RenderTiKVStartScript(tc)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(strings.Count(script, "cp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml")).Should(gomega.Equal(1))
+
+	_, err = RenderTiKVStartScript(newTC("1Gi", "512Mi"))
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("RegionMaxSize 512 must not be less than RegionSplitSize 1024")))
+}
+
+func TestRenderTiKVStartScriptWithBlockCacheMemoryPercent(t *testing.T) {
+	newTC := func(percent int32, memoryLimit string) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{BlockCacheMemoryPercent: percent},
+			},
+		}
+		if memoryLimit != "" {
+			tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memoryLimit)}
 		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+
+	cases := []struct {
+		name        string
+		percent     int32
+		memoryLimit string
+		config      string
+		// expected is the runtime config file passed to TiKV
+		expected string
+	}{
+		{
+			name:        "no block cache section in the config file",
+			percent:     40,
+			memoryLimit: "16Gi",
+			config:      "[storage]\nreserve-space = \"1GB\"\n",
+			expected:    "[storage]\nreserve-space = \"1GB\"\n\n[storage.block-cache]\ncapacity = \"6553MiB\"\n",
+		},
+		{
+			name:        "block cache section in the config file",
+			percent:     50,
+			memoryLimit: "4Gi",
+			config:      "[storage.block-cache]\nnum-shard-bits = 6\n",
+			expected:    "[storage.block-cache]\ncapacity = \"2048MiB\"\nnum-shard-bits = 6\n",
+		},
+		{
+			name:        "memory limit in decimal units",
+			percent:     25,
+			memoryLimit: "1G",
+			config:      "",
+			expected:    "\n[storage.block-cache]\ncapacity = \"238MiB\"\n",
+		},
+		{
+			name:        "large memory limit",
+			percent:     80,
+			memoryLimit: "512Gi",
+			config:      "",
+			expected:    "\n[storage.block-cache]\ncapacity = \"419430MiB\"\n",
+		},
+		{
+			name:        "block cache capacity is set in the config file",
+			percent:     40,
+			memoryLimit: "16Gi",
+			config:      "[storage.block-cache]\ncapacity = \"1GiB\"\n",
+			expected:    "[storage.block-cache]\ncapacity = \"1GiB\"\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			tc := newTC(c.percent, c.memoryLimit)
+			script, err := RenderTiKVStartScript(tc)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.ContainSubstring("--config=/var/lib/tikv/runtime-tikv.toml"))
 
+			tmp := t.TempDir()
+			configFile := filepath.Join(tmp, "tikv.toml")
+			g.Expect(os.WriteFile(configFile, []byte(c.config), 0644)).Should(gomega.Succeed())
+			begin := strings.Index(script, "\ncp /etc/tikv/tikv.toml /var/lib/tikv/runtime-tikv.toml")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.NewReplacer(
+				"/etc/tikv/tikv.toml", configFile,
+				"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+			).Replace(script[begin:end])
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			limit := resource.MustParse(c.memoryLimit)
+			runner, err := interp.New(interp.Env(expand.ListEnviron(
+				"PATH="+os.Getenv("PATH"), fmt.Sprintf("TIKV_MEMORY_LIMIT=%d", limit.Value()))),
+				interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+
+			runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(string(runtimeConfig)).Should(gomega.Equal(c.expected))
+			g.Expect(TiKVRequiredEnvVars(tc)).Should(gomega.ContainElement("TIKV_MEMORY_LIMIT"))
+		})
+	}
+
+	g := gomega.NewGomegaWithT(t)
+
+	// TiKV sizes the block cache by itself if the percent or the memory limit is not set
+	for _, tc := range []*v1alpha1.TidbCluster{newTC(0, "16Gi"), newTC(40, "")} {
 		script, err := RenderTiKVStartScript(tc)
 		g.Expect(err).Should(gomega.Succeed())
-		if diff := cmp.Diff(c.expectScript, script); diff != "" {
-			t.Errorf("unexpected (-want, +got): %s", diff)
+		g.Expect(script).ShouldNot(gomega.ContainSubstring("block-cache"))
+		g.Expect(script).Should(gomega.ContainSubstring("--config=/etc/tikv/tikv.toml"))
+		g.Expect(TiKVRequiredEnvVars(tc)).ShouldNot(gomega.ContainElement("TIKV_MEMORY_LIMIT"))
+	}
+
+	_, err := RenderTiKVStartScript(newTC(90, "16Gi"))
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("BlockCacheMemoryPercent 90 must be in [1, 80]")))
+}
+
+func TestRenderTiKVStartScriptWithInlineConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTC := func() *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{},
+			},
 		}
-		g.Expect(validateScript(script)).Should(gomega.Succeed())
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		return tc
+	}
+	tikvConfig := "log-level = \"info\"\n\n[storage]\nreserve-space = \"1GB\"\n\n[server]\nlabels = { zone = \"$zone\" }\n"
+
+	script, err := RenderTiKVStartScriptWithInlineConfig(newTC(), tikvConfig)
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(validateScript(script)).Should(gomega.Succeed())
+	g.Expect(script).Should(gomega.ContainSubstring(`
+mkdir -p $(dirname /etc/tikv/tikv.toml)
+cat > /etc/tikv/tikv.toml <<'TIKV_CONFIG_EOF'
+log-level = "info"
+
+[storage]
+reserve-space = "1GB"
+
+[server]
+labels = { zone = "$zone" }
+TIKV_CONFIG_EOF
+`))
+	g.Expect(script).Should(gomega.ContainSubstring("--config=/etc/tikv/tikv.toml"))
+
+	// the config is written as it is, and before it is copied to the runtime config file
+	tc := newTC()
+	tc.Spec.TiKV.GCNumThreads = 4
+	script, err = RenderTiKVStartScriptWithInlineConfig(tc, tikvConfig)
+	g.Expect(err).Should(gomega.Succeed())
+	tmp := t.TempDir()
+	begin := strings.Index(script, "\nmkdir -p $(dirname /etc/tikv/tikv.toml)")
+	end := strings.Index(script, "\nARGS=")
+	g.Expect(begin).Should(gomega.BeNumerically(">", 0))
+	fragment := strings.NewReplacer(
+		"/etc/tikv/tikv.toml", filepath.Join(tmp, "etc", "tikv.toml"),
+		"/var/lib/tikv/runtime-tikv.toml", filepath.Join(tmp, "runtime-tikv.toml"),
+	).Replace(script[begin:end])
+	file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+	g.Expect(err).Should(gomega.Succeed())
+	runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(runner.Run(context.Background(), file)).Should(gomega.Succeed())
+	written, err := os.ReadFile(filepath.Join(tmp, "etc", "tikv.toml"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(written)).Should(gomega.Equal(tikvConfig))
+	runtimeConfig, err := os.ReadFile(filepath.Join(tmp, "runtime-tikv.toml"))
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(string(runtimeConfig)).Should(gomega.Equal(tikvConfig + "\n[gc]\nnum-threads = 4\n"))
+
+	// the mounted config file is used without the inline config
+	script, err = RenderTiKVStartScript(newTC())
+	g.Expect(err).Should(gomega.Succeed())
+	g.Expect(script).ShouldNot(gomega.ContainSubstring("TIKV_CONFIG_EOF"))
+
+	_, err = RenderTiKVStartScriptWithInlineConfig(newTC(), " \n")
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("the inline config of TiKV must not be empty")))
+	_, err = RenderTiKVStartScriptWithInlineConfig(newTC(), "[storage\n")
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("InlineConfig is not valid TOML")))
+	_, err = RenderTiKVStartScriptWithInlineConfig(newTC(), "a = \"\"\"\nTIKV_CONFIG_EOF\n\"\"\"\n")
+	g.Expect(err).Should(gomega.MatchError(gomega.ContainSubstring("InlineConfig must not contain the line \"TIKV_CONFIG_EOF\"")))
+}
+
+func TestRenderTiKVStartScriptWithMinFreeSpace(t *testing.T) {
+	render := func(minFreeSpace *resource.Quantity) string {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{MinFreeSpace: minFreeSpace},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		script, err := RenderTiKVStartScript(tc)
+		gomega.NewGomegaWithT(t).Expect(err).Should(gomega.Succeed())
+		return script
+	}
+	quantity := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(render(nil)).ShouldNot(gomega.ContainSubstring("free_space"))
+	g.Expect(render(quantity("0"))).ShouldNot(gomega.ContainSubstring("free_space"))
+
+	cases := []struct {
+		name         string
+		minFreeSpace string
+		fail         bool
+	}{
+		{
+			name:         "enough free space",
+			minFreeSpace: "1Ki",
+		},
+		{
+			name:         "not enough free space",
+			minFreeSpace: "1Ei",
+			fail:         true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			script := render(quantity(c.minFreeSpace))
+			begin := strings.Index(script, "\nfree_space=")
+			end := strings.Index(script, "\nARGS=")
+			fragment := strings.ReplaceAll(script[begin:end], "/var/lib/tikv", t.TempDir())
+			file, err := syntax.NewParser().Parse(strings.NewReader(fragment), "")
+			g.Expect(err).Should(gomega.Succeed())
+			runner, err := interp.New(interp.Env(expand.ListEnviron("PATH="+os.Getenv("PATH"))), interp.StdIO(nil, io.Discard, io.Discard))
+			g.Expect(err).Should(gomega.Succeed())
+			err = runner.Run(context.Background(), file)
+			if c.fail {
+				g.Expect(err).Should(gomega.Equal(interp.NewExitStatus(ExitCodeStorageCheckFailed)))
+			} else {
+				g.Expect(err).Should(gomega.Succeed())
+			}
+		})
 	}
 }
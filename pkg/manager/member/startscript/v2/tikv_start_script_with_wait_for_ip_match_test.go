@@ -14,6 +14,8 @@
 package v2
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
@@ -637,10 +639,10 @@ while true; do
         fi
     fi
 done
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -748,10 +750,10 @@ while true; do
         fi
     fi
 done
-pd_url=start-script-test-pd:2379
+pd_url=http://start-script-test-pd:2379
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url=start-script-test-discovery.start-script-test-ns:10261
-until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g'); do
+until result=$(wget -qO- -T 3 http://${discovery_url}/verify/${encoded_domain_url} 2>/dev/null | sed 's/http:\/\///g' | sed 's/https:\/\///g'); do
     echo "waiting for the verification of PD endpoints ..."
     sleep $((RANDOM % 5))
 done
@@ -976,6 +978,523 @@ if [ ! -z "${STORE_LABELS:-}" ]; then
   ARGS="${ARGS}${LABELS}"
 fi
 
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "set tikv start timeout",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = &v1alpha1.PDSpec{StartTimeout: 60}
+				tc.Spec.TiKV.StartTimeout = 120
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc
+waitThreshold=120
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "fallback to pd start timeout",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.PD = &v1alpha1.PDSpec{StartTimeout: 60}
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc
+waitThreshold=60
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "lookup dns with dig",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = append(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig)
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc
+waitThreshold=30
+nsLookupCmd="dig ${componentDomain} A ${componentDomain} AAAA +search +short"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "lookup dns with nslookup",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.StartScriptV2FeatureFlags = append(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup)
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc
+waitThreshold=30
+nsLookupCmd="nslookup $componentDomain 2>/dev/null | awk '/^Name:/ {found=1; next} found && /^Address/ {print (\$2 ~ /:$/) ? \$3 : \$2}'"
+
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( elapseTime+period ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name: "seconds threshold unit",
+			modifyTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.DnsWaitThresholdUnit = v1alpha1.DnsWaitThresholdUnitSeconds
+			},
+			expectScript: `#!/bin/sh
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+TIKV_POD_NAME=${POD_NAME:-$HOSTNAME}
+componentDomain=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc
+waitThreshold=30
+nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"
+
+waitStartTime=$(date +%s)
+elapseTime=0
+period=1
+while true; do
+    sleep ${period}
+    elapseTime=$(( $(date +%s)-waitStartTime ))
+
+    if [[ ${elapseTime} -ge ${waitThreshold} ]]; then
+        echo "waiting for cluster ready timeout" >&2
+        exit 1
+    fi
+
+    digRes=$(eval "$nsLookupCmd")
+    if [ $? -ne 0  ]; then
+        echo "domain resolve ${componentDomain} failed"
+        echo "$digRes"
+        continue
+    fi
+
+    if [ -z "${digRes}" ]
+    then
+        echo "domain resolve ${componentDomain} no record return"
+    else
+        echo "domain resolve ${componentDomain} success"
+        echo "$digRes"
+
+        # now compare resolved IPs with host IPs
+        hostnameIRes=($(hostname -I))
+        hostIps=()
+        while IFS= read -r line; do
+            hostIps+=("$line")
+        done <<< "$hostnameIRes"
+        echo "hostIps: ${hostIps[@]}"
+
+        resolvedIps=()
+        while IFS= read -r line; do
+            resolvedIps+=("$line")
+        done <<< "$digRes"
+        echo "resolvedIps: ${resolvedIps[@]}"
+
+        foundIp=false
+        for element in "${resolvedIps[@]}"
+        do
+            if [[ " ${hostIps[@]} " =~ " ${element} " ]]; then
+                foundIp=true
+                break
+            fi
+        done
+        if [ "$foundIp" = true ]; then
+            echo "Success: Resolved IP matches one of podIPs"
+            break
+        else
+            echo "Resolved IP does not match any of podIPs"
+        fi
+    fi
+done
+
+ARGS="--pd=start-script-test-pd:2379 \
+--advertise-addr=${TIKV_POD_NAME}.start-script-test-tikv-peer.start-script-test-ns.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=/etc/tikv/tikv.toml"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS="--labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
 echo "starting tikv-server ..."
 echo "/tikv-server ${ARGS}"
 exec /tikv-server ${ARGS}
@@ -1012,3 +1531,118 @@ exec /tikv-server ${ARGS}
 		g.Expect(validateScript(script)).Should(gomega.Succeed())
 	}
 }
+
+func TestRenderStartScriptWithDnsLookupCmd(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"tikv":    RenderTiKVStartScript,
+		"tiflash": RenderTiFlashStartScript,
+	}
+	cases := map[v1alpha1.StartScriptV2FeatureFlag]string{
+		v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig:      `nsLookupCmd="dig ${componentDomain} A ${componentDomain} AAAA +search +short"`,
+		v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup: `nsLookupCmd="nslookup $componentDomain 2>/dev/null | awk '/^Name:/ {found=1; next} found && /^Address/ {print (\$2 ~ /:$/) ? \$3 : \$2}'"`,
+	}
+	getentCmd := `nsLookupCmd="getent ahosts $componentDomain | sed -n 's/ *STREAM.*//p'"`
+
+	newTC := func(flags ...v1alpha1.StartScriptV2FeatureFlag) *v1alpha1.TidbCluster {
+		tc := &v1alpha1.TidbCluster{
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV:    &v1alpha1.TiKVSpec{},
+				TiFlash: &v1alpha1.TiFlashSpec{},
+			},
+		}
+		tc.Name = "start-script-test"
+		tc.Namespace = "start-script-test-ns"
+		tc.Spec.StartScriptV2FeatureFlags = append([]v1alpha1.StartScriptV2FeatureFlag{
+			v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch,
+			v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch,
+		}, flags...)
+		return tc
+	}
+
+	for component, render := range renders {
+		defaultScript, err := render(newTC())
+		g.Expect(err).Should(gomega.Succeed())
+		g.Expect(defaultScript).Should(gomega.ContainSubstring(getentCmd), "component %s", component)
+
+		for flag, expectCmd := range cases {
+			script, err := render(newTC(flag))
+			g.Expect(err).Should(gomega.Succeed())
+			// only the lookup command is changed, the wait loop is kept as it is
+			expectScript := strings.Replace(defaultScript, getentCmd, expectCmd, 1)
+			if diff := cmp.Diff(expectScript, script); diff != "" {
+				t.Errorf("unexpected script of %s with %s (-want, +got): %s", component, flag, diff)
+			}
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+		}
+	}
+}
+
+func TestRenderStartScriptWithDnsWaitResolver(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	renders := map[string]func(tc *v1alpha1.TidbCluster) (string, error){
+		"pd":      RenderPDStartScript,
+		"tikv":    RenderTiKVStartScript,
+		"tidb":    RenderTiDBStartScript,
+		"tiflash": RenderTiFlashStartScript,
+		"tso":     RenderPDTSOStartScript,
+	}
+	digCmd := `nsLookupCmd="dig @10.0.0.10 ${componentDomain} A ${componentDomain} AAAA +search +short"`
+	cases := []struct {
+		flags  []v1alpha1.StartScriptV2FeatureFlag
+		expect map[string]string
+	}{
+		{
+			// getent can not query the resolver, dig is used instead
+			flags:  []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch},
+			expect: map[string]string{"pd": digCmd, "tikv": digCmd, "tidb": digCmd, "tiflash": digCmd, "tso": digCmd},
+		},
+		{
+			flags:  []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithDig},
+			expect: map[string]string{"pd": digCmd, "tikv": digCmd, "tidb": digCmd, "tiflash": digCmd, "tso": digCmd},
+		},
+		{
+			flags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagWaitForDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagTiDBTiFlashDnsNameIpMatch, v1alpha1.StartScriptV2FeatureFlagDnsLookupWithNslookup},
+			expect: map[string]string{
+				"pd":      digCmd,
+				"tikv":    `nsLookupCmd="nslookup $componentDomain 10.0.0.10 2>/dev/null | awk`,
+				"tidb":    `nsLookupCmd="nslookup $componentDomain 10.0.0.10 2>/dev/null | awk`,
+				"tiflash": `nsLookupCmd="nslookup $componentDomain 10.0.0.10 2>/dev/null | awk`,
+				"tso":     `nsLookupCmd="nslookup $componentDomain 10.0.0.10 2>/dev/null | awk`,
+			},
+		},
+		{
+			// PD only waits for its DNS name without the ip match
+			expect: map[string]string{"pd": "digRes=$(dig @10.0.0.10 ${PD_DOMAIN} A ${PD_DOMAIN} AAAA +search +short)"},
+		},
+	}
+
+	for _, c := range cases {
+		for component, expect := range c.expect {
+			tc := newAllComponentsTidbCluster()
+			tc.Spec.StartScriptV2FeatureFlags = c.flags
+			tc.Spec.DnsWaitResolver = "10.0.0.10"
+			script, err := renders[component](tc)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(validateScript(script)).Should(gomega.Succeed())
+			g.Expect(script).Should(gomega.ContainSubstring(expect), "component %s, flags %v", component, c.flags)
+
+			// the resolver of the host is used by default
+			tc.Spec.DnsWaitResolver = ""
+			script, err = renders[component](tc)
+			g.Expect(err).Should(gomega.Succeed())
+			g.Expect(script).ShouldNot(gomega.ContainSubstring("10.0.0.10"), "component %s, flags %v", component, c.flags)
+		}
+	}
+
+	// the resolver is rendered without quoting, so it must be an IP address
+	for component, render := range renders {
+		tc := newAllComponentsTidbCluster()
+		tc.Spec.DnsWaitResolver = "10.0.0.10; rm -rf /"
+		_, err := render(tc)
+		g.Expect(errors.Is(err, ErrModelValidation)).Should(gomega.BeTrue(), "component %s", component)
+		g.Expect(err.Error()).Should(gomega.ContainSubstring("DnsWaitResolver"), "component %s", component)
+	}
+}
@@ -14,21 +14,100 @@
 package v2
 
 import (
-	"text/template"
+	"path/filepath"
+	"slices"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+)
+
+const (
+	// tiproxyConfigPath is the path of the config file mounted from the ConfigMap
+	tiproxyConfigPath = "/etc/proxy/proxy.toml"
 )
 
 // TiProxyStartScriptModel contain fields for rendering TiProxy start script
 type TiProxyStartScriptModel struct {
+	// AdvertiseAddr is only set if the TiProxyAdvertiseAddr feature flag is set,
+	// as --advertise-addr is not supported by old versions of TiProxy.
+	AdvertiseAddr string
+	ConfigPath    string
+
+	// RuntimeConfigPath is the path of the config file with the PD address got at runtime,
+	// it is only set when the cluster is deployed across k8s.
+	RuntimeConfigPath string
+
+	AcrossK8s *AcrossK8sScriptModel
+}
+
+// Validate checks the fields required by TiProxy start script
+func (m *TiProxyStartScriptModel) Validate() error {
+	return validateModel("TiProxy start",
+		validateRequired("ConfigPath", m.ConfigPath),
+		m.AcrossK8s.Validate(),
+	)
 }
 
 // RenderTiProxyStartScript renders tiproxy start script for TidbCluster
 func RenderTiProxyStartScript(tc *v1alpha1.TidbCluster) (string, error) {
 	m := &TiProxyStartScriptModel{}
-	return renderTemplateFunc(template.Must(template.New("tiproxy").Parse(componentCommonScript+`
-ARGS="--config=/etc/proxy/proxy.toml"
+	if slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagTiProxyAdvertiseAddr) {
+		m.AdvertiseAddr = TiProxyAdvertiseAddr(tc)
+	}
+
+	m.ConfigPath = tiproxyConfigPath
+	if tc.AcrossK8s() {
+		// the PD address in the config file is replaced by the one verified by discovery
+		m.AcrossK8s = newAcrossK8sScriptModel(tc)
+		m.RuntimeConfigPath = filepath.Join(constants.TiProxyVolumeMountPath, "proxy.toml")
+		m.ConfigPath = m.RuntimeConfigPath
+	}
+
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	tiproxyStartScriptTpl, err := parseStartScriptTemplate(tc, "tiproxy-start-script", tiproxyStartSubScript,
+		commonScript(tc, "/etc/proxy")+tiproxyStartScript)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplateFunc(tiproxyStartScriptTpl, m)
+}
+
+const (
+	// tiproxyStartSubScript contains optional subscripts used in start script.
+	tiproxyStartSubScript = `
+{{ define "AcrossK8sSubscript" -}}
+pd_url={{ .AcrossK8s.PDAddr }}
+encoded_domain_url=$(echo $pd_url | ` + base64EncodeSubScript + `)
+discovery_url={{ .AcrossK8s.DiscoveryAddr }}
+until result=$(` + acrossK8sVerifySubScript + acrossK8sStripPDSchemeSubScript + `); do` + acrossK8sMaxRetriesSubScript + `
+    echo "waiting for the verification of PD endpoints ..."
+    sleep $((RANDOM % {{ or .AcrossK8s.MaxBackoff 5 }}))
+done` + acrossK8sVerboseSubScript + `
+
+mkdir -p $(dirname {{ .RuntimeConfigPath }})
+sed "s/^\( *\)pd-addrs = .*/\1pd-addrs = \"${result}\"/" ` + tiproxyConfigPath + ` > {{ .RuntimeConfigPath }}
+{{- end }}
+`
+
+	// tiproxyStartScript is the template of start script.
+	tiproxyStartScript = `
+{{ if .AdvertiseAddr -}}
+TIPROXY_POD_NAME=${POD_NAME:-$HOSTNAME}
+
+{{ end -}}
+{{ if .AcrossK8s -}}
+{{ template "AcrossK8sSubscript" . }}
+
+{{ end -}}
+ARGS="--config={{ .ConfigPath }}
+{{- if .AdvertiseAddr }} \
+--advertise-addr={{ .AdvertiseAddr }}
+{{- end }}"
 echo "starting: tiproxy ${ARGS}"
 exec /bin/tiproxy ${ARGS}
-`)), m)
-}
+`
+)
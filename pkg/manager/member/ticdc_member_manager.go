@@ -448,6 +448,7 @@ func getNewTiCDCStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*ap
 			Value: tc.TiCDCTimezone(),
 		},
 	}
+	envs = append(envs, cpuLimitCoresEnv(tc, v1alpha1.TiCDCMemberType.String(), tc.Spec.TiCDC.Limits)...)
 
 	script, err := startscript.RenderTiCDCStartScript(tc)
 	if err != nil {
@@ -458,7 +459,7 @@ func getNewTiCDCStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*ap
 		Name:            v1alpha1.TiCDCMemberType.String(),
 		Image:           tc.TiCDCImage(),
 		ImagePullPolicy: baseTiCDCSpec.ImagePullPolicy(),
-		Command:         []string{"/bin/sh", "-c", script},
+		Command:         startscript.StartScriptCommand(tc, script),
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "ticdc",
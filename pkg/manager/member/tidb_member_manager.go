@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/backup/constants"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/manager"
+	memberconstants "github.com/pingcap/tidb-operator/pkg/manager/member/constants"
 	"github.com/pingcap/tidb-operator/pkg/manager/member/startscript"
 	"github.com/pingcap/tidb-operator/pkg/manager/suspender"
 	mngerutils "github.com/pingcap/tidb-operator/pkg/manager/utils"
@@ -65,12 +66,6 @@ const (
 	// When user use self-signed certificates, the root CA must be provided. We
 	// following the same convention used in Kubernetes service token.
 	tlsSecretRootCAKey = corev1.ServiceAccountRootCAKey
-	// nolint: gosec
-	// tidbAuthTokenPath is where the assets for auth tidb client stored. Such as: tidb auth token JWKS
-	tidbAuthTokenPath = "/var/lib/tidb-auth-token"
-	// nolint: gosec
-	tidbAuthTokenJWKS = "tidb_auth_token_jwks.json"
-
 	// tidb DC label Name
 	tidbDCLabel = "zone"
 
@@ -570,7 +565,7 @@ func getTiDBConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 	config := tc.Spec.TiDB.Config.DeepCopy()
 
 	if pointer.BoolPtrDerefOr(tc.Spec.TiDB.TokenBasedAuthEnabled, false) {
-		config.Set("security.auth-token-jwks", path.Join(tidbAuthTokenPath, tidbAuthTokenJWKS))
+		config.Set("security.auth-token-jwks", path.Join(memberconstants.TiDBAuthTokenPath, memberconstants.TiDBAuthTokenJWKS))
 	}
 
 	// override CA if tls enabled
@@ -618,6 +613,13 @@ func getTiDBConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 		"config-file":    string(confText),
 		"startup-script": startScript,
 	}
+	if tc.Spec.TiDB.PreStop != nil {
+		preStopScript, err := startscript.RenderTiDBPreStopScript(tc)
+		if err != nil {
+			return nil, fmt.Errorf("render prestop-script for tc %s/%s failed: %v", tc.Namespace, tc.Name, err)
+		}
+		data["prestop-script"] = preStopScript
+	}
 	name := controller.TiDBMemberName(tc.Name)
 	instanceName := tc.GetInstanceName()
 	tidbLabels := label.New().Instance(instanceName).TiDB().Labels()
@@ -759,7 +761,7 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	}
 	if pointer.BoolPtrDerefOr(tc.Spec.TiDB.TokenBasedAuthEnabled, false) {
 		volMounts = append(volMounts, corev1.VolumeMount{
-			Name: "tidb-auth-token", ReadOnly: true, MountPath: tidbAuthTokenPath,
+			Name: "tidb-auth-token", ReadOnly: true, MountPath: memberconstants.TiDBAuthTokenPath,
 		})
 	}
 	if tc.IsTLSClusterEnabled() {
@@ -773,6 +775,10 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		})
 	}
 
+	startupScriptItems := []corev1.KeyToPath{{Key: "startup-script", Path: "tidb_start_script.sh"}}
+	if tc.Spec.TiDB.PreStop != nil {
+		startupScriptItems = append(startupScriptItems, corev1.KeyToPath{Key: "prestop-script", Path: "tidb_prestop_script.sh"})
+	}
 	vols := []corev1.Volume{
 		annoVolume,
 		{Name: "config", VolumeSource: corev1.VolumeSource{
@@ -788,7 +794,7 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 				LocalObjectReference: corev1.LocalObjectReference{
 					Name: tidbConfigMap,
 				},
-				Items: []corev1.KeyToPath{{Key: "startup-script", Path: "tidb_start_script.sh"}},
+				Items: startupScriptItems,
 			}},
 		},
 	}
@@ -973,11 +979,12 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			Value: headlessSvcName,
 		},
 	}
+	envs = append(envs, cpuLimitCoresEnv(tc, v1alpha1.TiDBMemberType.String(), tc.Spec.TiDB.Limits)...)
 
 	c := corev1.Container{
 		Name:            v1alpha1.TiDBMemberType.String(),
 		Image:           tc.TiDBImage(),
-		Command:         []string{"/bin/sh", "/usr/local/bin/tidb_start_script.sh"},
+		Command:         startscript.StartScriptFileCommand(tc, "/usr/local/bin/tidb_start_script.sh"),
 		ImagePullPolicy: baseTiDBSpec.ImagePullPolicy(),
 		Ports: []corev1.ContainerPort{
 			{
@@ -1003,6 +1010,19 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	if tc.Spec.TiDB.Lifecycle != nil {
 		c.Lifecycle = tc.Spec.TiDB.Lifecycle
 	}
+	// the preStop handler in Lifecycle takes precedence over the drain script
+	if tc.Spec.TiDB.PreStop != nil && (c.Lifecycle == nil || c.Lifecycle.PreStop == nil) {
+		if c.Lifecycle == nil {
+			c.Lifecycle = &corev1.Lifecycle{}
+		} else {
+			c.Lifecycle = c.Lifecycle.DeepCopy()
+		}
+		c.Lifecycle.PreStop = &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{
+				Command: startscript.StartScriptFileCommand(tc, "/usr/local/bin/tidb_prestop_script.sh"),
+			},
+		}
+	}
 	if tc.Spec.TiDB.ReadinessProbe != nil {
 		if tc.Spec.TiDB.ReadinessProbe.InitialDelaySeconds != nil {
 			c.ReadinessProbe.InitialDelaySeconds = *tc.Spec.TiDB.ReadinessProbe.InitialDelaySeconds
@@ -1265,6 +1265,124 @@ func TestGetNewTiDBSetForTidbCluster(t *testing.T) {
 				g.Expect(sts.Spec.Template.Spec.Containers[1].ReadinessProbe.PeriodSeconds).To(Equal(int32(2)))
 			},
 		},
+		{
+			name: "TiDB preStop script",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{
+						PreStop: &v1alpha1.TiDBPreStopSpec{},
+					},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Volumes).To(ContainElement(
+					corev1.Volume{Name: "startup-script", VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: controller.TiDBMemberName("tc")},
+							Items: []corev1.KeyToPath{
+								{Key: "startup-script", Path: "tidb_start_script.sh"},
+								{Key: "prestop-script", Path: "tidb_prestop_script.sh"},
+							},
+						},
+					}},
+				))
+				g.Expect(sts.Spec.Template.Spec.Containers[1].Lifecycle).To(Equal(&corev1.Lifecycle{
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "/usr/local/bin/tidb_prestop_script.sh"}},
+					},
+				}))
+			},
+		},
+		{
+			name: "TiDB preStop script with custom shell",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{
+						PreStop: &v1alpha1.TiDBPreStopSpec{},
+					},
+					TiKV:               &v1alpha1.TiKVSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+					StartScriptV2Shell: "/bin/bash",
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Containers[1].Lifecycle.PreStop.Exec.Command).To(Equal([]string{"/bin/bash", "/usr/local/bin/tidb_prestop_script.sh"}))
+			},
+		},
+		{
+			name: "TiDB preStop script with lifecycle",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{
+						PreStop: &v1alpha1.TiDBPreStopSpec{},
+						Lifecycle: &corev1.Lifecycle{
+							PostStart: &corev1.LifecycleHandler{
+								Exec: &corev1.ExecAction{Command: []string{"echo", "started"}},
+							},
+						},
+					},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Containers[1].Lifecycle).To(Equal(&corev1.Lifecycle{
+					PostStart: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"echo", "started"}},
+					},
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "/usr/local/bin/tidb_prestop_script.sh"}},
+					},
+				}))
+			},
+		},
+		{
+			name: "TiDB preStop script is overridden by lifecycle",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD: &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{
+						PreStop: &v1alpha1.TiDBPreStopSpec{},
+						Lifecycle: &corev1.Lifecycle{
+							PreStop: &corev1.LifecycleHandler{
+								Exec: &corev1.ExecAction{Command: []string{"sleep", "10"}},
+							},
+						},
+					},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Containers[1].Lifecycle).To(Equal(&corev1.Lifecycle{
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"sleep", "10"}},
+					},
+				}))
+			},
+		},
 		// TODO add more tests
 	}
 
@@ -319,6 +319,7 @@ func getNewHeadlessService(tc *v1alpha1.TidbCluster) *corev1.Service {
 	instanceName := tc.GetInstanceName()
 	svcName := controller.TiFlashPeerMemberName(tcName)
 	svcLabel := label.New().Instance(instanceName).TiFlash().Labels()
+	ports := tc.TiFlashPorts()
 
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -332,27 +333,27 @@ func getNewHeadlessService(tc *v1alpha1.TidbCluster) *corev1.Service {
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "tiflash",
-					Port:       v1alpha1.DefaultTiFlashFlashPort,
-					TargetPort: intstr.FromInt(int(v1alpha1.DefaultTiFlashFlashPort)),
+					Port:       ports.Flash,
+					TargetPort: intstr.FromInt(int(ports.Flash)),
 					Protocol:   corev1.ProtocolTCP,
 				},
 				{
 					Name:       "proxy",
-					Port:       v1alpha1.DefaultTiFlashProxyPort,
-					TargetPort: intstr.FromInt(int(v1alpha1.DefaultTiFlashProxyPort)),
+					Port:       ports.Proxy,
+					TargetPort: intstr.FromInt(int(ports.Proxy)),
 					Protocol:   corev1.ProtocolTCP,
 				},
 				{
 					Name:       "metrics",
-					Port:       v1alpha1.DefaultTiFlashMetricsPort,
-					TargetPort: intstr.FromInt(int(v1alpha1.DefaultTiFlashMetricsPort)),
+					Port:       ports.Metrics,
+					TargetPort: intstr.FromInt(int(ports.Metrics)),
 					Protocol:   corev1.ProtocolTCP,
 				},
 
 				{
 					Name:       "proxy-metrics",
-					Port:       v1alpha1.DefaultTiFlashProxyStatusPort,
-					TargetPort: intstr.FromInt(int(v1alpha1.DefaultTiFlashProxyStatusPort)),
+					Port:       ports.ProxyStatus,
+					TargetPort: intstr.FromInt(int(ports.ProxyStatus)),
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
@@ -504,8 +505,9 @@ func getNewStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*apps.St
 	stsLabels := labelTiFlash(tc)
 	setName := controller.TiFlashMemberName(tcName)
 	podLabels := util.CombineStringMap(stsLabels, baseTiFlashSpec.Labels())
-	podAnnotations := util.CombineStringMap(baseTiFlashSpec.Annotations(), controller.AnnProm(v1alpha1.DefaultTiFlashMetricsPort, "/metrics"))
-	podAnnotations = util.CombineStringMap(controller.AnnAdditionalProm("tiflash.proxy", v1alpha1.DefaultTiFlashProxyStatusPort), podAnnotations)
+	ports := tc.TiFlashPorts()
+	podAnnotations := util.CombineStringMap(baseTiFlashSpec.Annotations(), controller.AnnProm(ports.Metrics, "/metrics"))
+	podAnnotations = util.CombineStringMap(controller.AnnAdditionalProm("tiflash.proxy", ports.ProxyStatus), podAnnotations)
 	stsAnnotations := getStsAnnotations(tc.Annotations, label.TiFlashLabelVal)
 	capacity := controller.TiKVCapacity(tc.Spec.TiFlash.Limits)
 	headlessSvcName := controller.TiFlashPeerMemberName(tcName)
@@ -551,39 +553,39 @@ func getNewStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*apps.St
 		Name:            v1alpha1.TiFlashMemberType.String(),
 		Image:           tc.TiFlashImage(),
 		ImagePullPolicy: baseTiFlashSpec.ImagePullPolicy(),
-		Command:         []string{"/bin/sh", "-c", startScript},
+		Command:         startscript.StartScriptCommand(tc, startScript),
 		SecurityContext: &corev1.SecurityContext{
 			Privileged: tc.TiFlashContainerPrivilege(),
 		},
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "tiflash",
-				ContainerPort: v1alpha1.DefaultTiFlashFlashPort,
+				ContainerPort: ports.Flash,
 				Protocol:      corev1.ProtocolTCP,
 			},
 			{
 				Name:          "proxy",
-				ContainerPort: v1alpha1.DefaultTiFlashProxyPort,
+				ContainerPort: ports.Proxy,
 				Protocol:      corev1.ProtocolTCP,
 			},
 			{
 				Name:          "tcp",
-				ContainerPort: v1alpha1.DefaultTiFlashTcpPort,
+				ContainerPort: ports.Tcp,
 				Protocol:      corev1.ProtocolTCP,
 			},
 			{
 				Name:          "http",
-				ContainerPort: v1alpha1.DefaultTiFlashHttpPort,
+				ContainerPort: ports.Http,
 				Protocol:      corev1.ProtocolTCP,
 			},
 			{
 				Name:          "internal",
-				ContainerPort: v1alpha1.DefaultTiFlashInternalPort,
+				ContainerPort: ports.Internal,
 				Protocol:      corev1.ProtocolTCP,
 			},
 			{
 				Name:          "metrics",
-				ContainerPort: v1alpha1.DefaultTiFlashMetricsPort,
+				ContainerPort: ports.Metrics,
 				Protocol:      corev1.ProtocolTCP,
 			},
 		},
@@ -1728,6 +1728,48 @@ func TestGetNewTiFlashSetForTidbCluster(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "tiflash base port offset",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiDB: &v1alpha1.TiDBSpec{},
+					TiFlash: &v1alpha1.TiFlashSpec{
+						BasePortOffset: 10000,
+						StorageClaims: []v1alpha1.StorageClaim{
+							{
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{
+										corev1.ResourceStorage: resource.MustParse("100Gi"),
+									},
+								},
+							},
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				ports := map[string]int32{}
+				for _, port := range sts.Spec.Template.Spec.Containers[0].Ports {
+					ports[port.Name] = port.ContainerPort
+				}
+				g.Expect(ports).To(Equal(map[string]int32{
+					"tiflash":  13930,
+					"proxy":    30170,
+					"tcp":      19000,
+					"http":     18123,
+					"internal": 19009,
+					"metrics":  18234,
+				}))
+				g.Expect(sts.Spec.Template.Annotations).To(HaveKeyWithValue("prometheus.io/port", "18234"))
+			},
+		},
 		// TODO add more tests
 	}
 
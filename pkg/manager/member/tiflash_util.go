@@ -140,6 +140,7 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 	if tc.Spec.PreferIPv6 {
 		listenHost = listenHostForIPv6
 	}
+	ports := tc.TiFlashPorts()
 	version := tc.TiFlashVersion()
 
 	// common
@@ -165,8 +166,8 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 
 		// port
 		if ok, err := tiflashEqualOrGreaterThanV710.Check(version); err == nil && !ok || tc.KeepTiFlash710Ports() {
-			common.SetIfNil("tcp_port", int64(v1alpha1.DefaultTiFlashTcpPort))
-			common.SetIfNil("http_port", int64(v1alpha1.DefaultTiFlashHttpPort))
+			common.SetIfNil("tcp_port", int64(ports.Tcp))
+			common.SetIfNil("http_port", int64(ports.Http))
 		}
 
 		// flash
@@ -186,11 +187,11 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 			}
 		}
 		common.SetIfNil("flash.tidb_status_addr", tidbStatusAddr)
-		common.SetIfNil("flash.service_addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashFlashPort))
+		common.SetIfNil("flash.service_addr", fmt.Sprintf("%s:%d", listenHost, ports.Flash))
 		common.SetIfNil("flash.flash_cluster.log", defaultClusterLog)
-		common.SetIfNil("flash.proxy.addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashProxyPort))
+		common.SetIfNil("flash.proxy.addr", fmt.Sprintf("%s:%d", listenHost, ports.Proxy))
 		common.SetIfNil("flash.proxy.advertise-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(name),
-			controller.TiFlashPeerMemberName(name), ns, controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashProxyPort))
+			controller.TiFlashPeerMemberName(name), ns, controller.FormatClusterDomain(clusterDomain), ports.Proxy))
 		common.SetIfNil("flash.proxy.data-dir", "/data0/proxy")
 		common.SetIfNil("flash.proxy.config", "/data0/proxy.toml")
 
@@ -210,7 +211,9 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 
 		if listenHost == listenHostForIPv6 {
 			common.SetIfNil("listen_host", "::") // listen host must be "::" not "[::]"
-			common.SetIfNil("status.metrics_port", int64(v1alpha1.DefaultTiFlashMetricsPort))
+		}
+		if listenHost == listenHostForIPv6 || ports.Offset != 0 {
+			common.SetIfNil("status.metrics_port", int64(ports.Metrics))
 		}
 	}
 
@@ -218,10 +221,10 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 	{
 		proxy.SetIfNil("log-level", "info")
 		proxy.SetIfNil("server.engine-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(name), controller.TiFlashPeerMemberName(name), ns,
-			controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashFlashPort))
-		proxy.SetIfNil("server.status-addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashProxyStatusPort))
+			controller.FormatClusterDomain(clusterDomain), ports.Flash))
+		proxy.SetIfNil("server.status-addr", fmt.Sprintf("%s:%d", listenHost, ports.ProxyStatus))
 		proxy.SetIfNil("server.advertise-status-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(name), controller.TiFlashPeerMemberName(name), ns,
-			controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashProxyStatusPort))
+			controller.FormatClusterDomain(clusterDomain), ports.ProxyStatus))
 	}
 
 	// Note the config of tiflash use "_" by convention, others(proxy) use "-".
@@ -230,8 +233,8 @@ func getTiFlashConfigV2(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper
 		common.Set("security.cert_path", path.Join(tiflashCertPath, corev1.TLSCertKey))
 		common.Set("security.key_path", path.Join(tiflashCertPath, corev1.TLSPrivateKeyKey))
 		if ok, err := tiflashEqualOrGreaterThanV710.Check(version); err == nil && !ok || tc.KeepTiFlash710Ports() {
-			common.SetIfNil("tcp_port_secure", int64(v1alpha1.DefaultTiFlashTcpPort))
-			common.SetIfNil("https_port", int64(v1alpha1.DefaultTiFlashHttpPort))
+			common.SetIfNil("tcp_port_secure", int64(ports.Tcp))
+			common.SetIfNil("https_port", int64(ports.Http))
 		}
 		common.Del("http_port")
 		common.Del("tcp_port")
@@ -278,7 +281,7 @@ func getTiFlashConfig(tc *v1alpha1.TidbCluster) *v1alpha1.TiFlashConfigWraper {
 		listenHost = listenHostForIPv6
 	}
 
-	setTiFlashConfigDefault(config, ref, tc.Name, tc.Namespace, tc.Spec.ClusterDomain, listenHost, noLocalPD, noLocalTiDB, acrossK8s)
+	setTiFlashConfigDefault(config, ref, tc.Name, tc.Namespace, tc.Spec.ClusterDomain, listenHost, tc.TiFlashPorts(), noLocalPD, noLocalTiDB, acrossK8s)
 
 	// Note the config of tiflash use "_" by convention, others(proxy) use "-".
 	if tc.IsTLSClusterEnabled() {
@@ -318,28 +321,28 @@ func setTiFlashLogConfigDefault(config *v1alpha1.TiFlashConfigWraper) {
 
 // setTiFlashConfigDefault sets default configs for TiFlash
 func setTiFlashConfigDefault(config *v1alpha1.TiFlashConfigWraper, ref *v1alpha1.TidbClusterRef,
-	clusterName, ns, clusterDomain, listenHost string, noLocalPD bool, noLocalTiDB bool, acrossK8s bool) {
+	clusterName, ns, clusterDomain, listenHost string, ports *v1alpha1.TiFlashPorts, noLocalPD bool, noLocalTiDB bool, acrossK8s bool) {
 	if config.Common == nil {
 		config.Common = v1alpha1.NewTiFlashCommonConfig()
 	}
-	setTiFlashCommonConfigDefault(config.Common, ref, clusterName, ns, clusterDomain, listenHost, noLocalPD, noLocalTiDB, acrossK8s)
+	setTiFlashCommonConfigDefault(config.Common, ref, clusterName, ns, clusterDomain, listenHost, ports, noLocalPD, noLocalTiDB, acrossK8s)
 
 	if config.Proxy == nil {
 		config.Proxy = v1alpha1.NewTiFlashProxyConfig()
 	}
-	setTiFlashProxyConfigDefault(config.Proxy, clusterName, ns, clusterDomain, listenHost)
+	setTiFlashProxyConfigDefault(config.Proxy, clusterName, ns, clusterDomain, listenHost, ports)
 }
 
-func setTiFlashProxyConfigDefault(config *v1alpha1.TiFlashProxyConfigWraper, clusterName, ns, clusterDomain, listenHost string) {
+func setTiFlashProxyConfigDefault(config *v1alpha1.TiFlashProxyConfigWraper, clusterName, ns, clusterDomain, listenHost string, ports *v1alpha1.TiFlashPorts) {
 	config.SetIfNil("log-level", "info")
 	config.SetIfNil("server.engine-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(clusterName), controller.TiFlashPeerMemberName(clusterName), ns,
-		controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashFlashPort))
-	config.SetIfNil("server.status-addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashProxyStatusPort))
+		controller.FormatClusterDomain(clusterDomain), ports.Flash))
+	config.SetIfNil("server.status-addr", fmt.Sprintf("%s:%d", listenHost, ports.ProxyStatus))
 	config.SetIfNil("server.advertise-status-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(clusterName), controller.TiFlashPeerMemberName(clusterName), ns,
-		controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashProxyStatusPort))
+		controller.FormatClusterDomain(clusterDomain), ports.ProxyStatus))
 }
 
-func setTiFlashCommonConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, ref *v1alpha1.TidbClusterRef, clusterName, ns, clusterDomain, listenHost string, noLocalPD bool, noLocalTiDB bool, acrossK8s bool) {
+func setTiFlashCommonConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, ref *v1alpha1.TidbClusterRef, clusterName, ns, clusterDomain, listenHost string, ports *v1alpha1.TiFlashPorts, noLocalPD bool, noLocalTiDB bool, acrossK8s bool) {
 	config.SetIfNil("tmp_path", "/data0/tmp")
 	config.SetIfNil("display_name", "TiFlash")
 	config.SetIfNil("default_profile", "default")
@@ -347,12 +350,12 @@ func setTiFlashCommonConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, r
 	config.SetIfNil("path_realtime_mode", false)
 	config.SetIfNil("mark_cache_size", int64(5368709120))
 	config.SetIfNil("minmax_index_cache_size", int64(5368709120))
-	config.SetIfNil("tcp_port", int64(v1alpha1.DefaultTiFlashTcpPort))
-	config.SetIfNil("tcp_port_secure", int64(v1alpha1.DefaultTiFlashTcpPort))
-	config.SetIfNil("https_port", int64(v1alpha1.DefaultTiFlashHttpPort))
-	config.SetIfNil("http_port", int64(v1alpha1.DefaultTiFlashHttpPort))
-	config.SetIfNil("interserver_http_port", int64(v1alpha1.DefaultTiFlashInternalPort))
-	setTiFlashFlashConfigDefault(config, ref, clusterName, ns, clusterDomain, listenHost, noLocalTiDB, acrossK8s)
+	config.SetIfNil("tcp_port", int64(ports.Tcp))
+	config.SetIfNil("tcp_port_secure", int64(ports.Tcp))
+	config.SetIfNil("https_port", int64(ports.Http))
+	config.SetIfNil("http_port", int64(ports.Http))
+	config.SetIfNil("interserver_http_port", int64(ports.Internal))
+	setTiFlashFlashConfigDefault(config, ref, clusterName, ns, clusterDomain, listenHost, ports, noLocalTiDB, acrossK8s)
 	setTiFlashLoggerConfigDefault(config)
 	setTiFlashApplicationConfigDefault(config)
 
@@ -363,7 +366,7 @@ func setTiFlashCommonConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, r
 	} else {
 		config.SetIfNil("listen_host", listenHost)
 	}
-	config.SetIfNil("status.metrics_port", int64(v1alpha1.DefaultTiFlashMetricsPort))
+	config.SetIfNil("status.metrics_port", int64(ports.Metrics))
 
 	config.SetIfNil("quotas.default.interval.duration", int64(3600))
 	config.SetIfNil("quotas.default.interval.queries", int64(0))
@@ -387,7 +390,7 @@ func setTiFlashCommonConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, r
 	config.SetIfNil("profiles.default.use_uncompressed_cache", int64(0))
 }
 
-func setTiFlashFlashConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, ref *v1alpha1.TidbClusterRef, clusterName, ns, clusterDomain, listenHost string, noLocalTiDB, acrossK8s bool) {
+func setTiFlashFlashConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, ref *v1alpha1.TidbClusterRef, clusterName, ns, clusterDomain, listenHost string, ports *v1alpha1.TiFlashPorts, noLocalTiDB, acrossK8s bool) {
 	tidbStatusAddr := fmt.Sprintf("%s.%s.svc:%d", controller.TiDBMemberName(clusterName), ns, v1alpha1.DefaultTiDBStatusPort)
 	if noLocalTiDB {
 		// TODO: support first cluster without TiDB when deploy cluster across mutli Kubernete clusters
@@ -405,7 +408,7 @@ func setTiFlashFlashConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, re
 	}
 
 	config.SetIfNil("flash.tidb_status_addr", tidbStatusAddr)
-	config.SetIfNil("flash.service_addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashFlashPort))
+	config.SetIfNil("flash.service_addr", fmt.Sprintf("%s:%d", listenHost, ports.Flash))
 	config.SetIfNil("flash.overlap_threshold", 0.6)
 	config.SetIfNil("flash.compact_log_min_period", int64(200))
 
@@ -417,9 +420,9 @@ func setTiFlashFlashConfigDefault(config *v1alpha1.TiFlashCommonConfigWraper, re
 	config.SetIfNil("flash.flash_cluster.master_ttl", int64(60))
 
 	// set proxy
-	config.SetIfNil("flash.proxy.addr", fmt.Sprintf("%s:%d", listenHost, v1alpha1.DefaultTiFlashProxyPort))
+	config.SetIfNil("flash.proxy.addr", fmt.Sprintf("%s:%d", listenHost, ports.Proxy))
 	config.SetIfNil("flash.proxy.advertise-addr", fmt.Sprintf("%s-POD_NUM.%s.%s.svc%s:%d", controller.TiFlashMemberName(clusterName),
-		controller.TiFlashPeerMemberName(clusterName), ns, controller.FormatClusterDomain(clusterDomain), v1alpha1.DefaultTiFlashProxyPort))
+		controller.TiFlashPeerMemberName(clusterName), ns, controller.FormatClusterDomain(clusterDomain), ports.Proxy))
 	config.SetIfNil("flash.proxy.data-dir", "/data0/proxy")
 	config.SetIfNil("flash.proxy.config", "/data0/proxy.toml")
 }
@@ -506,7 +506,7 @@ func TestSetTiFlashConfigDefault(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			// g := NewGomegaWithT(t)
-			setTiFlashConfigDefault(test.config, nil, "test", "test", "", "0.0.0.0", false, false, false)
+			setTiFlashConfigDefault(test.config, nil, "test", "test", "", "0.0.0.0", (&v1alpha1.TidbCluster{}).TiFlashPorts(), false, false, false)
 			// g.Expect(test.config).To(Equal(test.expect))
 			if diff := cmp.Diff(*test.expect, *test.config); diff != "" {
 				t.Fatalf("unexpected configuration (-want, +got): %s", diff)
@@ -1885,6 +1885,29 @@ func TestTestGetTiFlashConfig(t *testing.T) {
 
 }
 
+func TestGetTiFlashConfigWithBasePortOffset(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, version := range []string{"v5.3.0", "v7.0.0"} {
+		tc := &v1alpha1.TidbCluster{}
+		tc.Name = "test"
+		tc.Namespace = "default"
+		tc.Spec.Version = version
+		tc.Spec.TiFlash = &v1alpha1.TiFlashSpec{BasePortOffset: 10000}
+		tc.Spec.TiFlash.BaseImage = "pingcap/tiflash"
+
+		cfg := GetTiFlashConfig(tc)
+		g.Expect(cfg.Common.Get("tcp_port").MustInt()).Should(Equal(int64(19000)), version)
+		g.Expect(cfg.Common.Get("http_port").MustInt()).Should(Equal(int64(18123)), version)
+		g.Expect(cfg.Common.Get("status.metrics_port").MustInt()).Should(Equal(int64(18234)), version)
+		g.Expect(cfg.Common.Get("flash.service_addr").MustString()).Should(Equal("0.0.0.0:13930"), version)
+		g.Expect(cfg.Common.Get("flash.proxy.addr").MustString()).Should(Equal("0.0.0.0:30170"), version)
+		g.Expect(cfg.Proxy.Get("server.engine-addr").MustString()).Should(HaveSuffix(":13930"), version)
+		g.Expect(cfg.Proxy.Get("server.status-addr").MustString()).Should(Equal("0.0.0.0:30292"), version)
+		g.Expect(cfg.Proxy.Get("server.advertise-status-addr").MustString()).Should(HaveSuffix(":30292"), version)
+	}
+}
+
 func mustFromOldConfig(old *v1alpha1.TiFlashConfig) *v1alpha1.TiFlashConfigWraper {
 	config := v1alpha1.NewTiFlashConfig()
 
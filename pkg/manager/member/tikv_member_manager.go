@@ -18,6 +18,7 @@ import (
 	"path"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/startscript"
 	"github.com/pingcap/tidb-operator/pkg/manager/suspender"
 	mngerutils "github.com/pingcap/tidb-operator/pkg/manager/utils"
 	"github.com/pingcap/tidb-operator/pkg/manager/volumes"
@@ -37,6 +39,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -47,7 +50,7 @@ import (
 
 const (
 	// tikvClusterCertPath is where the cert for inter-cluster communication stored (if any)
-	tikvClusterCertPath = "/var/lib/tikv-tls"
+	tikvClusterCertPath = constants.TiKVCertPath
 
 	// find a better way to manage store only managed by tikv in Operator
 	tikvStoreLimitPattern = `%s-tikv-\d+\.%s-tikv-peer\.%s\.svc%s\:\d+`
@@ -366,6 +369,13 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	}
 
 	annoMount, annoVolume := annotationsMountVolume()
+	if len(tc.Spec.TiKV.PodLabelsAsStoreLabels) > 0 ||
+		slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagTopologyStoreLabels) {
+		annoVolume.DownwardAPI.Items = append(annoVolume.DownwardAPI.Items, corev1.DownwardAPIVolumeFile{
+			Path:     constants.PodLabelsFileName,
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+		})
+	}
 	dataVolumeName := string(v1alpha1.GetStorageVolumeName("", v1alpha1.TiKVMemberType))
 	tikvDataVol := corev1.VolumeMount{
 		Name:      dataVolumeName,
@@ -388,6 +398,21 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		}
 	}
 
+	if ec := tc.Spec.TiKV.EncryptionConfig; ec != nil {
+		volMounts = append(volMounts, corev1.VolumeMount{
+			Name: "tikv-encryption-master-key", ReadOnly: true, MountPath: constants.TiKVEncryptionMasterKeyMountPath,
+		})
+		if ec.PreviousMasterKeySecretName != "" {
+			volMounts = append(volMounts, corev1.VolumeMount{
+				Name: "tikv-encryption-previous-master-key", ReadOnly: true, MountPath: constants.TiKVEncryptionPreviousMasterKeyMountPath,
+			})
+		}
+	}
+
+	startupScriptItems := []corev1.KeyToPath{{Key: "startup-script", Path: "tikv_start_script.sh"}}
+	if tc.Spec.TiKV.PreStop != nil {
+		startupScriptItems = append(startupScriptItems, corev1.KeyToPath{Key: "prestop-script", Path: "tikv_prestop_script.sh"})
+	}
 	vols := []corev1.Volume{
 		annoVolume,
 		{Name: "config", VolumeSource: corev1.VolumeSource{
@@ -403,7 +428,7 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 				LocalObjectReference: corev1.LocalObjectReference{
 					Name: tikvConfigMap,
 				},
-				Items: []corev1.KeyToPath{{Key: "startup-script", Path: "tikv_start_script.sh"}},
+				Items: startupScriptItems,
 			}},
 		},
 	}
@@ -425,6 +450,24 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			})
 		}
 	}
+	if ec := tc.Spec.TiKV.EncryptionConfig; ec != nil {
+		vols = append(vols, corev1.Volume{
+			Name: "tikv-encryption-master-key", VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: ec.MasterKeySecretName,
+				},
+			},
+		})
+		if ec.PreviousMasterKeySecretName != "" {
+			vols = append(vols, corev1.Volume{
+				Name: "tikv-encryption-previous-master-key", VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: ec.PreviousMasterKeySecretName,
+					},
+				},
+			})
+		}
+	}
 	// handle StorageVolumes and AdditionalVolumeMounts in ComponentSpec
 	storageVolMounts, additionalPVCs := util.BuildStorageVolumeAndVolumeMount(tc.Spec.TiKV.StorageVolumes, tc.Spec.TiKV.StorageClassName, v1alpha1.TiKVMemberType)
 	volMounts = append(volMounts, storageVolMounts...)
@@ -612,11 +655,35 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			Value: tc.Spec.Timezone,
 		},
 	}
+	if tc.TiKVCPULimitUsed() {
+		env = append(env, corev1.EnvVar{
+			Name: constants.TiKVCPULimitEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: v1alpha1.TiKVMemberType.String(),
+					Resource:      "limits.cpu",
+					Divisor:       resource.MustParse("1m"),
+				},
+			},
+		})
+	}
+	if tc.Spec.TiKV.BlockCacheSizedByMemoryLimit() {
+		env = append(env, corev1.EnvVar{
+			Name: constants.TiKVMemoryLimitEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: v1alpha1.TiKVMemberType.String(),
+					Resource:      "limits.memory",
+					Divisor:       resource.MustParse("1"),
+				},
+			},
+		})
+	}
 	tikvContainer := corev1.Container{
 		Name:            v1alpha1.TiKVMemberType.String(),
 		Image:           tc.TiKVImage(),
 		ImagePullPolicy: baseTiKVSpec.ImagePullPolicy(),
-		Command:         []string{"/bin/sh", "/usr/local/bin/tikv_start_script.sh"},
+		Command:         startscript.StartScriptFileCommand(tc, "/usr/local/bin/tikv_start_script.sh"),
 		SecurityContext: &corev1.SecurityContext{
 			Privileged: tc.TiKVContainerPrivilege(),
 		},
@@ -631,6 +698,16 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		Resources:    controller.ContainerResource(tc.Spec.TiKV.ResourceRequirements),
 	}
 
+	if tc.Spec.TiKV.PreStop != nil {
+		tikvContainer.Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{
+					Command: startscript.StartScriptFileCommand(tc, "/usr/local/bin/tikv_prestop_script.sh"),
+				},
+			},
+		}
+	}
+
 	if tc.Spec.TiKV.ReadinessProbe != nil {
 		tikvContainer.ReadinessProbe = &corev1.Probe{
 			ProbeHandler:        buildTiKVReadinessProbHandler(tc),
@@ -670,6 +747,9 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	}
 	tikvContainer.Env = util.AppendEnv(env, baseTiKVSpec.Env())
 	tikvContainer.EnvFrom = baseTiKVSpec.EnvFrom()
+	if err := checkRequiredEnvVars(tikvContainer.Env, tikvContainer.EnvFrom, startscript.TiKVRequiredEnvVars(tc)); err != nil {
+		return nil, fmt.Errorf("the start script of TiKV of [%s/%s] can not run, error: %v", ns, tcName, err)
+	}
 	containers = append(containers, tikvContainer)
 
 	podSpec.Volumes = append(vols, baseTiKVSpec.AdditionalVolumes()...)
@@ -14,6 +14,7 @@
 package member
 
 import (
+	stderrs "errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/apis/util/toml"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	startscriptv2 "github.com/pingcap/tidb-operator/pkg/manager/member/startscript/v2"
 	"github.com/pingcap/tidb-operator/pkg/manager/suspender"
 	"github.com/pingcap/tidb-operator/pkg/manager/volumes"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
@@ -1756,6 +1758,26 @@ func TestGetNewTiKVSetForTidbCluster(t *testing.T) {
 			},
 			testSts: testHostNetwork(t, false, ""),
 		},
+		{
+			name: "tikv start script is run by the shell of start script v2",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV:               &v1alpha1.TiKVSpec{},
+					PD:                 &v1alpha1.PDSpec{},
+					TiDB:               &v1alpha1.TiDBSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+					StartScriptV2Shell: "/bin/bash",
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Containers[0].Command).To(Equal([]string{"/bin/bash", "/usr/local/bin/tikv_start_script.sh"}))
+			},
+		},
 		{
 			name: "tikv network is host",
 			tc: v1alpha1.TidbCluster{
@@ -1891,6 +1913,211 @@ func TestGetNewTiKVSetForTidbCluster(t *testing.T) {
 					Name:  "CAPACITY",
 					Value: "100GB",
 				}), "Expected the CAPACITY of tikv is properly set")
+				// the limits are not passed to the start script without the feature flags
+				g.Expect(tikvContainer.Env).To(Equal([]corev1.EnvVar{
+					{
+						Name: "NAMESPACE",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath: "metadata.namespace",
+							},
+						},
+					},
+					{
+						Name:  "CLUSTER_NAME",
+						Value: "tc",
+					},
+					{
+						Name:  "HEADLESS_SERVICE_NAME",
+						Value: "tc-tikv-peer",
+					},
+					{
+						Name:  "CAPACITY",
+						Value: "100GB",
+					},
+					{
+						Name: "TZ",
+					},
+				}), "Expected the env of tikv is the same as before")
+				for _, env := range tikvContainer.Env {
+					g.Expect(env.Name).NotTo(Equal("TIKV_MEMORY_LIMIT"), "Expected the memory limit is only injected with blockCacheMemoryPercent")
+				}
+			},
+		},
+		{
+			name: "tikv with cpu quota",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("100Gi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("1"),
+							},
+						},
+					},
+					PD:                        &v1alpha1.PDSpec{},
+					TiDB:                      &v1alpha1.TiDBSpec{},
+					StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{v1alpha1.StartScriptV2FeatureFlagTiKVCPUQuota},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				tikvContainer := MapContainers(&sts.Spec.Template.Spec)[v1alpha1.TiKVMemberType.String()]
+				g.Expect(tikvContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name: "TIKV_CPU_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: "tikv",
+							Resource:      "limits.cpu",
+							Divisor:       resource.MustParse("1m"),
+						},
+					},
+				}), "Expected the CPU limit of tikv is injected")
+			},
+		},
+		{
+			name: "tikv without cpu limit",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:     resource.MustParse("1"),
+								corev1.ResourceStorage: resource.MustParse("100Gi"),
+							},
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				tikvContainer := MapContainers(&sts.Spec.Template.Spec)[v1alpha1.TiKVMemberType.String()]
+				for _, env := range tikvContainer.Env {
+					g.Expect(env.Name).NotTo(Equal("TIKV_CPU_LIMIT"))
+				}
+			},
+		},
+		{
+			name: "tikv with topology store labels",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("100Gi"),
+							},
+						},
+					},
+					PD:                 &v1alpha1.PDSpec{},
+					TiDB:               &v1alpha1.TiDBSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+					StartScriptV2FeatureFlags: []v1alpha1.StartScriptV2FeatureFlag{
+						v1alpha1.StartScriptV2FeatureFlagTopologyStoreLabels,
+					},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				var items []corev1.DownwardAPIVolumeFile
+				for _, vol := range sts.Spec.Template.Spec.Volumes {
+					if vol.Name == "annotations" {
+						items = vol.DownwardAPI.Items
+					}
+				}
+				g.Expect(items).To(ContainElement(corev1.DownwardAPIVolumeFile{
+					Path:     "labels",
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+				}), "Expected the pod labels are mounted to tikv")
+			},
+		},
+		{
+			name: "tikv with block cache sized by memory limit",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("100Gi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("16Gi"),
+							},
+						},
+						BlockCacheMemoryPercent: 40,
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				tikvContainer := MapContainers(&sts.Spec.Template.Spec)[v1alpha1.TiKVMemberType.String()]
+				g.Expect(tikvContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name: "TIKV_MEMORY_LIMIT",
+					ValueFrom: &corev1.EnvVarSource{
+						ResourceFieldRef: &corev1.ResourceFieldSelector{
+							ContainerName: "tikv",
+							Resource:      "limits.memory",
+							Divisor:       resource.MustParse("1"),
+						},
+					},
+				}), "Expected the memory limit of tikv is injected")
+			},
+		},
+		{
+			name: "tikv with pod labels as store labels",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse("100Gi"),
+							},
+						},
+						PodLabelsAsStoreLabels: []string{"rack"},
+					},
+					PD:                 &v1alpha1.PDSpec{},
+					TiDB:               &v1alpha1.TiDBSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				var items []corev1.DownwardAPIVolumeFile
+				for _, vol := range sts.Spec.Template.Spec.Volumes {
+					if vol.Name == "annotations" {
+						items = vol.DownwardAPI.Items
+					}
+				}
+				g.Expect(items).To(ContainElement(corev1.DownwardAPIVolumeFile{
+					Path:     "labels",
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+				}), "Expected the pod labels are mounted to tikv")
 			},
 		},
 		{
@@ -2140,6 +2367,97 @@ func TestGetNewTiKVSetForTidbCluster(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "TiKV encryption master keys from secrets",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						EncryptionConfig: &v1alpha1.TiKVEncryptionSecretConfig{
+							MasterKeySecretName:         "master-key",
+							PreviousMasterKeySecretName: "previous-master-key",
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Volumes).To(ContainElements(
+					corev1.Volume{Name: "tikv-encryption-master-key", VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: "master-key"},
+					}},
+					corev1.Volume{Name: "tikv-encryption-previous-master-key", VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: "previous-master-key"},
+					}},
+				))
+				g.Expect(sts.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElements(
+					corev1.VolumeMount{Name: "tikv-encryption-master-key", ReadOnly: true, MountPath: "/var/lib/tikv-encryption/master-key"},
+					corev1.VolumeMount{Name: "tikv-encryption-previous-master-key", ReadOnly: true, MountPath: "/var/lib/tikv-encryption/previous-master-key"},
+				))
+			},
+		},
+		{
+			name: "TiKV preStop script",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						PreStop: &v1alpha1.TiKVPreStopSpec{},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Volumes).To(ContainElement(
+					corev1.Volume{Name: "startup-script", VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: controller.TiKVMemberName("tc")},
+							Items: []corev1.KeyToPath{
+								{Key: "startup-script", Path: "tikv_start_script.sh"},
+								{Key: "prestop-script", Path: "tikv_prestop_script.sh"},
+							},
+						},
+					}},
+				))
+				g.Expect(sts.Spec.Template.Spec.Containers[0].Lifecycle).To(Equal(&corev1.Lifecycle{
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "/usr/local/bin/tikv_prestop_script.sh"}},
+					},
+				}))
+			},
+		},
+		{
+			name: "TiKV preStop script with custom shell",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						PreStop: &v1alpha1.TiKVPreStopSpec{},
+					},
+					PD:                 &v1alpha1.PDSpec{},
+					TiDB:               &v1alpha1.TiDBSpec{},
+					StartScriptVersion: v1alpha1.StartScriptV2,
+					StartScriptV2Shell: "/bin/bash",
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.Containers[0].Lifecycle.PreStop.Exec.Command).To(Equal([]string{"/bin/bash", "/usr/local/bin/tikv_prestop_script.sh"}))
+			},
+		},
 		{
 			name: "TiKV VolumeReplace modifications to sts",
 			tc: v1alpha1.TidbCluster{
@@ -2617,73 +2935,482 @@ func TestGetTiKVConfigMap(t *testing.T) {
 				},
 			},
 		},
-	}
-
-	for i := range testCases {
-		tt := &testCases[i]
-		t.Run(tt.name, func(t *testing.T) {
-			cm, err := getTikVConfigMap(&tt.tc)
-			g.Expect(err).To(Succeed())
-			if tt.expected == nil {
-				g.Expect(cm).To(BeNil())
-				return
-			}
-			// startup-script is better to be tested in e2e
-			cm.Data["startup-script"] = ""
-
-			got := cm.Data["config-file"]
-			want := tt.expected.Data["config-file"]
-			g.Expect(toml.Equal([]byte(got), []byte(want))).To(BeTrue())
-			delete(cm.Data, "config-file")
-			delete(tt.expected.Data, "config-file")
-
-			if diff := cmp.Diff(*tt.expected, *cm); diff != "" {
-				t.Errorf("unexpected plugin configuration (-want, +got): %s", diff)
-			}
-		})
-	}
-}
-
-func TestTransformTiKVConfigMap(t *testing.T) {
-	g := NewGomegaWithT(t)
-	type testcase struct {
-		name                string
-		waitForLockTimeout  string
-		wakeUpDelayDuration string
-		result              string
-	}
-	tests := []testcase{
-		{
-			name:                "under 4.0",
-			waitForLockTimeout:  "1000",
-			wakeUpDelayDuration: "20",
-			result: `[pessimistic-txn]
-  wait-for-lock-timeout = 1000
-  wake-up-delay-duration = 20
-`,
-		},
 		{
-			name:                "4.0.0",
-			waitForLockTimeout:  "1s",
-			wakeUpDelayDuration: "20ms",
-			result: `[pessimistic-txn]
-  wait-for-lock-timeout = "1s"
-  wake-up-delay-duration = "20ms"
-`,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			tc := newTidbClusterForTiKV()
-			tc.Spec.TiKV.Config.Set("pessimistic-txn.wait-for-lock-timeout", test.waitForLockTimeout)
-			tc.Spec.TiKV.Config.Set("pessimistic-txn.wake-up-delay-duration", test.wakeUpDelayDuration)
-			confText, err := tc.Spec.TiKV.Config.MarshalTOML()
-			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(test.result).Should(Equal(transformTiKVConfigMap(string(confText), tc)))
-		})
-	}
-}
+			name: "TiKV encryption master keys from secrets",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config: mustTiKVConfig(&v1alpha1.TiKVConfig{
+							Security: &v1alpha1.TiKVSecurityConfig{
+								Encryption: &v1alpha1.TiKVSecurityConfigEncryption{
+									DataEncryptionMethod: pointer.StringPtr("aes128-ctr"),
+								},
+							},
+						}),
+						EncryptionConfig: &v1alpha1.TiKVEncryptionSecretConfig{
+							MasterKeySecretName:         "master-key",
+							PreviousMasterKeySecretName: "previous-master-key",
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[security]
+  [security.encryption]
+    data-encryption-method = "aes128-ctr"
+    [security.encryption.master-key]
+      type = "file"
+      path = "/var/lib/tikv-encryption/master-key/master-key"
+    [security.encryption.previous-master-key]
+      type = "file"
+      path = "/var/lib/tikv-encryption/previous-master-key/master-key"
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV titan volume",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config: mustTiKVConfig(&v1alpha1.TiKVConfig{
+							Rocksdb: &v1alpha1.TiKVDbConfig{
+								Titan: &v1alpha1.TiKVTitanDBConfig{
+									Enabled: pointer.BoolPtr(true),
+								},
+							},
+						}),
+						StorageVolumes:  []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}},
+						TitanVolumeName: "titan",
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[rocksdb]
+  [rocksdb.titan]
+    enabled = true
+    dirname = "/var/lib/titan"
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV wal volume",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config:         v1alpha1.NewTiKVConfig(),
+						StorageVolumes: []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "1Gi", MountPath: "/var/lib/wal"}},
+						WALVolumeName:  "wal",
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[rocksdb]
+  wal-dir = "/var/lib/wal"
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV raft volume",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config:           v1alpha1.NewTiKVConfig(),
+						StorageVolumes:   []v1alpha1.StorageVolume{{Name: "raft", StorageSize: "1Gi", MountPath: "/var/lib/raft"}},
+						RaftEngineSubDir: "raft-engine",
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[raft-engine]
+  dir = "/var/lib/raft/raft-engine"
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV disable status server",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config:              v1alpha1.NewTiKVConfig(),
+						DisableStatusServer: true,
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[server]
+  status-addr = ""
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV titan volume with dirname in config",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config: mustTiKVConfig(&v1alpha1.TiKVConfig{
+							Rocksdb: &v1alpha1.TiKVDbConfig{
+								Titan: &v1alpha1.TiKVTitanDBConfig{
+									Enabled: pointer.BoolPtr(true),
+									Dirname: pointer.StringPtr("/var/lib/titan/blob"),
+								},
+							},
+						}),
+						StorageVolumes:  []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}},
+						TitanVolumeName: "titan",
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[rocksdb]
+  [rocksdb.titan]
+    enabled = true
+    dirname = "/var/lib/titan/blob"
+`,
+				},
+			},
+		},
+		{
+			name: "TiKV titan volume when titan is disabled",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ConfigUpdateStrategy: &updateStrategy,
+						},
+						Config: mustTiKVConfig(&v1alpha1.TiKVConfig{
+							Rocksdb: &v1alpha1.TiKVDbConfig{
+								Titan: &v1alpha1.TiKVTitanDBConfig{
+									Enabled: pointer.BoolPtr(false),
+								},
+							},
+						}),
+						StorageVolumes:  []v1alpha1.StorageVolume{{Name: "titan", StorageSize: "1Gi", MountPath: "/var/lib/titan"}},
+						TitanVolumeName: "titan",
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-tikv",
+					Namespace: "ns",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "tidb-cluster",
+						"app.kubernetes.io/managed-by": "tidb-operator",
+						"app.kubernetes.io/instance":   "foo",
+						"app.kubernetes.io/component":  "tikv",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "pingcap.com/v1alpha1",
+							Kind:       "TidbCluster",
+							Name:       "foo",
+							UID:        "",
+							Controller: func(b bool) *bool {
+								return &b
+							}(true),
+							BlockOwnerDeletion: func(b bool) *bool {
+								return &b
+							}(true),
+						},
+					},
+				},
+				Data: map[string]string{
+					"startup-script": "",
+					"config-file": `[rocksdb]
+  [rocksdb.titan]
+    enabled = false
+`,
+				},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tt := &testCases[i]
+		t.Run(tt.name, func(t *testing.T) {
+			cm, err := getTikVConfigMap(&tt.tc)
+			g.Expect(err).To(Succeed())
+			if tt.expected == nil {
+				g.Expect(cm).To(BeNil())
+				return
+			}
+			// startup-script is better to be tested in e2e
+			cm.Data["startup-script"] = ""
+
+			got := cm.Data["config-file"]
+			want := tt.expected.Data["config-file"]
+			g.Expect(toml.Equal([]byte(got), []byte(want))).To(BeTrue())
+			delete(cm.Data, "config-file")
+			delete(tt.expected.Data, "config-file")
+
+			if diff := cmp.Diff(*tt.expected, *cm); diff != "" {
+				t.Errorf("unexpected plugin configuration (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestTransformTiKVConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name                string
+		waitForLockTimeout  string
+		wakeUpDelayDuration string
+		result              string
+	}
+	tests := []testcase{
+		{
+			name:                "under 4.0",
+			waitForLockTimeout:  "1000",
+			wakeUpDelayDuration: "20",
+			result: `[pessimistic-txn]
+  wait-for-lock-timeout = 1000
+  wake-up-delay-duration = 20
+`,
+		},
+		{
+			name:                "4.0.0",
+			waitForLockTimeout:  "1s",
+			wakeUpDelayDuration: "20ms",
+			result: `[pessimistic-txn]
+  wait-for-lock-timeout = "1s"
+  wake-up-delay-duration = "20ms"
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForTiKV()
+			tc.Spec.TiKV.Config.Set("pessimistic-txn.wait-for-lock-timeout", test.waitForLockTimeout)
+			tc.Spec.TiKV.Config.Set("pessimistic-txn.wake-up-delay-duration", test.wakeUpDelayDuration)
+			confText, err := tc.Spec.TiKV.Config.MarshalTOML()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(test.result).Should(Equal(transformTiKVConfigMap(string(confText), tc)))
+		})
+	}
+}
 
 func TestTiKVBackupConfig(t *testing.T) {
 	g := NewGomegaWithT(t)
@@ -2752,3 +3479,18 @@ func mustTiKVConfig(x interface{}) *v1alpha1.TiKVConfigWraper {
 
 	return c
 }
+
+func TestGetTiKVConfigMapWithInvalidStartScript(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiKV()
+	tc.Spec.StartScriptVersion = v1alpha1.StartScriptV2
+	tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+	tc.Spec.TiKV.DataSubDir = "../data"
+
+	// the kind of the error is kept, so that the controller can tell it from the others
+	_, err := getTikVConfigMap(tc)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(stderrs.Is(err, startscriptv2.ErrInvalidDataSubDir)).To(BeTrue())
+	g.Expect(stderrs.Is(err, startscriptv2.ErrModelValidation)).To(BeFalse())
+}
@@ -103,12 +103,9 @@ func (m *tiproxyMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 }
 
 func (m *tiproxyMemberManager) syncConfigMap(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) (*corev1.ConfigMap, error) {
+	// if the cluster is deployed across k8s, the PD address is replaced by the start script at runtime
 	PDAddr := fmt.Sprintf("%s:%d", controller.PDMemberName(tc.Name), v1alpha1.DefaultPDClientPort)
-	// TODO: support it
-	if tc.AcrossK8s() {
-		return nil, fmt.Errorf("across k8s is not supported for")
-	}
-	if tc.Heterogeneous() && tc.WithoutLocalPD() {
+	if !tc.AcrossK8s() && tc.Heterogeneous() && tc.WithoutLocalPD() {
 		PDAddr = fmt.Sprintf("%s:%d", controller.PDMemberName(tc.Spec.Cluster.Name), v1alpha1.DefaultPDClientPort) // use pd of reference cluster
 	}
 
@@ -124,6 +121,14 @@ func (m *tiproxyMemberManager) syncConfigMap(tc *v1alpha1.TidbCluster, set *apps
 	if cfgWrapper.Get("proxy.require-backend-tls") == nil {
 		cfgWrapper.Set("proxy.require-backend-tls", false)
 	}
+	if tc.Spec.PreferIPv6 {
+		if cfgWrapper.Get("proxy.addr") == nil {
+			cfgWrapper.Set("proxy.addr", "[::]:6000")
+		}
+		if cfgWrapper.Get("api.addr") == nil {
+			cfgWrapper.Set("api.addr", "[::]:3080")
+		}
+	}
 
 	if tc.IsTLSClusterEnabled() {
 		cfgWrapper.Set("security.cluster-tls.ca", path.Join(util.ClusterClientTLSPath, "ca.crt"))
@@ -495,7 +500,7 @@ func (m *tiproxyMemberManager) getNewStatefulSet(tc *v1alpha1.TidbCluster, cm *c
 		Name:            v1alpha1.TiProxyMemberType.String(),
 		Image:           tc.TiProxyImage(),
 		ImagePullPolicy: baseTiProxySpec.ImagePullPolicy(),
-		Command:         []string{"/bin/sh", "/etc/proxy/start.sh"},
+		Command:         startscript.StartScriptFileCommand(tc, "/etc/proxy/start.sh"),
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "tiproxy",
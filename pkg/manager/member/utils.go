@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/apis/util/toml"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager/member/constants"
 	"github.com/pingcap/tidb-operator/pkg/manager/member/startscript"
 	"github.com/pingcap/tidb-operator/pkg/util"
 
@@ -34,6 +36,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -60,7 +63,7 @@ var (
 )
 
 func annotationsMountVolume() (corev1.VolumeMount, corev1.Volume) {
-	m := corev1.VolumeMount{Name: "annotations", ReadOnly: true, MountPath: "/etc/podinfo"}
+	m := corev1.VolumeMount{Name: "annotations", ReadOnly: true, MountPath: constants.PodInfoMountPath}
 	v := corev1.Volume{
 		Name: "annotations",
 		VolumeSource: corev1.VolumeSource{
@@ -248,6 +251,30 @@ func getTikVConfigMapForTiKVSpec(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbC
 		config.Set("security.cert-path", path.Join(tikvClusterCertPath, corev1.TLSCertKey))
 		config.Set("security.key-path", path.Join(tikvClusterCertPath, corev1.TLSPrivateKeyKey))
 	}
+	if ec := tikvSpec.EncryptionConfig; ec != nil {
+		// the master keys in the config are rejected by the validation, they are not overwritten in case the validation is skipped
+		config.SetIfNil("security.encryption.master-key.type", "file")
+		config.SetIfNil("security.encryption.master-key.path",
+			path.Join(constants.TiKVEncryptionMasterKeyMountPath, constants.TiKVEncryptionMasterKeySecretKey))
+		if ec.PreviousMasterKeySecretName != "" {
+			config.SetIfNil("security.encryption.previous-master-key.type", "file")
+			config.SetIfNil("security.encryption.previous-master-key.path",
+				path.Join(constants.TiKVEncryptionPreviousMasterKeyMountPath, constants.TiKVEncryptionMasterKeySecretKey))
+		}
+	}
+	if tikvSpec.DisableStatusServer {
+		// TiKV does not start the status server if the address is empty
+		config.Set("server.status-addr", "")
+	}
+	if dir := tikvSpec.WalDir(); dir != "" {
+		config.SetIfNil("rocksdb.wal-dir", dir)
+	}
+	if dir := tikvSpec.TitanDir(); dir != "" {
+		config.SetIfNil("rocksdb.titan.dirname", dir)
+	}
+	if dir := tikvSpec.RaftEngineDir(); dir != "" {
+		config.SetIfNil("raft-engine.dir", dir)
+	}
 	confText, err := config.MarshalTOML()
 	if err != nil {
 		return nil, err
@@ -255,7 +282,7 @@ func getTikVConfigMapForTiKVSpec(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbC
 
 	startScript, err := startscript.RenderTiKVStartScript(tc)
 	if err != nil {
-		return nil, fmt.Errorf("render start-script for tc %s/%s failed: %v", tc.Namespace, tc.Name, err)
+		return nil, fmt.Errorf("render start-script for tc %s/%s failed: %w", tc.Namespace, tc.Name, err)
 	}
 
 	cm := &corev1.ConfigMap{
@@ -264,6 +291,13 @@ func getTikVConfigMapForTiKVSpec(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbC
 			"startup-script": startScript,
 		},
 	}
+	if tikvSpec.PreStop != nil {
+		preStopScript, err := startscript.RenderTiKVPreStopScript(tc)
+		if err != nil {
+			return nil, fmt.Errorf("render prestop-script for tc %s/%s failed: %v", tc.Namespace, tc.Name, err)
+		}
+		cm.Data["prestop-script"] = preStopScript
+	}
 	return cm, nil
 }
 
@@ -599,3 +633,44 @@ func SetServiceWhenPreferIPv6(svc *corev1.Service) {
 	policy := corev1.IPFamilyPolicyPreferDualStack
 	svc.Spec.IPFamilyPolicy = &policy
 }
+
+// cpuLimitCoresEnv returns the env of the CPU limit of the container rounded up to cores, start scripts of
+// Go components export it as GOMAXPROCS. It is empty unless the GoMaxProcs feature flag is set and the limit is configured.
+func cpuLimitCoresEnv(tc *v1alpha1.TidbCluster, containerName string, limits corev1.ResourceList) []corev1.EnvVar {
+	if !slices.Contains(tc.Spec.StartScriptV2FeatureFlags, v1alpha1.StartScriptV2FeatureFlagGoMaxProcs) {
+		return nil
+	}
+	if _, ok := limits[corev1.ResourceCPU]; !ok {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{
+			Name: constants.CPULimitCoresEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: containerName,
+					Resource:      "limits.cpu",
+					Divisor:       resource.MustParse("1"),
+				},
+			},
+		},
+	}
+}
+
+// checkRequiredEnvVars checks that the env vars required by the start script are set in env.
+// The env from EnvFrom is resolved by kubelet, so the check is skipped if it is used.
+func checkRequiredEnvVars(env []corev1.EnvVar, envFrom []corev1.EnvFromSource, required []string) error {
+	if len(envFrom) != 0 {
+		return nil
+	}
+	var missing []string
+	for _, name := range required {
+		if !slices.ContainsFunc(env, func(e corev1.EnvVar) bool { return e.Name == name }) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("required env %s not set", strings.Join(missing, ","))
+	}
+	return nil
+}
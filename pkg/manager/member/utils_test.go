@@ -978,3 +978,16 @@ func TestMergePatchContainersOrderPreserved(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckRequiredEnvVars(t *testing.T) {
+	g := NewGomegaWithT(t)
+	env := []v1.EnvVar{{Name: "CAPACITY", Value: "0"}, {Name: "TZ", Value: "UTC"}}
+
+	g.Expect(checkRequiredEnvVars(env, nil, nil)).Should(Succeed())
+	g.Expect(checkRequiredEnvVars(env, nil, []string{"CAPACITY"})).Should(Succeed())
+	err := checkRequiredEnvVars(env, nil, []string{"CAPACITY", "TIKV_CPU_LIMIT", "FOO"})
+	g.Expect(err).Should(MatchError("required env TIKV_CPU_LIMIT,FOO not set"))
+	// the env from EnvFrom can not be checked
+	envFrom := []v1.EnvFromSource{{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "env"}}}}
+	g.Expect(checkRequiredEnvVars(env, envFrom, []string{"FOO"})).Should(Succeed())
+}
@@ -19,7 +19,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
@@ -32,8 +31,8 @@ const (
 
 // TiFlashControlInterface is an interface that knows how to manage and get client for TiFlash
 type TiFlashControlInterface interface {
-	// GetTiFlashPodClient provides TiFlashClient of the TiFlash cluster.
-	GetTiFlashPodClient(namespace string, tcName string, podName string, tlsEnabled bool) TiFlashClient
+	// GetTiFlashPodClient provides TiFlashClient of the TiFlash cluster, statusPort is the proxy status port of TiFlash.
+	GetTiFlashPodClient(namespace string, tcName string, podName string, statusPort int32, tlsEnabled bool) TiFlashClient
 }
 
 // defaultTiFlashControl is the default implementation of TiFlashControlInterface.
@@ -47,7 +46,7 @@ func NewDefaultTiFlashControl(secretLister corelisterv1.SecretLister) TiFlashCon
 	return &defaultTiFlashControl{secretLister: secretLister}
 }
 
-func (tc *defaultTiFlashControl) GetTiFlashPodClient(namespace string, tcName string, podName string, tlsEnabled bool) TiFlashClient {
+func (tc *defaultTiFlashControl) GetTiFlashPodClient(namespace string, tcName string, podName string, statusPort int32, tlsEnabled bool) TiFlashClient {
 	tc.mutex.Lock()
 	defer tc.mutex.Unlock()
 
@@ -60,13 +59,13 @@ func (tc *defaultTiFlashControl) GetTiFlashPodClient(namespace string, tcName st
 		tlsConfig, err = pdapi.GetTLSConfig(tc.secretLister, pdapi.Namespace(namespace), util.ClusterClientTLSSecretName(tcName))
 		if err != nil {
 			klog.Errorf("Unable to get tls config for TiFlash cluster %q, tiflash client may not work: %v", tcName, err)
-			return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme), DefaultTimeout, tlsConfig, true)
+			return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme, statusPort), DefaultTimeout, tlsConfig, true)
 		}
 
-		return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme), DefaultTimeout, tlsConfig, true)
+		return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme, statusPort), DefaultTimeout, tlsConfig, true)
 	}
 
-	return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme), DefaultTimeout, tlsConfig, true)
+	return NewTiFlashClient(TiFlashPodClientURL(namespace, tcName, podName, scheme, statusPort), DefaultTimeout, tlsConfig, true)
 }
 
 func tiflashPodClientKey(schema, namespace, clusterName, podName string) string {
@@ -74,8 +73,8 @@ func tiflashPodClientKey(schema, namespace, clusterName, podName string) string
 }
 
 // TiFlashPodClientURL builds the url of tiflash pod client
-func TiFlashPodClientURL(namespace, clusterName, podName, scheme string) string {
-	return fmt.Sprintf("%s://%s.%s-tiflash-peer.%s:%d", scheme, podName, clusterName, namespace, v1alpha1.DefaultTiFlashProxyStatusPort)
+func TiFlashPodClientURL(namespace, clusterName, podName, scheme string, statusPort int32) string {
+	return fmt.Sprintf("%s://%s.%s-tiflash-peer.%s:%d", scheme, podName, clusterName, namespace, statusPort)
 }
 
 // FakeTiFlashControl implements a fake version of TiFlashControlInterface.
@@ -95,6 +94,6 @@ func (ftc *FakeTiFlashControl) SetTiFlashPodClient(namespace, tcName, podName st
 	ftc.tiflashPodClients[tiflashPodClientKey("http", namespace, tcName, podName)] = tiflashPodClient
 }
 
-func (ftc *FakeTiFlashControl) GetTiFlashPodClient(namespace, tcName, podName string, tlsEnabled bool) TiFlashClient {
+func (ftc *FakeTiFlashControl) GetTiFlashPodClient(namespace, tcName, podName string, statusPort int32, tlsEnabled bool) TiFlashClient {
 	return ftc.tiflashPodClients[tiflashPodClientKey("http", namespace, tcName, podName)]
 }